@@ -1,8 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,13 +19,20 @@ import (
 	appauth "voc-go-backend/internal/application/auth"
 	docs "voc-go-backend/docs"
 	"voc-go-backend/internal/infrastructure/cache"
+	"voc-go-backend/internal/infrastructure/clientinfo"
+	clientdomain "voc-go-backend/internal/domain/client"
+	optiondomain "voc-go-backend/internal/domain/option"
 	rbacdomain "voc-go-backend/internal/domain/rbac"
+	syslogdomain "voc-go-backend/internal/domain/syslog"
 	"voc-go-backend/internal/domain/user"
 	"voc-go-backend/internal/infrastructure/db"
+	clientp "voc-go-backend/internal/infrastructure/persistence/client"
+	optionp "voc-go-backend/internal/infrastructure/persistence/option"
 	rbacp "voc-go-backend/internal/infrastructure/persistence/rbac"
 	syslogp "voc-go-backend/internal/infrastructure/persistence/syslog"
 	persistence "voc-go-backend/internal/infrastructure/persistence/user"
 	"voc-go-backend/internal/infrastructure/security"
+	"voc-go-backend/internal/infrastructure/storage"
 	httpif "voc-go-backend/internal/interfaces/http"
 )
 
@@ -32,6 +46,10 @@ import (
 // @name Authorization
 
 func main() {
+	// 进程退出信号（Ctrl+C / SIGTERM），用于停止后台任务并优雅停机
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// 1. 初始化数据库连接（PostgreSQL）
 	dbCfg := db.LoadConfigFromEnv()
 	pg, err := db.NewPostgres(dbCfg)
@@ -65,17 +83,29 @@ func main() {
 	pwdHasher := security.BcryptHasher{}
 
 	jwtSecret := getenvDefault("AUTH_JWT_SECRET", "asdasdasifhueuiwyurfewbfjsdafjk")
+	// 默认 Token 有效期，仅在客户端 timeout 为 -1（不限制）时使用；
+	// 实际有效期由登录客户端 sys_client.timeout 决定。
 	tokenTTL := 24 * time.Hour
 	tokenSvc := security.NewTokenService(jwtSecret, tokenTTL)
+	// Token 吊销列表（Redis）：登出/强退后 Token 立即失效，多实例共享。
+	tokenSvc.SetRevocationStore(cache.NewRedisTokenRevocationStore(redisClient))
 
 	// 3. 初始化领域仓储和应用服务
 	var userRepo user.Repository = persistence.NewPgRepository(pg)
 	var roleRepo rbacdomain.RoleRepository = rbacp.NewPgRoleRepository(pg)
 	var menuRepo rbacdomain.MenuRepository = rbacp.NewPgMenuRepository(pg)
-	authSvc := appauth.NewService(userRepo, rsaDecryptor, pwdVerifier, tokenSvc)
+	var clientRepo clientdomain.Repository = clientp.NewPgRepository(pg)
+	var optionRepo optiondomain.Repository = optionp.NewPgRepository(pg)
+	// 登录失败计数（Redis）：按 PASSWORD_ERROR_LOCK_* 配置锁定账号。
+	loginAttempts := cache.NewRedisLoginAttemptStore(redisClient)
+	// 密码策略：读取 sys_option 中的 PASSWORD_* 配置，校验复杂度、历史密码与有效期。
+	pwdPolicy := security.NewPasswordPolicyValidator(optionRepo, persistence.NewPgPasswordHistoryRepository(pg), pwdVerifier)
+	authSvc := appauth.NewService(userRepo, clientRepo, optionRepo, loginAttempts, rsaDecryptor, pwdVerifier, tokenSvc)
 
 	// 4. 初始化 HTTP 服务（Gin）
-	r := gin.Default()
+	// 链路 ID 中间件最先执行，访问日志、系统日志与接口响应均携带同一链路 ID。
+	r := gin.New()
+	r.Use(httpif.NewTraceMiddleware(), gin.LoggerWithFormatter(httpif.TraceLogFormatter), gin.Recovery())
 
 	// 全局 CORS（开发阶段允许前端本地调试）
 	r.Use(func(c *gin.Context) {
@@ -86,7 +116,8 @@ func main() {
 			c.Writer.Header().Set("Vary", "Origin")
 		}
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, "+httpif.TraceIDHeader)
+		c.Writer.Header().Set("Access-Control-Expose-Headers", httpif.TraceIDHeader)
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 
 		// 预检请求直接返回
@@ -98,85 +129,142 @@ func main() {
 		c.Next()
 	})
 
+	// 请求来源解析：IP 归属地使用 ip2region 离线库（IP2REGION_DB_PATH，默认 ./data/ip2region.xdb），
+	// 文件不存在时仅识别内网地址；浏览器与操作系统由 User-Agent 解析。系统日志与在线用户共用。
+	var ipRegion *clientinfo.IPRegion
+	ipRegionPath := getenvDefault("IP2REGION_DB_PATH", "./data/ip2region.xdb")
+	if ipRegion, err = clientinfo.LoadIPRegion(ipRegionPath); err != nil {
+		log.Printf("ip region database unavailable, address lookup disabled: %v", err)
+	}
+	clientResolver := clientinfo.NewResolver(ipRegion)
+
 	// 系统操作日志中间件：在业务处理前后统一记录 sys_log。
-	sysLogRepo := syslogp.NewPgRepository(pg)
-	r.Use(httpif.NewSysLogMiddleware(sysLogRepo, tokenSvc))
+	// 默认屏蔽认证相关请求头及密码、密钥、令牌字段，
+	// 可通过 SYSLOG_MASK_HEADERS / SYSLOG_MASK_FIELDS（逗号分隔，字段支持 data.token 形式的路径）追加。
+	// 日志异步批量写入，队列已满时丢弃，避免数据库变慢拖慢请求；
+	// 可通过 SYSLOG_QUEUE_SIZE、SYSLOG_BATCH_SIZE、SYSLOG_FLUSH_INTERVAL（如 1s）调整。
+	sysLogRepo := syslogp.NewAsyncRepository(syslogp.NewPgRepository(pg), syslogp.AsyncOptions{
+		QueueSize:     getenvInt("SYSLOG_QUEUE_SIZE", 0),
+		BatchSize:     getenvInt("SYSLOG_BATCH_SIZE", 0),
+		FlushInterval: getenvDuration("SYSLOG_FLUSH_INTERVAL", 0),
+	})
+	maskCfg := syslogdomain.DefaultMaskConfig()
+	maskCfg.Headers = append(maskCfg.Headers, getenvList("SYSLOG_MASK_HEADERS")...)
+	maskCfg.Fields = append(maskCfg.Fields, getenvList("SYSLOG_MASK_FIELDS")...)
+	r.Use(httpif.NewSysLogMiddleware(sysLogRepo, tokenSvc, syslogdomain.NewMasker(maskCfg), clientResolver))
+
+	// 在线用户存储：默认使用 Redis（多实例共享、重启不丢失），
+	// 本地开发可设置 ONLINE_STORE=memory 使用进程内存储。
+	var onlineStore httpif.OnlineStore
+	if getenvDefault("ONLINE_STORE", "redis") == "memory" {
+		onlineStore = httpif.NewMemoryOnlineStore()
+	} else {
+		onlineStore = httpif.NewRedisOnlineStore(redisClient)
+	}
 
-	// 在线用户内存存储（仅当前进程有效）
-	onlineStore := httpif.NewOnlineStore()
+	// 接口鉴权中间件：按 sys_menu 权限码校验访问权限，admin 角色直接放行；
+	// 同时刷新在线会话活跃时间并执行空闲超时。
+	authMw := httpif.NewAuthMiddleware(tokenSvc, roleRepo, menuRepo, onlineStore)
+	// 密码过期的用户仅允许访问修改密码接口。
+	authMw.EnforcePasswordExpiry(userRepo, pwdPolicy)
 
 	// 公共接口
 	commonHandler := httpif.NewCommonHandler(pg)
-	commonHandler.RegisterCommonRoutes(r)
+	commonHandler.RegisterCommonRoutes(r, authMw)
 
 	// 验证码接口（登录图片验证码）
 	captchaHandler := httpif.NewCaptchaHandler(pg, redisClient)
 	captchaHandler.RegisterCaptchaRoutes(r)
 
 	// 登录与用户接口
-	authHandler := httpif.NewAuthHandler(authSvc, onlineStore, pg, redisClient)
+	authHandler := httpif.NewAuthHandler(authSvc, onlineStore, pg, redisClient, clientResolver)
 	authHandler.RegisterAuthRoutes(r)
-	userHandler := httpif.NewUserHandler(userRepo, roleRepo, menuRepo, tokenSvc)
+	userHandler := httpif.NewUserHandler(userRepo, roleRepo, menuRepo, tokenSvc, pwdPolicy)
 	userHandler.RegisterUserRoutes(r)
 
 	// 系统监控：在线用户
 	onlineUserHandler := httpif.NewOnlineUserHandler(onlineStore, tokenSvc)
-	onlineUserHandler.RegisterOnlineUserRoutes(r)
+	onlineUserHandler.RegisterOnlineUserRoutes(r, authMw)
 
 	// 系统管理：菜单管理
 	menuHandler := httpif.NewMenuHandler(pg, tokenSvc)
-	menuHandler.RegisterMenuRoutes(r)
+	menuHandler.RegisterMenuRoutes(r, authMw)
 
 	// 系统管理：角色管理
 	roleHandler := httpif.NewRoleHandler(pg, tokenSvc)
-	roleHandler.RegisterRoleRoutes(r)
+	roleHandler.RegisterRoleRoutes(r, authMw)
 
 	// 系统管理：部门管理（仅树查询）
 	deptHandler := httpif.NewDeptHandler(pg, tokenSvc)
-	deptHandler.RegisterDeptRoutes(r)
+	deptHandler.RegisterDeptRoutes(r, authMw)
 
 	// 系统管理：用户管理
-	systemUserHandler := httpif.NewSystemUserHandler(pg, tokenSvc, rsaDecryptor, pwdHasher)
-	systemUserHandler.RegisterSystemUserRoutes(r)
+	systemUserHandler := httpif.NewSystemUserHandler(pg, userRepo, tokenSvc, rsaDecryptor, pwdHasher, pwdPolicy, redisClient, onlineStore)
+	systemUserHandler.RegisterSystemUserRoutes(r, authMw)
 
 	// 系统管理：字典管理
 	dictHandler := httpif.NewDictHandler(pg, tokenSvc)
-	dictHandler.RegisterDictRoutes(r)
+	dictHandler.RegisterDictRoutes(r, authMw)
 
 	// 系统管理：系统配置（参数管理）
 	optionHandler := httpif.NewOptionHandler(pg, tokenSvc)
-	optionHandler.RegisterOptionRoutes(r)
+	optionHandler.RegisterOptionRoutes(r, authMw)
+
+	// 系统管理：文件管理（存储驱动按存储配置 ID 缓存，文件与存储配置接口共用）
+	storageManager := storage.NewManager()
+	fileHandler := httpif.NewFileHandler(pg, tokenSvc, storageManager, redisClient)
+	fileHandler.RegisterFileRoutes(r, authMw)
+	// 回收站：每小时清理超过保留天数的文件
+	fileHandler.StartRecyclePurge(ctx, time.Hour)
+	// 分片上传：每小时中止超过有效期仍未完成的上传
+	fileHandler.StartChunkUploadPurge(ctx, time.Hour)
 
-	// 系统管理：文件管理
-	fileHandler := httpif.NewFileHandler(pg, tokenSvc)
-	fileHandler.RegisterFileRoutes(r)
+	// 个人中心：基础信息、头像、密码、手机号、邮箱
+	userProfileHandler := httpif.NewUserProfileHandler(userRepo, rsaDecryptor, pwdHasher, pwdVerifier, pwdPolicy, fileHandler, tokenSvc, onlineStore)
+	userProfileHandler.RegisterUserProfileRoutes(r, authMw)
 
-	// 系统管理：存储配置（需要 RSA 解密存储密钥）
-	storageHandler := httpif.NewStorageHandler(pg, tokenSvc, rsaDecryptor)
-	storageHandler.RegisterStorageRoutes(r)
+	// 系统管理：存储配置（需要 RSA 解密存储密钥；存储迁移复用文件管理的对象读写）
+	storageHandler := httpif.NewStorageHandler(pg, tokenSvc, rsaDecryptor, storageManager, fileHandler, redisClient)
+	storageHandler.RegisterStorageRoutes(r, authMw)
 
 	// 系统管理：客户端配置
 	clientHandler := httpif.NewClientHandler(pg, tokenSvc)
-	clientHandler.RegisterClientRoutes(r)
+	clientHandler.RegisterClientRoutes(r, authMw)
 
 	// 系统监控：系统日志
-	logHandler := httpif.NewLogHandler(pg)
-	logHandler.RegisterLogRoutes(r)
+	logHandler := httpif.NewLogHandler(pg, sysLogRepo)
+	logHandler.RegisterLogRoutes(r, authMw)
 
-	// 静态文件访问（上传文件）
+	// 本地存储文件访问（上传文件），关闭公开访问的存储不在此输出
 	fileRoot := getenvDefault("FILE_STORAGE_DIR", "./data/file")
-	r.Static("/file", fileRoot)
+	fileHandler.RegisterLocalFileRoutes(r, fileRoot)
 
-	// Swagger 接口文档
+	// Swagger 接口文档（不记录系统日志）
 	// 访问地址示例：http://localhost:4398/swagger/index.html
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	r.GET("/swagger/*any", httpif.NoAudit(), ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// 5. 启动 HTTP 服务
 	port := getenvDefault("HTTP_PORT", "4398")
 	// 在启动前设置 swagger 文档的 Host，便于在 UI 中调试。
 	docs.SwaggerInfo.Host = "localhost:" + port
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("failed to start http server: %v", err)
+	srv := &http.Server{Addr: ":" + port, Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("failed to start http server: %v", err)
+		}
+	}()
+
+	// 6. 收到退出信号后优雅停机：等待处理中的请求结束，再写入队列中剩余的系统日志
+	<-ctx.Done()
+	stop()
+	log.Printf("shutting down http server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("http server shutdown: %v", err)
+	}
+	if err := sysLogRepo.Close(shutdownCtx); err != nil {
+		log.Printf("[syslog] flush on shutdown: %v (queued=%d)", err, sysLogRepo.Stats().Queued)
 	}
 }
 
@@ -186,3 +274,30 @@ func getenvDefault(key, def string) string {
 	}
 	return def
 }
+
+// getenvList 读取逗号分隔的环境变量，忽略空项。
+func getenvList(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// getenvInt 读取整数环境变量，未设置或格式不正确时返回默认值。
+func getenvInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+// getenvDuration 读取时长环境变量（如 500ms、2s），未设置或格式不正确时返回默认值。
+func getenvDuration(key string, def time.Duration) time.Duration {
+	if v, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	optionLockCount   = "PASSWORD_ERROR_LOCK_COUNT"
+	optionLockMinutes = "PASSWORD_ERROR_LOCK_MINUTES"
+
+	// ipLockMultiplier 同一 IP 的失败阈值为账号阈值的倍数，
+	// 用于拦截使用大量不同用户名的撞库请求，同时避免误锁共享出口 IP 的正常用户。
+	ipLockMultiplier = 5
+)
+
+// LoginAttemptStore records login attempts per subject
+// (USER:{username} / IP:{ip}); the counter expires after the lock window.
+// Attempts are reserved before the password is verified, so concurrent requests
+// cannot all pass the check while the counter is still below the limit.
+type LoginAttemptStore interface {
+	// Reserve atomically increments the counter and returns the new count and its TTL.
+	// The TTL is reset to window only while the count is within limit, so requests
+	// rejected during a lock do not extend it.
+	Reserve(ctx context.Context, subject string, limit int64, window time.Duration) (int64, time.Duration, error)
+	// Release gives back one reserved attempt that did not fail (e.g. a successful login).
+	Release(ctx context.Context, subject string) error
+	Reset(ctx context.Context, subject string) error
+}
+
+// lockPolicy 对应 sys_option 中的密码错误锁定配置，count 为 0 表示禁用锁定。
+type lockPolicy struct {
+	count    int64
+	duration time.Duration
+}
+
+func (p lockPolicy) enabled() bool {
+	return p.count > 0 && p.duration > 0
+}
+
+func userLockSubject(username string) string { return "USER:" + username }
+func ipLockSubject(ip string) string         { return "IP:" + ip }
+
+// loadLockPolicy 读取 PASSWORD_ERROR_LOCK_COUNT / PASSWORD_ERROR_LOCK_MINUTES。
+func (s *Service) loadLockPolicy(ctx context.Context) (lockPolicy, error) {
+	if s.options == nil || s.attempts == nil {
+		return lockPolicy{}, nil
+	}
+	values, err := s.options.GetValues(ctx, optionLockCount, optionLockMinutes)
+	if err != nil {
+		return lockPolicy{}, err
+	}
+	return lockPolicy{
+		count:    int64(values.Int(optionLockCount, 5)),
+		duration: time.Duration(values.Int(optionLockMinutes, 5)) * time.Minute,
+	}, nil
+}
+
+// reserveAttempt 在校验密码前为账号与来源 IP 各占用一次尝试次数，超过阈值（处于锁定状态）时拒绝，
+// 返回占用后账号的尝试次数。并发请求依次累加计数，锁定前最多校验 PASSWORD_ERROR_LOCK_COUNT 次。
+func (s *Service) reserveAttempt(ctx context.Context, p lockPolicy, username, ip string) (int64, error) {
+	if !p.enabled() {
+		return 0, nil
+	}
+	n, ttl, err := s.attempts.Reserve(ctx, userLockSubject(username), p.count, p.duration)
+	if err != nil {
+		return 0, err
+	}
+	if n > p.count {
+		return 0, fmt.Errorf("密码错误次数过多，账号已锁定，请 %d 分钟后再试", remainingMinutes(ttl))
+	}
+	if ip == "" {
+		return n, nil
+	}
+	ipN, ttl, err := s.attempts.Reserve(ctx, ipLockSubject(ip), p.count*ipLockMultiplier, p.duration)
+	if err != nil {
+		_ = s.attempts.Release(ctx, userLockSubject(username))
+		return 0, err
+	}
+	if ipN > p.count*ipLockMultiplier {
+		// 未校验密码，归还账号的尝试次数
+		_ = s.attempts.Release(ctx, userLockSubject(username))
+		return 0, fmt.Errorf("登录失败次数过多，请 %d 分钟后再试", remainingMinutes(ttl))
+	}
+	return n, nil
+}
+
+// failureError 返回密码错误时应提示给用户的错误，n 为 reserveAttempt 返回的尝试次数。
+func failureError(p lockPolicy, n int64) error {
+	if p.enabled() && n >= p.count {
+		return fmt.Errorf("密码错误已达 %d 次，账号已锁定 %d 分钟", p.count, remainingMinutes(p.duration))
+	}
+	return errInvalidCredentials
+}
+
+// releaseAttempt 归还未计入失败的尝试（如查询用户出错），不影响已有的失败次数。
+func (s *Service) releaseAttempt(ctx context.Context, p lockPolicy, username, ip string) {
+	if !p.enabled() {
+		return
+	}
+	_ = s.attempts.Release(ctx, userLockSubject(username))
+	if ip != "" {
+		_ = s.attempts.Release(ctx, ipLockSubject(ip))
+	}
+}
+
+// resetFailures 登录成功后清除账号的失败计数，并归还本次占用的 IP 尝试次数（IP 失败计数保留至自然过期）。
+func (s *Service) resetFailures(ctx context.Context, p lockPolicy, username, ip string) error {
+	if !p.enabled() {
+		return nil
+	}
+	if err := s.attempts.Reset(ctx, userLockSubject(username)); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return s.attempts.Release(ctx, ipLockSubject(ip))
+}
+
+// remainingMinutes 将剩余时长向上取整为分钟，至少为 1。
+func remainingMinutes(d time.Duration) int64 {
+	m := int64((d + time.Minute - 1) / time.Minute)
+	if m < 1 {
+		m = 1
+	}
+	return m
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryAttempts 为内存中的 LoginAttemptStore，语义与 Redis 实现一致。
+type memoryAttempts struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func newMemoryAttempts() *memoryAttempts {
+	return &memoryAttempts{counts: make(map[string]int64)}
+}
+
+func (m *memoryAttempts) Reserve(_ context.Context, subject string, _ int64, window time.Duration) (int64, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[subject]++
+	return m.counts[subject], window, nil
+}
+
+func (m *memoryAttempts) Release(_ context.Context, subject string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.counts[subject] > 0 {
+		m.counts[subject]--
+	}
+	return nil
+}
+
+func (m *memoryAttempts) Reset(_ context.Context, subject string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.counts, subject)
+	return nil
+}
+
+func TestReserveAttemptConcurrent(t *testing.T) {
+	store := newMemoryAttempts()
+	s := &Service{attempts: store}
+	p := lockPolicy{count: 5, duration: 5 * time.Minute}
+
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int64
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.reserveAttempt(context.Background(), p, "admin", "10.0.0.1"); err == nil {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := allowed.Load(); got != p.count {
+		t.Fatalf("allowed attempts = %d, want %d", got, p.count)
+	}
+	// 账号已锁定的请求不再占用 IP 次数
+	if got := store.counts[ipLockSubject("10.0.0.1")]; got != p.count {
+		t.Fatalf("ip attempts = %d, want %d", got, p.count)
+	}
+}
+
+func TestReserveAttempt(t *testing.T) {
+	p := lockPolicy{count: 3, duration: time.Minute}
+	tests := []struct {
+		name     string
+		policy   lockPolicy
+		user, ip int64
+		wantN    int64
+		wantErr  bool
+		wantUser int64
+	}{
+		{"disabled", lockPolicy{}, 10, 0, 0, false, 10},
+		{"first attempt", p, 0, 0, 1, false, 1},
+		{"last allowed attempt", p, 2, 0, 3, false, 3},
+		{"user locked", p, 3, 0, 0, true, 4},
+		{"ip locked releases user", p, 0, 15, 0, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryAttempts()
+			store.counts[userLockSubject("admin")] = tt.user
+			store.counts[ipLockSubject("10.0.0.1")] = tt.ip
+			s := &Service{attempts: store}
+
+			n, err := s.reserveAttempt(context.Background(), tt.policy, "admin", "10.0.0.1")
+			if (err != nil) != tt.wantErr || n != tt.wantN {
+				t.Fatalf("reserveAttempt() = (%d, %v), want (%d, err=%v)", n, err, tt.wantN, tt.wantErr)
+			}
+			if got := store.counts[userLockSubject("admin")]; got != tt.wantUser {
+				t.Fatalf("user attempts = %d, want %d", got, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestFailureError(t *testing.T) {
+	p := lockPolicy{count: 3, duration: time.Minute}
+	tests := []struct {
+		name       string
+		policy     lockPolicy
+		n          int64
+		wantLocked bool
+	}{
+		{"disabled", lockPolicy{}, 10, false},
+		{"below limit", p, 2, false},
+		{"reaches limit", p, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := failureError(tt.policy, tt.n)
+			if locked := !errors.Is(err, errInvalidCredentials); locked != tt.wantLocked {
+				t.Fatalf("failureError(%d) = %v, want locked=%v", tt.n, err, tt.wantLocked)
+			}
+		})
+	}
+}
+
+func TestResetFailuresReleasesIP(t *testing.T) {
+	store := newMemoryAttempts()
+	s := &Service{attempts: store}
+	p := lockPolicy{count: 3, duration: time.Minute}
+
+	if _, err := s.reserveAttempt(context.Background(), p, "admin", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.resetFailures(context.Background(), p, "admin", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := store.counts[userLockSubject("admin")]; n != 0 {
+		t.Fatalf("user attempts = %d, want 0", n)
+	}
+	if n := store.counts[ipLockSubject("10.0.0.1")]; n != 0 {
+		t.Fatalf("ip attempts = %d, want 0", n)
+	}
+}
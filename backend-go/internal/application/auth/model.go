@@ -1,5 +1,7 @@
 package auth
 
+import "time"
+
 // LoginRequest mirrors the JSON structure sent by the front-end
 // when calling POST /auth/login with ACCOUNT auth type.
 type LoginRequest struct {
@@ -10,13 +12,22 @@ type LoginRequest struct {
 	Password string `json:"password"` // RSA + Base64 encrypted
 	Captcha  string `json:"captcha"`
 	UUID     string `json:"uuid"`
+
+	// ClientIP 由 HTTP 层填充，用于按来源 IP 统计登录失败次数。
+	ClientIP string `json:"-"`
 }
 
 // LoginResponse 匹配 Java LoginResp 返回结构，前端当前仅使用 token 字段。
-// 额外携带的用户基础信息用于 Go 端在线用户统计，不会影响前端兼容性。
+// 额外携带的用户基础信息用于 Go 端在线用户统计，不会影响前端兼容性；
+// 客户端与过期信息仅供服务端记录在线会话，不输出到 JSON。
 type LoginResponse struct {
 	Token    string `json:"token"`
 	UserID   int64  `json:"userId,omitempty"`
 	Username string `json:"username,omitempty"`
 	Nickname string `json:"nickname,omitempty"`
+
+	ClientID    string        `json:"-"`
+	ClientType  string        `json:"-"`
+	ExpireTime  time.Time     `json:"-"`
+	IdleTimeout time.Duration `json:"-"`
 }
@@ -3,15 +3,24 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 
+	clientdomain "voc-go-backend/internal/domain/client"
+	optiondomain "voc-go-backend/internal/domain/option"
 	domain "voc-go-backend/internal/domain/user"
 	"voc-go-backend/internal/infrastructure/security"
 )
 
+// errInvalidCredentials 用户名或密码不正确（与 Java 提示保持一致）。
+var errInvalidCredentials = errors.New("用户名或密码不正确")
+
 // Service handles authentication use cases.
 type Service struct {
 	users       domain.Repository
+	clients     clientdomain.Repository
+	options     optiondomain.Repository
+	attempts    LoginAttemptStore
 	decryptor   *security.RSADecryptor
 	pwdVerifier security.PasswordVerifier
 	tokenSvc    *security.TokenService
@@ -20,27 +29,54 @@ type Service struct {
 // NewService builds a new auth Service.
 func NewService(
 	users domain.Repository,
+	clients clientdomain.Repository,
+	options optiondomain.Repository,
+	attempts LoginAttemptStore,
 	decryptor *security.RSADecryptor,
 	pwdVerifier security.PasswordVerifier,
 	tokenSvc *security.TokenService,
 ) *Service {
 	return &Service{
 		users:       users,
+		clients:     clients,
+		options:     options,
+		attempts:    attempts,
 		decryptor:   decryptor,
 		pwdVerifier: pwdVerifier,
 		tokenSvc:    tokenSvc,
 	}
 }
 
-// Login validates credentials and issues a token.
+// Login validates the client and credentials, then issues a token whose
+// lifetime follows the client's timeout (sys_client.timeout).
 func (s *Service) Login(ctx context.Context, req LoginRequest) (*LoginResponse, error) {
 	authType := strings.ToUpper(strings.TrimSpace(req.AuthType))
-	if authType != "" && authType != "ACCOUNT" {
-		return nil, errors.New("暂不支持该认证方式")
+	if authType == "" {
+		authType = "ACCOUNT"
 	}
-	if strings.TrimSpace(req.ClientID) == "" {
+	clientID := strings.TrimSpace(req.ClientID)
+	if clientID == "" {
 		return nil, errors.New("客户端ID不能为空")
 	}
+
+	// 客户端校验（与 Java LoginController 一致）：存在、启用、授权了该认证方式。
+	client, err := s.clients.GetByClientID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, errors.New("客户端不存在")
+	}
+	if !client.IsEnabled() {
+		return nil, errors.New("客户端已禁用")
+	}
+	if !client.SupportsAuthType(authType) {
+		return nil, fmt.Errorf("该客户端暂未授权 [%s] 认证", authType)
+	}
+	if authType != "ACCOUNT" {
+		return nil, errors.New("暂不支持该认证方式")
+	}
+
 	if strings.TrimSpace(req.Username) == "" {
 		return nil, errors.New("用户名不能为空")
 	}
@@ -48,40 +84,70 @@ func (s *Service) Login(ctx context.Context, req LoginRequest) (*LoginResponse,
 		return nil, errors.New("密码不能为空")
 	}
 
+	// 连续密码错误锁定：按用户名与来源 IP 计数，校验密码前先占用一次尝试，锁定期间直接拒绝。
+	username := strings.TrimSpace(req.Username)
+	lock, err := s.loadLockPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	attempt, err := s.reserveAttempt(ctx, lock, username, req.ClientIP)
+	if err != nil {
+		return nil, err
+	}
+
 	rawPassword, err := s.decryptor.DecryptBase64(req.Password)
 	if err != nil {
 		return nil, errors.New("密码解密失败")
 	}
 
-	user, err := s.users.GetByUsername(ctx, req.Username)
+	user, err := s.users.GetByUsername(ctx, username)
 	if err != nil {
+		s.releaseAttempt(ctx, lock, username, req.ClientIP)
 		return nil, err
 	}
-	// 用户名或密码不正确（与 Java 提示保持一致）
+	// 用户不存在与密码错误同样计入失败次数，避免泄露账号是否存在。
 	if user == nil {
-		return nil, errors.New("用户名或密码不正确")
+		return nil, failureError(lock, attempt)
 	}
 
 	ok, err := s.pwdVerifier.Verify(rawPassword, user.Password)
 	if err != nil {
+		s.releaseAttempt(ctx, lock, username, req.ClientIP)
 		return nil, err
 	}
 	if !ok {
-		return nil, errors.New("用户名或密码不正确")
+		return nil, failureError(lock, attempt)
+	}
+	if err := s.resetFailures(ctx, lock, username, req.ClientIP); err != nil {
+		return nil, err
 	}
 
 	if !user.IsEnabled() {
 		return nil, errors.New("此账号已被禁用，如有疑问，请联系管理员")
 	}
 
-	token, err := s.tokenSvc.Generate(user.ID)
+	// timeout 为 -1（不限制）时使用 TokenService 的默认有效期。
+	token, expireTime, err := s.tokenSvc.GenerateWithTTL(user.ID, client.TokenTTL())
 	if err != nil {
 		return nil, err
 	}
 	return &LoginResponse{
-		Token:    token,
-		UserID:   user.ID,
-		Username: user.Username,
-		Nickname: user.Nickname,
+		Token:       token,
+		UserID:      user.ID,
+		Username:    user.Username,
+		Nickname:    user.Nickname,
+		ClientID:    client.ClientID,
+		ClientType:  client.ClientType,
+		ExpireTime:  expireTime,
+		IdleTimeout: client.IdleTimeout(),
 	}, nil
 }
+
+// Logout revokes the given token so that it is rejected immediately,
+// instead of remaining valid until it expires.
+func (s *Service) Logout(ctx context.Context, token string) error {
+	if strings.TrimSpace(token) == "" {
+		return nil
+	}
+	return s.tokenSvc.Revoke(ctx, token)
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	domain "voc-go-backend/internal/domain/user"
 	rbac "voc-go-backend/internal/domain/rbac"
+	"voc-go-backend/internal/infrastructure/security"
 )
 
 // UserInfo is the shape returned by /auth/user/info,
@@ -18,6 +19,10 @@ type UserInfo struct {
 	Description     string   `json:"description"`
 	PwdResetTime    string   `json:"pwdResetTime"`
 	PwdExpired      bool     `json:"pwdExpired"`
+	// 密码过期时间与剩余天数，未启用密码有效期时为空。
+	PwdExpireTime    string `json:"pwdExpireTime,omitempty"`
+	PwdExpireDays    *int   `json:"pwdExpireDays"`
+	PwdExpireWarning bool   `json:"pwdExpireWarning"`
 	RegistrationDate string  `json:"registrationDate"`
 	DeptName        string   `json:"deptName"`
 	Roles           []string `json:"roles"`
@@ -25,8 +30,8 @@ type UserInfo struct {
 }
 
 // BuildUserInfo maps a domain.User to UserInfo.
-// roles and permissions are supplied by caller (RBAC layer).
-func BuildUserInfo(u *domain.User, roleCodes []string, permissions []string, deptName string, pwdExpired bool) UserInfo {
+// roles, permissions and password expiry are supplied by caller.
+func BuildUserInfo(u *domain.User, roleCodes []string, permissions []string, deptName string, expiry security.PasswordExpiry) UserInfo {
 	var pwdResetTime string
 	if u.PwdResetTime != nil {
 		pwdResetTime = u.PwdResetTime.Format("2006-01-02 15:04:05")
@@ -47,6 +52,14 @@ func BuildUserInfo(u *domain.User, roleCodes []string, permissions []string, dep
 		desc = *u.Description
 	}
 
+	var pwdExpireTime string
+	var pwdExpireDays *int
+	if expiry.ExpireTime != nil {
+		pwdExpireTime = expiry.ExpireTime.Format("2006-01-02 15:04:05")
+		days := expiry.DaysLeft
+		pwdExpireDays = &days
+	}
+
 	return UserInfo{
 		ID:               u.ID,
 		Username:         u.Username,
@@ -57,7 +70,10 @@ func BuildUserInfo(u *domain.User, roleCodes []string, permissions []string, dep
 		Avatar:           avatar,
 		Description:      desc,
 		PwdResetTime:     pwdResetTime,
-		PwdExpired:       pwdExpired,
+		PwdExpired:       expiry.Expired,
+		PwdExpireTime:    pwdExpireTime,
+		PwdExpireDays:    pwdExpireDays,
+		PwdExpireWarning: expiry.Warning,
 		RegistrationDate: regDate,
 		DeptName:         deptName,
 		Roles:            roleCodes,
@@ -0,0 +1,53 @@
+package client
+
+import "time"
+
+// Client is the domain entity for a login client (PC / mobile ...).
+// It mirrors the Java ClientDO and the sys_client PostgreSQL table.
+type Client struct {
+	ID         int64
+	ClientID   string
+	ClientType string
+	AuthType   []string
+	// ActiveTimeout is the idle timeout in seconds, -1 means no limit.
+	ActiveTimeout int64
+	// Timeout is the token lifetime in seconds, -1 means no limit.
+	Timeout int64
+	Status  int16
+}
+
+// IsEnabled returns true if the client status is "enabled" (1).
+func (c *Client) IsEnabled() bool {
+	return c != nil && c.Status == 1
+}
+
+// SupportsAuthType reports whether authType is listed in the client's auth_type.
+func (c *Client) SupportsAuthType(authType string) bool {
+	if c == nil {
+		return false
+	}
+	for _, t := range c.AuthType {
+		if t == authType {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenTTL returns the token lifetime configured for the client,
+// or 0 when the client does not limit it.
+func (c *Client) TokenTTL() time.Duration {
+	if c == nil || c.Timeout <= 0 {
+		return 0
+	}
+	return time.Duration(c.Timeout) * time.Second
+}
+
+// IdleTimeout returns the idle timeout configured for the client,
+// or 0 when the client does not limit it.
+func (c *Client) IdleTimeout() time.Duration {
+	if c == nil || c.ActiveTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(c.ActiveTimeout) * time.Second
+}
@@ -0,0 +1,9 @@
+package client
+
+import "context"
+
+// Repository defines read access to login client configuration.
+type Repository interface {
+	// GetByClientID returns the client with the given client_id, or (nil, nil) if not found.
+	GetByClientID(ctx context.Context, clientID string) (*Client, error)
+}
@@ -0,0 +1,40 @@
+package option
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Repository defines read access to system options (sys_option).
+type Repository interface {
+	// GetValues returns the effective value (value, falling back to default_value)
+	// of the given option codes. Codes that do not exist are absent from the result.
+	GetValues(ctx context.Context, codes ...string) (Values, error)
+}
+
+// Values holds effective option values keyed by option code.
+type Values map[string]string
+
+// Int returns the option as an int, or def when missing or malformed.
+func (v Values) Int(code string, def int) int {
+	raw, ok := v[code]
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// Bool returns true when the option is set to a non-zero value, or def when missing.
+func (v Values) Bool(code string, def bool) bool {
+	raw, ok := v[code]
+	if !ok {
+		return def
+	}
+	raw = strings.TrimSpace(raw)
+	return raw != "" && raw != "0" && !strings.EqualFold(raw, "false")
+}
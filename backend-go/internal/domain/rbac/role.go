@@ -1,10 +1,21 @@
 package rbac
 
+// DataScope corresponds to sys_role.data_scope (DataScopeEnum in Java).
+// 1: ALL, 2: DEPT_AND_CHILD, 3: DEPT, 4: SELF, 5: CUSTOM.
+type DataScope int32
+
+const (
+	DataScopeAll          DataScope = 1
+	DataScopeDeptAndChild DataScope = 2
+	DataScopeDept         DataScope = 3
+	DataScopeSelf         DataScope = 4
+	DataScopeCustom       DataScope = 5
+)
+
 // Role represents a system role (sys_role).
 type Role struct {
 	ID        int64
 	Name      string
 	Code      string
-	DataScope int32
+	DataScope DataScope
 }
-
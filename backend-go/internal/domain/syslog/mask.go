@@ -0,0 +1,198 @@
+package syslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// MaskedValue 为脱敏后写入日志的占位值。
+const MaskedValue = "******"
+
+// MaskConfig 定义系统日志的脱敏规则，名称均不区分大小写。
+type MaskConfig struct {
+	// Headers 为需要脱敏的请求头与响应头，如 Authorization。
+	Headers []string
+	// Fields 为需要脱敏的字段：不含 "." 时匹配 JSON 任意层级、表单及 URL 查询参数中的同名字段；
+	// 含 "." 时按 JSON 路径从根开始匹配（数组不计入路径），如 data.token。
+	Fields []string
+}
+
+// DefaultMaskConfig 返回默认脱敏规则：认证相关请求头，以及密码、存储密钥和令牌字段。
+func DefaultMaskConfig() MaskConfig {
+	return MaskConfig{
+		Headers: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"},
+		Fields: []string{
+			"password", "oldPassword", "newPassword",
+			"secretKey",
+			"token", "accessToken", "refreshToken",
+		},
+	}
+}
+
+// Masker 在日志写入前屏蔽记录中的敏感信息。
+type Masker struct {
+	headers map[string]bool
+	names   map[string]bool
+	paths   map[string]bool
+}
+
+// NewMasker 按配置创建脱敏器。
+func NewMasker(cfg MaskConfig) *Masker {
+	m := &Masker{
+		headers: make(map[string]bool),
+		names:   make(map[string]bool),
+		paths:   make(map[string]bool),
+	}
+	for _, h := range cfg.Headers {
+		if h = strings.TrimSpace(h); h != "" {
+			m.headers[strings.ToLower(h)] = true
+		}
+	}
+	for _, f := range cfg.Fields {
+		f = strings.ToLower(strings.TrimSpace(f))
+		switch {
+		case f == "":
+		case strings.Contains(f, "."):
+			m.paths[f] = true
+		default:
+			m.names[f] = true
+		}
+	}
+	return m
+}
+
+// Mask 屏蔽请求 URL 查询参数、请求/响应头与请求/响应体中的敏感信息。
+// 无法解析的内容（如文件上传的 multipart 请求体）保持原样。
+func (m *Masker) Mask(rec *Record) {
+	rec.RequestURL = m.maskURL(rec.RequestURL)
+	rec.RequestHeaders = m.maskHeaders(rec.RequestHeaders)
+	rec.ResponseHeaders = m.maskHeaders(rec.ResponseHeaders)
+	rec.RequestBody = m.maskBody(rec.RequestBody)
+	rec.ResponseBody = m.maskBody(rec.ResponseBody)
+}
+
+// maskHeaders 处理 JSON 对象形式的请求头（见 marshalHeaders）。
+func (m *Masker) maskHeaders(s string) string {
+	if s == "" || len(m.headers) == 0 {
+		return s
+	}
+	var h map[string]string
+	if err := json.Unmarshal([]byte(s), &h); err != nil {
+		return s
+	}
+	changed := false
+	for k := range h {
+		if m.headers[strings.ToLower(k)] {
+			h[k] = MaskedValue
+			changed = true
+		}
+	}
+	if !changed {
+		return s
+	}
+	return marshalJSON(h, s)
+}
+
+func (m *Masker) maskURL(s string) string {
+	i := strings.IndexByte(s, '?')
+	if i < 0 {
+		return s
+	}
+	if q, ok := m.maskQuery(s[i+1:]); ok {
+		return s[:i+1] + q
+	}
+	return s
+}
+
+func (m *Masker) maskBody(s string) string {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		return s
+	}
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		dec := json.NewDecoder(strings.NewReader(trimmed))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return s
+		}
+		if !m.maskValue(v, "") {
+			return s
+		}
+		return marshalJSON(v, s)
+	}
+	// application/x-www-form-urlencoded
+	if !strings.ContainsAny(trimmed, " \r\n") && strings.Contains(trimmed, "=") {
+		if q, ok := m.maskQuery(trimmed); ok {
+			return q
+		}
+	}
+	return s
+}
+
+// maskQuery 屏蔽 URL 编码的键值对，没有需要屏蔽的字段时返回 false。
+func (m *Masker) maskQuery(raw string) (string, bool) {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return raw, false
+	}
+	changed := false
+	for k, vs := range values {
+		if m.names[strings.ToLower(k)] {
+			for i := range vs {
+				vs[i] = MaskedValue
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return raw, false
+	}
+	// 占位符 * 无需转义，保持可读
+	return strings.ReplaceAll(values.Encode(), url.QueryEscape(MaskedValue), MaskedValue), true
+}
+
+// maskValue 递归屏蔽 JSON 值中的敏感字段，返回是否有修改。
+func (m *Masker) maskValue(v any, path string) bool {
+	changed := false
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			key := strings.ToLower(k)
+			p := key
+			if path != "" {
+				p = path + "." + key
+			}
+			if m.names[key] || m.paths[p] {
+				if child != nil {
+					t[k] = MaskedValue
+					changed = true
+				}
+				continue
+			}
+			if m.maskValue(child, p) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, child := range t {
+			if m.maskValue(child, path) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// marshalJSON 序列化脱敏后的内容（不转义 HTML 字符），失败时返回 fallback。
+func marshalJSON(v any, fallback string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fallback
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
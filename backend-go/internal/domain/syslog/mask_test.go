@@ -0,0 +1,108 @@
+package syslog
+
+import "testing"
+
+func TestMaskerMaskHeaders(t *testing.T) {
+	m := NewMasker(DefaultMaskConfig())
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", "", ""},
+		{"authorization", `{"Authorization":"Bearer abc","Accept":"*/*"}`, `{"Accept":"*/*","Authorization":"******"}`},
+		{"case insensitive", `{"cookie":"sid=1"}`, `{"cookie":"******"}`},
+		{"nothing to mask", `{"Accept":"*/*","X-B":"1"}`, `{"Accept":"*/*","X-B":"1"}`},
+		{"invalid json", `Authorization: Bearer abc`, `Authorization: Bearer abc`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &Record{RequestHeaders: tt.in, ResponseHeaders: tt.in}
+			m.Mask(rec)
+			if rec.RequestHeaders != tt.want || rec.ResponseHeaders != tt.want {
+				t.Fatalf("Mask() headers = %q / %q, want %q", rec.RequestHeaders, rec.ResponseHeaders, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskerMaskBody(t *testing.T) {
+	m := NewMasker(DefaultMaskConfig())
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", "", ""},
+		{"json field", `{"username":"admin","password":"secret"}`, `{"password":"******","username":"admin"}`},
+		{"case insensitive", `{"PassWord":"secret"}`, `{"PassWord":"******"}`},
+		{"nested and array", `{"data":{"token":"t","list":[{"secretKey":"k","name":"a"}]}}`,
+			`{"data":{"list":[{"name":"a","secretKey":"******"}],"token":"******"}}`},
+		{"top level array", `[{"password":"a"},{"password":"b"}]`, `[{"password":"******"},{"password":"******"}]`},
+		{"null kept", `{"password":null}`, `{"password":null}`},
+		{"nothing to mask keeps original", `{ "b": 1, "a": 2 }`, `{ "b": 1, "a": 2 }`},
+		{"numbers preserved", `{"password":"p","id":12345678901234567890}`, `{"id":12345678901234567890,"password":"******"}`},
+		{"html not escaped", `{"password":"p","url":"<a>&"}`, `{"password":"******","url":"<a>&"}`},
+		{"invalid json", `{"password":`, `{"password":`},
+		{"form", `username=admin&password=secret`, `password=******&username=admin`},
+		{"form nothing to mask", `username=admin&b=1`, `username=admin&b=1`},
+		{"plain text", `password = secret`, `password = secret`},
+		{"multipart", "--x\r\nContent-Disposition: form-data; name=\"password\"\r\n\r\nsecret\r\n--x--", "--x\r\nContent-Disposition: form-data; name=\"password\"\r\n\r\nsecret\r\n--x--"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &Record{RequestBody: tt.in, ResponseBody: tt.in}
+			m.Mask(rec)
+			if rec.RequestBody != tt.want || rec.ResponseBody != tt.want {
+				t.Fatalf("Mask() body = %q / %q, want %q", rec.RequestBody, rec.ResponseBody, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskerMaskPath(t *testing.T) {
+	m := NewMasker(MaskConfig{Fields: []string{" data.ID ", "password", ""}})
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"path from root", `{"data":{"id":1},"id":2}`, `{"data":{"id":"******"},"id":2}`},
+		{"array not in path", `{"data":[{"id":1},{"id":2}]}`, `{"data":[{"id":"******"},{"id":"******"}]}`},
+		{"nested path not matched", `{"x":{"data":{"id":1}}}`, `{"x":{"data":{"id":1}}}`},
+		{"name still matched", `{"x":{"password":"p"}}`, `{"x":{"password":"******"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &Record{ResponseBody: tt.in}
+			m.Mask(rec)
+			if rec.ResponseBody != tt.want {
+				t.Fatalf("Mask() body = %q, want %q", rec.ResponseBody, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskerMaskURL(t *testing.T) {
+	m := NewMasker(DefaultMaskConfig())
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"no query", "/system/user", "/system/user"},
+		{"token", "/auth/check?token=abc&x=1", "/auth/check?token=******&x=1"},
+		{"repeated", "/a?accessToken=1&accessToken=2", "/a?accessToken=******&accessToken=******"},
+		{"nothing to mask", "/a?b=2&a=1", "/a?b=2&a=1"},
+		{"invalid query", "/a?token=%zz", "/a?token=%zz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &Record{RequestURL: tt.in}
+			m.Mask(rec)
+			if rec.RequestURL != tt.want {
+				t.Fatalf("Mask() url = %q, want %q", rec.RequestURL, tt.want)
+			}
+		})
+	}
+}
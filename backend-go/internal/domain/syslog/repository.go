@@ -8,3 +8,18 @@ type Repository interface {
 	Save(ctx context.Context, rec *Record) error
 }
 
+// WriterStats 为异步日志写入的运行状态。
+type WriterStats struct {
+	// Queued 为队列中等待写入的条数，Capacity 为队列容量
+	Queued   int `json:"queued"`
+	Capacity int `json:"capacity"`
+	// Written、Dropped、Failed 为启动以来写入成功、因队列已满丢弃、写入失败的累计条数
+	Written int64 `json:"written"`
+	Dropped int64 `json:"dropped"`
+	Failed  int64 `json:"failed"`
+}
+
+// StatsReporter 由异步写入的仓储实现，用于查看写入队列状态。
+type StatsReporter interface {
+	Stats() WriterStats
+}
@@ -1,6 +1,9 @@
 package user
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Repository defines read/write access to user aggregates.
 type Repository interface {
@@ -9,4 +12,41 @@ type Repository interface {
 
 	// GetByID returns the user with the given ID, or (nil, nil) if not found.
 	GetByID(ctx context.Context, id int64) (*User, error)
+
+	// ExistsByPhone reports whether another user (id != excludeID) uses the phone.
+	ExistsByPhone(ctx context.Context, phone string, excludeID int64) (bool, error)
+
+	// ExistsByEmail reports whether another user (id != excludeID) uses the email.
+	ExistsByEmail(ctx context.Context, email string, excludeID int64) (bool, error)
+
+	// UpdateBasicInfo updates nickname and gender of the user.
+	UpdateBasicInfo(ctx context.Context, id int64, nickname string, gender int16) error
+
+	// UpdateAvatar updates the avatar URL of the user and returns the replaced one.
+	// Concurrent updates are serialized so each replaced avatar is returned exactly once.
+	UpdateAvatar(ctx context.Context, id int64, avatar string) (string, error)
+
+	// UpdatePassword updates the password hash and pwd_reset_time of the user and,
+	// in the same transaction, records the replaced hash in the password history.
+	// operatorID is recorded as update_user (the user itself for self-service changes).
+	UpdatePassword(ctx context.Context, id int64, encoded string, resetTime time.Time, operatorID int64) error
+
+	// UpdatePhone updates the phone of the user.
+	UpdatePhone(ctx context.Context, id int64, phone string) error
+
+	// UpdateEmail updates the email of the user.
+	UpdateEmail(ctx context.Context, id int64, email string) error
+}
+
+// PasswordHistoryKeep is the number of replaced password hashes kept per user,
+// the upper bound of PASSWORD_REPETITION_TIMES, so that raising the option
+// later still takes earlier passwords into account.
+const PasswordHistoryKeep = 32
+
+// PasswordHistoryRepository stores previously used password hashes
+// (sys_user_password_history) to prevent password reuse. Entries are written
+// together with the password change (see Repository.UpdatePassword).
+type PasswordHistoryRepository interface {
+	// ListRecent returns the most recent password hashes of the user, newest first.
+	ListRecent(ctx context.Context, userID int64, limit int) ([]string, error)
 }
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// loginFailKeyPrefix 登录失败计数的 Redis key 前缀：LOGIN_FAIL:{subject}，
+// subject 由调用方区分维度（如 USER:{username}、IP:{ip}）。
+const loginFailKeyPrefix = "LOGIN_FAIL:"
+
+// RedisLoginAttemptStore 基于 Redis 记录登录失败次数。
+// 计数 key 的过期时间即锁定时长，到期后自动解锁；多实例共享同一计数。
+type RedisLoginAttemptStore struct {
+	client *redis.Client
+}
+
+// NewRedisLoginAttemptStore 创建登录失败计数存储。
+func NewRedisLoginAttemptStore(client *redis.Client) *RedisLoginAttemptStore {
+	return &RedisLoginAttemptStore{client: client}
+}
+
+// reserveAttemptScript 累加计数，计数未超过上限时将有效期重置为锁定时长，返回 {次数, 剩余毫秒}。
+var reserveAttemptScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n <= tonumber(ARGV[1]) then
+  redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return {n, redis.call('PTTL', KEYS[1])}
+`)
+
+// releaseAttemptScript 计数大于 0 时减一，保留原有效期。
+var releaseAttemptScript = redis.NewScript(`
+local n = tonumber(redis.call('GET', KEYS[1]) or '0')
+if n > 0 then
+  return redis.call('DECR', KEYS[1])
+end
+return 0
+`)
+
+// Reserve 原子地累加 subject 的尝试次数，返回累加后的次数及计数剩余有效期。
+// 次数未超过 limit 时有效期重置为 window；锁定期间的请求不会顺延锁定时间。
+func (s *RedisLoginAttemptStore) Reserve(ctx context.Context, subject string, limit int64, window time.Duration) (int64, time.Duration, error) {
+	res, err := reserveAttemptScript.Run(ctx, s.client, []string{loginFailKeyPrefix + subject}, limit, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(res) != 2 {
+		return 0, 0, fmt.Errorf("unexpected reserve result %v", res)
+	}
+	return res[0], time.Duration(res[1]) * time.Millisecond, nil
+}
+
+// Release 归还一次已占用的尝试次数。
+func (s *RedisLoginAttemptStore) Release(ctx context.Context, subject string) error {
+	return releaseAttemptScript.Run(ctx, s.client, []string{loginFailKeyPrefix + subject}).Err()
+}
+
+// Reset 清除 subject 的失败计数。
+func (s *RedisLoginAttemptStore) Reset(ctx context.Context, subject string) error {
+	return s.client.Del(ctx, loginFailKeyPrefix+subject).Err()
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenRevokedKeyPrefix 被吊销 Token 的 Redis key 前缀：TOKEN_REVOKED:{jti}
+const tokenRevokedKeyPrefix = "TOKEN_REVOKED:"
+
+// RedisTokenRevocationStore 基于 Redis 保存已吊销的 Token（jti），
+// key 的过期时间与 Token 剩余有效期一致，过期后自动清理。
+// 多实例部署时共享同一 Redis 即可让强退/登出在所有节点立即生效。
+type RedisTokenRevocationStore struct {
+	client *redis.Client
+}
+
+// NewRedisTokenRevocationStore 创建基于 Redis 的 Token 吊销列表。
+func NewRedisTokenRevocationStore(client *redis.Client) *RedisTokenRevocationStore {
+	return &RedisTokenRevocationStore{client: client}
+}
+
+// Revoke 将 jti 加入吊销列表，保留 ttl 时长。
+func (s *RedisTokenRevocationStore) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	return s.client.Set(ctx, tokenRevokedKeyPrefix+jti, 1, ttl).Err()
+}
+
+// IsRevoked 判断 jti 是否已被吊销。
+func (s *RedisTokenRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	n, err := s.client.Exists(ctx, tokenRevokedKeyPrefix+jti).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,95 @@
+package clientinfo
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// ip2region xdb（IPv4，v2 格式）文件结构：
+//   - 256 字节文件头；
+//   - 向量索引：按 IP 前两段划分为 256*256 个区间，每项 8 字节（段索引起止偏移）；
+//   - 段索引：每项 14 字节（起始 IP、结束 IP、地区数据长度、地区数据偏移）；
+//   - 地区数据：形如 "中国|0|广东省|深圳市|电信"，0 表示未知。
+const (
+	xdbHeaderLength       = 256
+	xdbVectorIndexCols    = 256
+	xdbVectorIndexSize    = 8
+	xdbSegmentIndexSize   = 14
+	xdbVectorIndexLength  = xdbVectorIndexCols * xdbVectorIndexCols * xdbVectorIndexSize
+	xdbMinimumFileLength  = xdbHeaderLength + xdbVectorIndexLength
+	xdbStructureVersion20 = 2
+)
+
+// IPRegion 为整体加载到内存的 ip2region 离线库，只读，可并发查询。
+type IPRegion struct {
+	content []byte
+}
+
+// LoadIPRegion 加载 ip2region xdb 离线库文件（IPv4）。
+func LoadIPRegion(path string) (*IPRegion, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) < xdbMinimumFileLength {
+		return nil, errors.New("invalid ip2region xdb file: too short")
+	}
+	if v := binary.LittleEndian.Uint16(content); v != xdbStructureVersion20 {
+		return nil, fmt.Errorf("unsupported ip2region xdb version %d", v)
+	}
+	return &IPRegion{content: content}, nil
+}
+
+// Search 返回 IPv4 地址对应的原始地区数据，未找到时返回空字符串。
+func (r *IPRegion) Search(ip net.IP) string {
+	ip4 := ip.To4()
+	if r == nil || ip4 == nil {
+		return ""
+	}
+	ipVal := binary.BigEndian.Uint32(ip4)
+
+	idx := xdbHeaderLength + (int(ip4[0])*xdbVectorIndexCols+int(ip4[1]))*xdbVectorIndexSize
+	sPtr := int(binary.LittleEndian.Uint32(r.content[idx:]))
+	ePtr := int(binary.LittleEndian.Uint32(r.content[idx+4:]))
+	if sPtr <= 0 || ePtr < sPtr || ePtr+xdbSegmentIndexSize > len(r.content) {
+		return ""
+	}
+
+	l, h := 0, (ePtr-sPtr)/xdbSegmentIndexSize
+	for l <= h {
+		m := (l + h) >> 1
+		p := sPtr + m*xdbSegmentIndexSize
+		seg := r.content[p : p+xdbSegmentIndexSize]
+		switch {
+		case ipVal < binary.LittleEndian.Uint32(seg):
+			h = m - 1
+		case ipVal > binary.LittleEndian.Uint32(seg[4:]):
+			l = m + 1
+		default:
+			dataLen := int(binary.LittleEndian.Uint16(seg[8:]))
+			dataPtr := int(binary.LittleEndian.Uint32(seg[10:]))
+			if dataPtr+dataLen > len(r.content) {
+				return ""
+			}
+			return string(r.content[dataPtr : dataPtr+dataLen])
+		}
+	}
+	return ""
+}
+
+// formatRegion 将 "中国|0|广东省|深圳市|电信" 格式化为 "中国 广东省 深圳市 电信"，忽略未知（0）及重复的部分。
+func formatRegion(region string) string {
+	parts := make([]string, 0, 5)
+	for _, p := range strings.Split(region, "|") {
+		p = strings.TrimSpace(p)
+		if p == "" || p == "0" || (len(parts) > 0 && parts[len(parts)-1] == p) {
+			continue
+		}
+		parts = append(parts, p)
+	}
+	return strings.Join(parts, " ")
+}
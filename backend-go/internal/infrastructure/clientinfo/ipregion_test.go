@@ -0,0 +1,171 @@
+package clientinfo
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type xdbSegment struct {
+	start, end string
+	region     string
+}
+
+// writeXDB 按 xdb v2 格式写入测试用离线库，每个区间须位于同一个 /16 内且按起始 IP 排序。
+func writeXDB(t *testing.T, segments []xdbSegment) string {
+	t.Helper()
+	segStart := xdbMinimumFileLength
+	dataStart := segStart + len(segments)*xdbSegmentIndexSize
+	content := make([]byte, dataStart)
+	binary.LittleEndian.PutUint16(content, xdbStructureVersion20)
+
+	for i, seg := range segments {
+		start := net.ParseIP(seg.start).To4()
+		end := net.ParseIP(seg.end).To4()
+		p := segStart + i*xdbSegmentIndexSize
+		binary.LittleEndian.PutUint32(content[p:], binary.BigEndian.Uint32(start))
+		binary.LittleEndian.PutUint32(content[p+4:], binary.BigEndian.Uint32(end))
+		binary.LittleEndian.PutUint16(content[p+8:], uint16(len(seg.region)))
+		binary.LittleEndian.PutUint32(content[p+10:], uint32(len(content)))
+		content = append(content, seg.region...)
+
+		idx := xdbHeaderLength + (int(start[0])*xdbVectorIndexCols+int(start[1]))*xdbVectorIndexSize
+		if binary.LittleEndian.Uint32(content[idx:]) == 0 {
+			binary.LittleEndian.PutUint32(content[idx:], uint32(p))
+		}
+		binary.LittleEndian.PutUint32(content[idx+4:], uint32(p))
+	}
+
+	path := filepath.Join(t.TempDir(), "ip2region.xdb")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadIPRegion(t *testing.T) {
+	dir := t.TempDir()
+	short := filepath.Join(dir, "short.xdb")
+	if err := os.WriteFile(short, make([]byte, 100), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	badVersion := filepath.Join(dir, "v3.xdb")
+	content := make([]byte, xdbMinimumFileLength)
+	binary.LittleEndian.PutUint16(content, 3)
+	if err := os.WriteFile(badVersion, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"valid", writeXDB(t, nil), false},
+		{"missing", filepath.Join(dir, "missing.xdb"), true},
+		{"too short", short, true},
+		{"unsupported version", badVersion, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadIPRegion(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadIPRegion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIPRegionSearch(t *testing.T) {
+	region, err := LoadIPRegion(writeXDB(t, []xdbSegment{
+		{"1.2.0.0", "1.2.0.255", "中国|0|广东省|深圳市|电信"},
+		{"1.2.1.0", "1.2.3.255", "中国|0|北京|北京市|联通"},
+		{"1.2.8.0", "1.2.255.255", "美国|0|0|0|0"},
+		{"8.8.0.0", "8.8.255.255", "美国|0|加利福尼亚|0|谷歌"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		ip   string
+		want string
+	}{
+		{"first segment start", "1.2.0.0", "中国|0|广东省|深圳市|电信"},
+		{"middle segment", "1.2.2.10", "中国|0|北京|北京市|联通"},
+		{"segment end", "1.2.3.255", "中国|0|北京|北京市|联通"},
+		{"last segment", "1.2.200.1", "美国|0|0|0|0"},
+		{"gap between segments", "1.2.5.1", ""},
+		{"single segment cell", "8.8.8.8", "美国|0|加利福尼亚|0|谷歌"},
+		{"cell without index", "9.9.9.9", ""},
+		{"ipv6", "2001:db8::1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := region.Search(net.ParseIP(tt.ip)); got != tt.want {
+				t.Fatalf("Search(%s) = %q, want %q", tt.ip, got, tt.want)
+			}
+		})
+	}
+
+	var nilRegion *IPRegion
+	if got := nilRegion.Search(net.ParseIP("1.2.0.1")); got != "" {
+		t.Fatalf("nil Search() = %q, want empty", got)
+	}
+}
+
+func TestFormatRegion(t *testing.T) {
+	tests := []struct {
+		name   string
+		region string
+		want   string
+	}{
+		{"empty", "", ""},
+		{"full", "中国|0|广东省|深圳市|电信", "中国 广东省 深圳市 电信"},
+		{"duplicate city", "中国|0|北京|北京|联通", "中国 北京 联通"},
+		{"unknown parts", "美国|0|0|0|0", "美国"},
+		{"intranet", "0|0|0|内网IP|内网IP", "内网IP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatRegion(tt.region); got != tt.want {
+				t.Fatalf("formatRegion(%q) = %q, want %q", tt.region, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolverAddress(t *testing.T) {
+	region, err := LoadIPRegion(writeXDB(t, []xdbSegment{
+		{"8.8.0.0", "8.8.255.255", "美国|0|加利福尼亚|0|谷歌"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		resolver *Resolver
+		ip       string
+		want     string
+	}{
+		{"invalid", NewResolver(region), "not-an-ip", ""},
+		{"loopback", NewResolver(region), "127.0.0.1", LocalAddress},
+		{"private", NewResolver(region), "192.168.1.10", LocalAddress},
+		{"ipv6 loopback", NewResolver(region), "::1", LocalAddress},
+		{"public", NewResolver(region), "8.8.8.8", "美国 加利福尼亚 谷歌"},
+		{"public not found", NewResolver(region), "9.9.9.9", ""},
+		{"without region", NewResolver(nil), "8.8.8.8", ""},
+		{"nil resolver", nil, "10.0.0.1", LocalAddress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.resolver.Address(tt.ip); got != tt.want {
+				t.Fatalf("Address(%q) = %q, want %q", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package clientinfo
+
+import "net"
+
+// LocalAddress 为内网及本机地址的归属地。
+const LocalAddress = "内网IP"
+
+// Info 为请求来源信息。
+type Info struct {
+	// Address 为 IP 归属地，如 中国 广东省 深圳市 电信
+	Address string
+	Browser string
+	OS      string
+}
+
+// Resolver 根据 IP 与 User-Agent 解析请求来源，供系统日志与在线用户使用。
+type Resolver struct {
+	region *IPRegion
+}
+
+// NewResolver 创建来源解析器，region 为 nil 时不解析公网 IP 归属地。
+func NewResolver(region *IPRegion) *Resolver {
+	return &Resolver{region: region}
+}
+
+// Resolve 解析 IP 归属地及浏览器、操作系统。
+func (r *Resolver) Resolve(ip, userAgent string) Info {
+	var info Info
+	info.Browser, info.OS = ParseUserAgent(userAgent)
+	info.Address = r.Address(ip)
+	return info
+}
+
+// Address 返回 IP 归属地，内网地址返回 LocalAddress，无法解析时返回空字符串。
+func (r *Resolver) Address(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if parsed.IsLoopback() || parsed.IsPrivate() || parsed.IsLinkLocalUnicast() || parsed.IsUnspecified() {
+		return LocalAddress
+	}
+	if r == nil || r.region == nil {
+		return ""
+	}
+	return formatRegion(r.region.Search(parsed))
+}
@@ -0,0 +1,129 @@
+package clientinfo
+
+import "strings"
+
+// browserRule 按 User-Agent 中的标识识别浏览器，Token 后紧跟版本号。
+type browserRule struct {
+	Token string
+	Name  string
+}
+
+// browserRules 按优先级排列：Edge、Opera 及国内浏览器的 UA 同样包含 Chrome/Safari，需先于后者匹配。
+var browserRules = []browserRule{
+	{"Edg/", "Edge"},
+	{"EdgA/", "Edge"},
+	{"EdgiOS/", "Edge"},
+	{"Edge/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Opera/", "Opera"},
+	{"MicroMessenger/", "WeChat"},
+	{"DingTalk/", "DingTalk"},
+	{"QQBrowser/", "QQBrowser"},
+	{"UCBrowser/", "UC Browser"},
+	{"SamsungBrowser/", "Samsung Browser"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"MSIE ", "Internet Explorer"},
+	{"PostmanRuntime/", "Postman"},
+	{"Apifox/", "Apifox"},
+	{"curl/", "curl"},
+	{"Go-http-client/", "Go-http-client"},
+}
+
+// windowsVersions 为 Windows NT 内核版本与系统名称的对应关系（Windows 11 的 UA 与 Windows 10 相同）。
+var windowsVersions = map[string]string{
+	"10.0": "Windows 10",
+	"6.3":  "Windows 8.1",
+	"6.2":  "Windows 8",
+	"6.1":  "Windows 7",
+	"6.0":  "Windows Vista",
+	"5.2":  "Windows XP",
+	"5.1":  "Windows XP",
+}
+
+// ParseUserAgent 解析 User-Agent，返回浏览器（名称 + 版本，如 Chrome 120.0.0.0）与操作系统（如 Windows 10），
+// 无法识别时返回空字符串。
+func ParseUserAgent(ua string) (browser, os string) {
+	if ua == "" {
+		return "", ""
+	}
+	return parseBrowser(ua), parseOS(ua)
+}
+
+func parseBrowser(ua string) string {
+	for _, rule := range browserRules {
+		if v, ok := versionAfter(ua, rule.Token); ok {
+			return withVersion(rule.Name, v)
+		}
+	}
+	// IE 11 不再包含 MSIE，版本号位于 rv:
+	if strings.Contains(ua, "Trident/") {
+		v, _ := versionAfter(ua, "rv:")
+		return withVersion("Internet Explorer", v)
+	}
+	if strings.Contains(ua, "Safari/") {
+		v, _ := versionAfter(ua, "Version/")
+		return withVersion("Safari", v)
+	}
+	return ""
+}
+
+func parseOS(ua string) string {
+	switch {
+	case strings.Contains(ua, "Windows Phone"):
+		return "Windows Phone"
+	case strings.Contains(ua, "Windows"):
+		if v, ok := versionAfter(ua, "Windows NT "); ok {
+			if name, ok := windowsVersions[v]; ok {
+				return name
+			}
+		}
+		return "Windows"
+	case strings.Contains(ua, "HarmonyOS") || strings.Contains(ua, "OpenHarmony"):
+		return "HarmonyOS"
+	case strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPad") || strings.Contains(ua, "iPod"):
+		v, ok := versionAfter(ua, "OS ")
+		if !ok {
+			return "iOS"
+		}
+		return withVersion("iOS", v)
+	case strings.Contains(ua, "Android"):
+		v, _ := versionAfter(ua, "Android ")
+		return withVersion("Android", v)
+	case strings.Contains(ua, "Mac OS X"):
+		v, _ := versionAfter(ua, "Mac OS X ")
+		return withVersion("macOS", v)
+	case strings.Contains(ua, "CrOS"):
+		return "Chrome OS"
+	case strings.Contains(ua, "Linux"):
+		return "Linux"
+	}
+	return ""
+}
+
+// versionAfter 返回 token 之后的版本号（数字、"." 与 "_"，"_" 转换为 "."），token 不存在时返回 false。
+func versionAfter(ua, token string) (string, bool) {
+	i := strings.Index(ua, token)
+	if i < 0 {
+		return "", false
+	}
+	rest := ua[i+len(token):]
+	end := 0
+	for end < len(rest) {
+		c := rest[end]
+		if (c < '0' || c > '9') && c != '.' && c != '_' {
+			break
+		}
+		end++
+	}
+	return strings.Trim(strings.ReplaceAll(rest[:end], "_", "."), "."), true
+}
+
+func withVersion(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + " " + version
+}
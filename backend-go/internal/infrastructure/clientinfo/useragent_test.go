@@ -0,0 +1,76 @@
+package clientinfo
+
+import "testing"
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		name        string
+		ua          string
+		wantBrowser string
+		wantOS      string
+	}{
+		{"empty", "", "", ""},
+		{"chrome on windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			"Chrome 120.0.0.0", "Windows 10"},
+		{"edge before chrome",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			"Edge 120.0.2210.91", "Windows 10"},
+		{"firefox on macos",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:121.0) Gecko/20100101 Firefox/121.0",
+			"Firefox 121.0", "macOS 10.15"},
+		{"safari on iphone",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1.2 Mobile/15E148 Safari/604.1",
+			"Safari 17.1.2", "iOS 17.1.2"},
+		{"wechat on android",
+			"Mozilla/5.0 (Linux; Android 13; PJZ110 Build/TP1A.220905.001; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/111.0.5563.116 Mobile Safari/537.36 MicroMessenger/8.0.43.2480(0x28002B51) NetType/WIFI",
+			"WeChat 8.0.43.2480", "Android 13"},
+		{"chrome on harmonyos",
+			"Mozilla/5.0 (Phone; OpenHarmony 4.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.4844.88 Safari/537.36 ArkWeb/4.1.6.1 Mobile",
+			"Chrome 99.0.4844.88", "HarmonyOS"},
+		{"chrome on chrome os",
+			"Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			"Chrome 120.0.0.0", "Chrome OS"},
+		{"firefox on linux",
+			"Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/115.0",
+			"Firefox 115.0", "Linux"},
+		{"ie 11", "Mozilla/5.0 (Windows NT 6.1; Trident/7.0; rv:11.0) like Gecko", "Internet Explorer 11.0", "Windows 7"},
+		{"ie 10", "Mozilla/5.0 (compatible; MSIE 10.0; Windows NT 6.2; Trident/6.0)", "Internet Explorer 10.0", "Windows 8"},
+		{"unknown windows version", "Mozilla/5.0 (Windows NT 4.0)", "", "Windows"},
+		{"ipad without version", "Mozilla/5.0 (iPad)", "", "iOS"},
+		{"curl", "curl/8.4.0", "curl 8.4.0", ""},
+		{"unknown", "my-client", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			browser, os := ParseUserAgent(tt.ua)
+			if browser != tt.wantBrowser || os != tt.wantOS {
+				t.Fatalf("ParseUserAgent() = (%q, %q), want (%q, %q)", browser, os, tt.wantBrowser, tt.wantOS)
+			}
+		})
+	}
+}
+
+func TestVersionAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		ua     string
+		token  string
+		want   string
+		wantOK bool
+	}{
+		{"missing token", "Chrome/120", "Firefox/", "", false},
+		{"dotted", "Chrome/120.0.1 Safari", "Chrome/", "120.0.1", true},
+		{"underscores", "OS 17_1_2 like", "OS ", "17.1.2", true},
+		{"trailing dot trimmed", "Version/4. Mobile", "Version/", "4", true},
+		{"no version", "Chrome/ x", "Chrome/", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := versionAfter(tt.ua, tt.token)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("versionAfter(%q, %q) = (%q, %v), want (%q, %v)", tt.ua, tt.token, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
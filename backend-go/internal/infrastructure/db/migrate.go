@@ -16,6 +16,9 @@ func AutoMigrate(database *sql.DB) error {
 	if err := ensureSysUser(database); err != nil {
 		return err
 	}
+	if err := ensureSysUserPasswordHistory(database); err != nil {
+		return err
+	}
 	if err := ensureSysRole(database); err != nil {
 		return err
 	}
@@ -46,6 +49,15 @@ func AutoMigrate(database *sql.DB) error {
 	if err := ensureSysFile(database); err != nil {
 		return err
 	}
+	if err := ensureSysFileRecycle(database); err != nil {
+		return err
+	}
+	if err := ensureSysFileBlob(database); err != nil {
+		return err
+	}
+	if err := ensureSysFileQuota(database); err != nil {
+		return err
+	}
 	if err := ensureSysOption(database); err != nil {
 		return err
 	}
@@ -128,6 +140,31 @@ WHERE NOT EXISTS (SELECT 1 FROM sys_user WHERE username = 'admin');
 	return nil
 }
 
+// ensureSysUserPasswordHistory 创建用户历史密码表，用于禁止重复使用最近 N 次密码。
+func ensureSysUserPasswordHistory(db *sql.DB) error {
+	const checkTable = `SELECT to_regclass('public.sys_user_password_history');`
+	var tableName sql.NullString
+	if err := db.QueryRow(checkTable).Scan(&tableName); err != nil {
+		return err
+	}
+	if !tableName.Valid {
+		const ddl = `
+CREATE TABLE IF NOT EXISTS sys_user_password_history (
+    id          BIGINT       NOT NULL,
+    user_id     BIGINT       NOT NULL,
+    password    VARCHAR(255) NOT NULL,
+    create_time TIMESTAMP    NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_uph_user_id ON sys_user_password_history (user_id);
+`
+		if _, err := db.Exec(ddl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func ensureSysRole(db *sql.DB) error {
 	const checkTable = `SELECT to_regclass('public.sys_role');`
 	var tableName sql.NullString
@@ -723,6 +760,20 @@ SELECT 1237, '设为默认存储', 1230, 3, NULL, NULL, NULL, NULL, NULL,
        NULL, NULL, NULL, 'system:storage:setDefault', 7, 1, 1, NOW()
 WHERE NOT EXISTS (SELECT 1 FROM sys_menu WHERE id = 1237);
 
+INSERT INTO sys_menu (id, title, parent_id, type, path, name, component, redirect, icon,
+                      is_external, is_cache, is_hidden, permission, sort, status,
+                      create_user, create_time)
+SELECT 1238, '测试连接', 1230, 3, NULL, NULL, NULL, NULL, NULL,
+       NULL, NULL, NULL, 'system:storage:test', 8, 1, 1, NOW()
+WHERE NOT EXISTS (SELECT 1 FROM sys_menu WHERE id = 1238);
+
+INSERT INTO sys_menu (id, title, parent_id, type, path, name, component, redirect, icon,
+                      is_external, is_cache, is_hidden, permission, sort, status,
+                      create_user, create_time)
+SELECT 1239, '迁移文件', 1230, 3, NULL, NULL, NULL, NULL, NULL,
+       NULL, NULL, NULL, 'system:storage:migrate', 9, 1, 1, NOW()
+WHERE NOT EXISTS (SELECT 1 FROM sys_menu WHERE id = 1239);
+
 -- 客户端配置（同样先迁菜单）
 INSERT INTO sys_menu (id, title, parent_id, type, path, name, component, redirect, icon,
                       is_external, is_cache, is_hidden, permission, sort, status,
@@ -830,6 +881,55 @@ SELECT 1118, '计算文件夹大小', 1110, 3, NULL, NULL, NULL, NULL, NULL,
        NULL, NULL, NULL, 'system:file:calcDirSize', 8, 1, 1, NOW()
 WHERE NOT EXISTS (SELECT 1 FROM sys_menu WHERE id = 1118);
 
+INSERT INTO sys_menu (id, title, parent_id, type, path, name, component, redirect, icon,
+                      is_external, is_cache, is_hidden, permission, sort, status,
+                      create_user, create_time)
+SELECT 1119, '回收站', 1110, 3, NULL, NULL, NULL, NULL, NULL,
+       NULL, NULL, NULL, 'system:file:recycle:list', 9, 1, 1, NOW()
+WHERE NOT EXISTS (SELECT 1 FROM sys_menu WHERE id = 1119);
+
+INSERT INTO sys_menu (id, title, parent_id, type, path, name, component, redirect, icon,
+                      is_external, is_cache, is_hidden, permission, sort, status,
+                      create_user, create_time)
+SELECT 1120, '还原', 1110, 3, NULL, NULL, NULL, NULL, NULL,
+       NULL, NULL, NULL, 'system:file:recycle:restore', 10, 1, 1, NOW()
+WHERE NOT EXISTS (SELECT 1 FROM sys_menu WHERE id = 1120);
+
+INSERT INTO sys_menu (id, title, parent_id, type, path, name, component, redirect, icon,
+                      is_external, is_cache, is_hidden, permission, sort, status,
+                      create_user, create_time)
+SELECT 1121, '彻底删除', 1110, 3, NULL, NULL, NULL, NULL, NULL,
+       NULL, NULL, NULL, 'system:file:recycle:delete', 11, 1, 1, NOW()
+WHERE NOT EXISTS (SELECT 1 FROM sys_menu WHERE id = 1121);
+
+INSERT INTO sys_menu (id, title, parent_id, type, path, name, component, redirect, icon,
+                      is_external, is_cache, is_hidden, permission, sort, status,
+                      create_user, create_time)
+SELECT 1122, '移动', 1110, 3, NULL, NULL, NULL, NULL, NULL,
+       NULL, NULL, NULL, 'system:file:move', 12, 1, 1, NOW()
+WHERE NOT EXISTS (SELECT 1 FROM sys_menu WHERE id = 1122);
+
+INSERT INTO sys_menu (id, title, parent_id, type, path, name, component, redirect, icon,
+                      is_external, is_cache, is_hidden, permission, sort, status,
+                      create_user, create_time)
+SELECT 1123, '复制', 1110, 3, NULL, NULL, NULL, NULL, NULL,
+       NULL, NULL, NULL, 'system:file:copy', 13, 1, 1, NOW()
+WHERE NOT EXISTS (SELECT 1 FROM sys_menu WHERE id = 1123);
+
+INSERT INTO sys_menu (id, title, parent_id, type, path, name, component, redirect, icon,
+                      is_external, is_cache, is_hidden, permission, sort, status,
+                      create_user, create_time)
+SELECT 1124, '存储配额', 1110, 3, NULL, NULL, NULL, NULL, NULL,
+       NULL, NULL, NULL, 'system:file:quota:list', 14, 1, 1, NOW()
+WHERE NOT EXISTS (SELECT 1 FROM sys_menu WHERE id = 1124);
+
+INSERT INTO sys_menu (id, title, parent_id, type, path, name, component, redirect, icon,
+                      is_external, is_cache, is_hidden, permission, sort, status,
+                      create_user, create_time)
+SELECT 1125, '修改存储配额', 1110, 3, NULL, NULL, NULL, NULL, NULL,
+       NULL, NULL, NULL, 'system:file:quota:update', 15, 1, 1, NOW()
+WHERE NOT EXISTS (SELECT 1 FROM sys_menu WHERE id = 1125);
+
 -- 系统监控（参考 Java main_data.sql）
 INSERT INTO sys_menu (id, title, parent_id, type, path, name, component, redirect, icon,
                       is_external, is_cache, is_hidden, permission, sort, status,
@@ -901,11 +1001,8 @@ func ensureSysFile(db *sql.DB) error {
 	if err := db.QueryRow(checkTable).Scan(&tableName); err != nil {
 		return err
 	}
-	if tableName.Valid {
-		return nil
-	}
-
-	const ddl = `
+	if !tableName.Valid {
+		const ddl = `
 CREATE TABLE IF NOT EXISTS sys_file (
     id                 BIGINT       NOT NULL,
     name               VARCHAR(255) NOT NULL,
@@ -922,6 +1019,7 @@ CREATE TABLE IF NOT EXISTS sys_file (
     thumbnail_size     BIGINT,
     thumbnail_metadata TEXT,
     storage_id         BIGINT       NOT NULL,
+    blob_id            BIGINT,
     create_user        BIGINT       NOT NULL,
     create_time        TIMESTAMP    NOT NULL,
     update_user        BIGINT,
@@ -933,12 +1031,146 @@ CREATE INDEX IF NOT EXISTS idx_file_sha256     ON sys_file (sha256);
 CREATE INDEX IF NOT EXISTS idx_file_storage_id ON sys_file (storage_id);
 CREATE INDEX IF NOT EXISTS idx_file_create_user ON sys_file (create_user);
 `
-	if _, err := db.Exec(ddl); err != nil {
+		if _, err := db.Exec(ddl); err != nil {
+			return err
+		}
+	} else {
+		// blob_id 关联 sys_file_blob，相同内容的文件共用一个物理对象。
+		if _, err := db.Exec(`ALTER TABLE sys_file ADD COLUMN IF NOT EXISTS blob_id BIGINT;`); err != nil {
+			return err
+		}
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_file_blob_id ON sys_file (blob_id);`); err != nil {
 		return err
 	}
 	return nil
 }
 
+// ensureSysFileRecycle 创建文件回收站表，列与 sys_file 一致，另记录删除人和删除时间。
+func ensureSysFileRecycle(db *sql.DB) error {
+	const ddl = `
+CREATE TABLE IF NOT EXISTS sys_file_recycle (
+    id                 BIGINT       NOT NULL,
+    name               VARCHAR(255) NOT NULL,
+    original_name      VARCHAR(255) NOT NULL,
+    size               BIGINT,
+    parent_path        VARCHAR(512) NOT NULL DEFAULT '/',
+    path               VARCHAR(512) NOT NULL,
+    extension          VARCHAR(100),
+    content_type       VARCHAR(255),
+    type               SMALLINT     NOT NULL DEFAULT 1,
+    sha256             VARCHAR(256) NOT NULL,
+    metadata           TEXT,
+    thumbnail_name     VARCHAR(255),
+    thumbnail_size     BIGINT,
+    thumbnail_metadata TEXT,
+    storage_id         BIGINT       NOT NULL,
+    blob_id            BIGINT,
+    create_user        BIGINT       NOT NULL,
+    create_time        TIMESTAMP    NOT NULL,
+    update_user        BIGINT,
+    update_time        TIMESTAMP,
+    delete_user        BIGINT,
+    delete_time        TIMESTAMP    NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_file_recycle_delete_time ON sys_file_recycle (delete_time);
+CREATE INDEX IF NOT EXISTS idx_file_recycle_blob_id ON sys_file_recycle (blob_id);
+`
+	_, err := db.Exec(ddl)
+	return err
+}
+
+// ensureSysFileQuota 创建按角色或用户配置的文件存储配额表（quota 为字节数，0 表示不限制）。
+func ensureSysFileQuota(db *sql.DB) error {
+	const ddl = `
+CREATE TABLE IF NOT EXISTS sys_file_quota (
+    id          BIGINT    NOT NULL,
+    target_type SMALLINT  NOT NULL,
+    target_id   BIGINT    NOT NULL,
+    quota       BIGINT    NOT NULL DEFAULT 0,
+    create_user BIGINT    NOT NULL,
+    create_time TIMESTAMP NOT NULL,
+    update_user BIGINT,
+    update_time TIMESTAMP,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uk_file_quota_target ON sys_file_quota (target_type, target_id);
+`
+	_, err := db.Exec(ddl)
+	return err
+}
+
+// ensureSysFileBlob 创建 sys_file_blob 表（存储中的物理对象及引用计数），
+// 并为尚未关联对象的历史文件补齐记录：同一存储同一路径的文件归为一个对象。
+func ensureSysFileBlob(db *sql.DB) error {
+	const ddl = `
+CREATE TABLE IF NOT EXISTS sys_file_blob (
+    id                 BIGINT       NOT NULL,
+    storage_id         BIGINT       NOT NULL,
+    path               VARCHAR(512) NOT NULL,
+    sha256             VARCHAR(256) NOT NULL,
+    size               BIGINT       NOT NULL DEFAULT 0,
+    metadata           TEXT,
+    thumbnail_name     VARCHAR(255),
+    thumbnail_size     BIGINT,
+    thumbnail_metadata TEXT,
+    ref_count          INTEGER      NOT NULL DEFAULT 0,
+    create_time        TIMESTAMP    NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uk_file_blob_path ON sys_file_blob (storage_id, path);
+CREATE INDEX IF NOT EXISTS idx_file_blob_sha256 ON sys_file_blob (sha256);
+`
+	if _, err := db.Exec(ddl); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const backfillBlob = `
+INSERT INTO sys_file_blob (
+    id, storage_id, path, sha256, size, metadata,
+    thumbnail_name, thumbnail_size, thumbnail_metadata, ref_count, create_time
+)
+SELECT MIN(f.id), f.storage_id, f.path, MIN(f.sha256), MAX(COALESCE(f.size, 0)), MAX(f.metadata),
+       MAX(f.thumbnail_name), MAX(f.thumbnail_size), MAX(f.thumbnail_metadata), 0, MIN(f.create_time)
+FROM sys_file AS f
+WHERE f.type <> 0 AND f.blob_id IS NULL
+GROUP BY f.storage_id, f.path
+ON CONFLICT (storage_id, path) DO NOTHING;
+`
+	if _, err := tx.Exec(backfillBlob); err != nil {
+		return err
+	}
+	const linkFile = `
+UPDATE sys_file AS f
+SET blob_id = b.id
+FROM sys_file_blob AS b
+WHERE f.type <> 0 AND f.blob_id IS NULL
+  AND b.storage_id = f.storage_id AND b.path = f.path;
+`
+	res, err := tx.Exec(linkFile)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		const recount = `
+UPDATE sys_file_blob AS b
+SET ref_count = (SELECT COUNT(*) FROM sys_file AS f WHERE f.blob_id = b.id)
+              + (SELECT COUNT(*) FROM sys_file_recycle AS r WHERE r.blob_id = b.id);
+`
+		if _, err := tx.Exec(recount); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
 // ensureSysOption creates sys_option table and seeds default options.
 func ensureSysOption(db *sql.DB) error {
 	const checkTable = `SELECT to_regclass('public.sys_option');`
@@ -1028,6 +1260,10 @@ WHERE NOT EXISTS (SELECT 1 FROM sys_option WHERE id = 17);
 INSERT INTO sys_option (id, category, name, code, value, default_value, description)
 SELECT 27, 'LOGIN', '是否启用验证码', 'LOGIN_CAPTCHA_ENABLED', NULL, '1', NULL
 WHERE NOT EXISTS (SELECT 1 FROM sys_option WHERE id = 27);
+
+INSERT INTO sys_option (id, category, name, code, value, default_value, description)
+SELECT 30, 'FILE', '回收站保留天数', 'FILE_RECYCLE_RETENTION_DAYS', NULL, '30', '删除的文件在回收站中保留的天数，到期后自动彻底删除（0表示不自动清理）'
+WHERE NOT EXISTS (SELECT 1 FROM sys_option WHERE id = 30);
 `
 	if _, err := db.Exec(seed); err != nil {
 		return err
@@ -1058,6 +1294,8 @@ CREATE TABLE IF NOT EXISTS sys_storage (
     domain      VARCHAR(255) DEFAULT NULL,
     description VARCHAR(200) DEFAULT NULL,
     is_default  BOOLEAN      NOT NULL DEFAULT FALSE,
+    public_access BOOLEAN    NOT NULL DEFAULT TRUE,
+    quota       BIGINT       NOT NULL DEFAULT 0,
     sort        INTEGER      NOT NULL DEFAULT 999,
     status      SMALLINT     NOT NULL DEFAULT 1,
     create_user BIGINT       NOT NULL,
@@ -1091,6 +1329,14 @@ LIMIT 1;
 				return err
 			}
 		}
+		// public_access 控制是否允许通过公开 URL 直接访问文件，关闭后只能经下载接口鉴权访问。
+		if _, err := db.Exec(`ALTER TABLE sys_storage ADD COLUMN IF NOT EXISTS public_access BOOLEAN NOT NULL DEFAULT TRUE;`); err != nil {
+			return err
+		}
+		// quota 为存储容量上限（字节），0 表示不限制。
+		if _, err := db.Exec(`ALTER TABLE sys_storage ADD COLUMN IF NOT EXISTS quota BIGINT NOT NULL DEFAULT 0;`); err != nil {
+			return err
+		}
 	}
 
 	// 默认存储：本地存储 + 相对访问路径，便于开发环境直接使用。
@@ -1488,7 +1734,9 @@ func ensureSysLog(db *sql.DB) error {
 		return err
 	}
 	if tableName.Valid {
-		return nil
+		// 已有表补建链路 ID 索引，用于按 traceId 查询日志。
+		_, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_log_trace_id ON sys_log (trace_id);`)
+		return err
 	}
 
 	const ddl = `
@@ -1519,6 +1767,7 @@ CREATE INDEX IF NOT EXISTS idx_log_module      ON sys_log (module);
 CREATE INDEX IF NOT EXISTS idx_log_ip          ON sys_log (ip);
 CREATE INDEX IF NOT EXISTS idx_log_address     ON sys_log (address);
 CREATE INDEX IF NOT EXISTS idx_log_create_time ON sys_log (create_time);
+CREATE INDEX IF NOT EXISTS idx_log_trace_id    ON sys_log (trace_id);
 `
 	if _, err := db.Exec(ddl); err != nil {
 		return err
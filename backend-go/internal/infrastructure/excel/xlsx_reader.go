@@ -0,0 +1,248 @@
+// Package excel 提供不依赖第三方库的最小化 XLSX 读写能力，
+// 仅覆盖导入导出场景所需的单工作表、纯文本单元格。
+package excel
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidXLSX 表示文件不是有效的 XLSX 工作簿。
+var ErrInvalidXLSX = errors.New("excel: invalid xlsx file")
+
+// ErrXLSXTooLarge 表示工作簿中的 XML 部件解压后超过 MaxPartSize。
+var ErrXLSXTooLarge = errors.New("excel: xlsx part too large")
+
+const (
+	// MaxRows 为单个工作表的最大行数（与 Excel 一致）。
+	MaxRows = 1048576
+	// MaxColumns 为单个工作表的最大列数（A–XFD，与 Excel 一致）。
+	MaxColumns = 16384
+	// MaxPartSize 为单个 XML 部件解压后的最大字节数，防止压缩炸弹。
+	MaxPartSize = 64 << 20
+	// MaxCells 为单个工作表补齐空白单元格后的最大单元格数。
+	// 引用靠后列（如 XFD1）的单元格会使整行补齐到该列，需限制总量以免放大内存占用。
+	MaxCells = 2 << 20
+)
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxRichText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxRichText) text() string {
+	if len(t.R) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.R {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string        `xml:"r,attr"`
+			Type   string        `xml:"t,attr"`
+			Value  string        `xml:"v"`
+			Inline *xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadFirstSheet 读取工作簿第一个工作表的全部行，单元格统一按文本返回。
+// 空行保留为空切片，调用方自行决定是否跳过；补齐后的单元格总数超过 MaxCells 时返回 ErrXLSXTooLarge。
+func ReadFirstSheet(r io.ReaderAt, size int64) ([][]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrInvalidXLSX
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared xlsxSharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeZipXML(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	f, ok := files[sheetPath]
+	if !ok {
+		return nil, ErrInvalidXLSX
+	}
+	var ws xlsxWorksheet
+	if err := decodeZipXML(f, &ws); err != nil {
+		return nil, err
+	}
+
+	var (
+		rows  [][]string
+		total int
+	)
+	for i, row := range ws.Rows {
+		rowNum := row.R
+		if rowNum <= 0 {
+			rowNum = i + 1
+		}
+		if rowNum > MaxRows {
+			return nil, fmt.Errorf("%w: row %d out of range", ErrInvalidXLSX, rowNum)
+		}
+		for len(rows) < rowNum-1 {
+			rows = append(rows, nil)
+		}
+		var cells []string
+		for j, c := range row.Cells {
+			col := j
+			if c.Ref != "" {
+				idx, ok := columnIndex(c.Ref)
+				if !ok {
+					return nil, fmt.Errorf("%w: invalid cell reference %q", ErrInvalidXLSX, c.Ref)
+				}
+				col = idx
+			}
+			if col >= MaxColumns {
+				return nil, fmt.Errorf("%w: column %d out of range", ErrInvalidXLSX, col+1)
+			}
+			if col >= len(cells) {
+				total += col + 1 - len(cells)
+				if total > MaxCells {
+					return nil, fmt.Errorf("%w: more than %d cells", ErrXLSXTooLarge, MaxCells)
+				}
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(strings.TrimSpace(c.Value))
+				if err == nil && idx >= 0 && idx < len(shared.Items) {
+					cells[col] = shared.Items[idx].text()
+				}
+			case "inlineStr":
+				if c.Inline != nil {
+					cells[col] = c.Inline.text()
+				}
+			default:
+				cells[col] = c.Value
+			}
+		}
+		rows = append(rows, cells)
+	}
+	return rows, nil
+}
+
+// firstSheetPath 通过 workbook.xml 与其关系文件定位第一个工作表。
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	wbFile, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "", ErrInvalidXLSX
+	}
+	var wb xlsxWorkbook
+	if err := decodeZipXML(wbFile, &wb); err != nil {
+		return "", err
+	}
+	relFile, ok := files["xl/_rels/workbook.xml.rels"]
+	if len(wb.Sheets) == 0 || !ok {
+		return fallback, nil
+	}
+	var rels xlsxRelationships
+	if err := decodeZipXML(relFile, &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != wb.Sheets[0].RID {
+			continue
+		}
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			return strings.TrimPrefix(target, "/"), nil
+		}
+		return path.Join("xl", target), nil
+	}
+	return fallback, nil
+}
+
+// decodeZipXML 解码压缩包内的 XML 部件，解压后超过 MaxPartSize 时返回 ErrXLSXTooLarge。
+func decodeZipXML(f *zip.File, v any) error {
+	if f.UncompressedSize64 > MaxPartSize {
+		return fmt.Errorf("%w: %s", ErrXLSXTooLarge, f.Name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	// 头部声明的大小不可信，实际读取同样限量。
+	lr := &io.LimitedReader{R: rc, N: MaxPartSize + 1}
+	err = xml.NewDecoder(lr).Decode(v)
+	if lr.N <= 0 {
+		return fmt.Errorf("%w: %s", ErrXLSXTooLarge, f.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("excel: decode %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// columnIndex 将单元格引用（如 "AB12"）转换为从 0 开始的列号。
+// 列字母最多 3 位且不超过 XFD，否则返回 false。
+func columnIndex(ref string) (int, bool) {
+	n := 0
+	i := 0
+	for ; i < len(ref); i++ {
+		ch := ref[i]
+		if ch >= 'a' && ch <= 'z' {
+			ch -= 'a' - 'A'
+		}
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		if i == 3 {
+			return 0, false
+		}
+		n = n*26 + int(ch-'A'+1)
+	}
+	if i == 0 || n > MaxColumns {
+		return 0, false
+	}
+	return n - 1, true
+}
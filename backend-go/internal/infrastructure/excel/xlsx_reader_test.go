@@ -0,0 +1,180 @@
+package excel
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testSheetHead = `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+// buildXLSX 生成仅含工作簿、共享字符串与第一个工作表的最小 XLSX。
+func buildXLSX(t *testing.T, sheetData string) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	parts := []struct{ name, body string }{
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"></workbook>`},
+		{"xl/sharedStrings.xml", `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<si><t>name</t></si><si><r><t>Ali</t></r><r><t>ce</t></r></si></sst>`},
+		{"xl/worksheets/sheet1.xml", testSheetHead + sheetData + `</sheetData></worksheet>`},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(p.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestReadFirstSheet(t *testing.T) {
+	tests := []struct {
+		name      string
+		sheetData string
+		want      [][]string
+		wantErr   error
+	}{
+		{
+			name: "shared inline and plain values",
+			sheetData: `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="inlineStr"><is><t>age</t></is></c></row>` +
+				`<row r="2"><c r="A2" t="s"><v>1</v></c><c r="B2"><v>30</v></c></row>`,
+			want: [][]string{{"name", "age"}, {"Alice", "30"}},
+		},
+		{
+			name:      "gaps in rows and columns",
+			sheetData: `<row r="3"><c r="C3"><v>x</v></c></row>`,
+			want:      [][]string{nil, nil, {"", "", "x"}},
+		},
+		{
+			name:      "missing refs fall back to position",
+			sheetData: `<row><c><v>a</v></c><c><v>b</v></c></row>`,
+			want:      [][]string{{"a", "b"}},
+		},
+		{
+			name:      "shared string index out of range",
+			sheetData: `<row r="1"><c r="A1" t="s"><v>9</v></c></row>`,
+			want:      [][]string{{""}},
+		},
+		{
+			name:      "last column XFD",
+			sheetData: `<row r="1"><c r="XFD1"><v>x</v></c></row>`,
+		},
+		{
+			name:      "row beyond limit",
+			sheetData: `<row r="1048577"><c r="A1048577"><v>x</v></c></row>`,
+			wantErr:   ErrInvalidXLSX,
+		},
+		{
+			name:      "column beyond XFD",
+			sheetData: `<row r="1"><c r="XFE1"><v>x</v></c></row>`,
+			wantErr:   ErrInvalidXLSX,
+		},
+		{
+			name:      "column letters overflow",
+			sheetData: `<row r="1"><c r="` + strings.Repeat("Z", 40) + `1"><v>x</v></c></row>`,
+			wantErr:   ErrInvalidXLSX,
+		},
+		{
+			name:      "reference without letters",
+			sheetData: `<row r="1"><c r="11"><v>x</v></c></row>`,
+			wantErr:   ErrInvalidXLSX,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := buildXLSX(t, tt.sheetData)
+			got, err := ReadFirstSheet(r, r.Size())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ReadFirstSheet() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadFirstSheet() error = %v", err)
+			}
+			if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ReadFirstSheet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFirstSheetMalformedPackage(t *testing.T) {
+	t.Run("not a zip", func(t *testing.T) {
+		r := bytes.NewReader([]byte("id,name\n1,alice\n"))
+		if _, err := ReadFirstSheet(r, r.Size()); !errors.Is(err, ErrInvalidXLSX) {
+			t.Fatalf("error = %v, want ErrInvalidXLSX", err)
+		}
+	})
+	t.Run("missing workbook", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		if _, err := zw.Create("xl/worksheets/sheet1.xml"); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		r := bytes.NewReader(buf.Bytes())
+		if _, err := ReadFirstSheet(r, r.Size()); !errors.Is(err, ErrInvalidXLSX) {
+			t.Fatalf("error = %v, want ErrInvalidXLSX", err)
+		}
+	})
+	t.Run("truncated sheet xml", func(t *testing.T) {
+		r := buildXLSX(t, `<row r="1"><c r="A1"><v>x</v>`)
+		if _, err := ReadFirstSheet(r, r.Size()); err == nil {
+			t.Fatal("expected decode error")
+		}
+	})
+	t.Run("oversized part", func(t *testing.T) {
+		r := buildXLSX(t, strings.Repeat(" ", MaxPartSize))
+		if _, err := ReadFirstSheet(r, r.Size()); !errors.Is(err, ErrXLSXTooLarge) {
+			t.Fatalf("error = %v, want ErrXLSXTooLarge", err)
+		}
+	})
+	t.Run("too many cells from wide references", func(t *testing.T) {
+		var b strings.Builder
+		for i := 1; i <= MaxCells/MaxColumns+1; i++ {
+			b.WriteString(`<row><c r="XFD` + strconv.Itoa(i) + `"><v>x</v></c></row>`)
+		}
+		r := buildXLSX(t, b.String())
+		if _, err := ReadFirstSheet(r, r.Size()); !errors.Is(err, ErrXLSXTooLarge) {
+			t.Fatalf("error = %v, want ErrXLSXTooLarge", err)
+		}
+	})
+}
+
+func TestColumnIndex(t *testing.T) {
+	tests := []struct {
+		ref    string
+		want   int
+		wantOK bool
+	}{
+		{"A1", 0, true},
+		{"z9", 25, true},
+		{"AA10", 26, true},
+		{"XFD1048576", MaxColumns - 1, true},
+		{"XFE1", 0, false},
+		{"AAAA1", 0, false},
+		{"1", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := columnIndex(tt.ref)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("columnIndex(%q) = %d, %v; want %d, %v", tt.ref, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package excel
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]string
+	}{
+		{"header only", [][]string{{"ID", "名称"}}},
+		{"escaped values", [][]string{{"name", "desc"}, {"<a & b>", `say "hi"`}}},
+		{"multiline and tabs", [][]string{{"a"}, {"line1\nline2\tend"}}},
+		{"preserved spaces", [][]string{{"a"}, {"  padded  "}}},
+		{"wide row", [][]string{strings.Split(strings.Repeat("x,", 29)+"x", ",")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, "Sheet")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range tt.rows {
+				if err := w.WriteRow(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadFirstSheet(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatalf("ReadFirstSheet() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.rows) {
+				t.Fatalf("round trip = %q, want %q", got, tt.rows)
+			}
+		})
+	}
+}
+
+func TestWriterStripsControlCharacters(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow([]string{"a\x00b\x1fc"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadFirstSheet(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"abc"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		idx  int
+		want string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+		{MaxColumns - 1, "XFD"},
+	}
+	for _, tt := range tests {
+		if got := columnName(tt.idx); got != tt.want {
+			t.Errorf("columnName(%d) = %q, want %q", tt.idx, got, tt.want)
+		}
+		if idx, ok := columnIndex(tt.want + "1"); !ok || idx != tt.idx {
+			t.Errorf("columnIndex(%q) = %d, %v; want %d", tt.want+"1", idx, ok, tt.idx)
+		}
+	}
+}
+
+func TestSanitizeSheetName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"用户数据", "用户数据"},
+		{" a[b]:c*d?e/f\\g ", "abcdefg"},
+		{strings.Repeat("长", 40), strings.Repeat("长", 31)},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := sanitizeSheetName(tt.in); got != tt.want {
+			t.Errorf("sanitizeSheetName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	domain "voc-go-backend/internal/domain/client"
+)
+
+// PgRepository implements domain.Repository using PostgreSQL.
+type PgRepository struct {
+	db *sql.DB
+}
+
+// NewPgRepository creates a new PgRepository.
+func NewPgRepository(db *sql.DB) *PgRepository {
+	return &PgRepository{db: db}
+}
+
+var _ domain.Repository = (*PgRepository)(nil)
+
+// GetByClientID loads a client by client_id from sys_client.
+func (r *PgRepository) GetByClientID(ctx context.Context, clientID string) (*domain.Client, error) {
+	const query = `
+SELECT
+    id,
+    client_id,
+    client_type,
+    auth_type,
+    active_timeout,
+    timeout,
+    status
+FROM sys_client
+WHERE client_id = $1
+LIMIT 1;
+`
+
+	var (
+		c       domain.Client
+		authRaw []byte
+	)
+	err := r.db.QueryRowContext(ctx, query, clientID).Scan(
+		&c.ID,
+		&c.ClientID,
+		&c.ClientType,
+		&authRaw,
+		&c.ActiveTimeout,
+		&c.Timeout,
+		&c.Status,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(authRaw) > 0 {
+		if err := json.Unmarshal(authRaw, &c.AuthType); err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
@@ -0,0 +1,53 @@
+package option
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+
+	domain "voc-go-backend/internal/domain/option"
+)
+
+// PgRepository implements domain.Repository using PostgreSQL.
+type PgRepository struct {
+	db *sql.DB
+}
+
+// NewPgRepository creates a new PgRepository.
+func NewPgRepository(db *sql.DB) *PgRepository {
+	return &PgRepository{db: db}
+}
+
+var _ domain.Repository = (*PgRepository)(nil)
+
+// GetValues loads COALESCE(value, default_value) for the given codes from sys_option.
+func (r *PgRepository) GetValues(ctx context.Context, codes ...string) (domain.Values, error) {
+	values := make(domain.Values, len(codes))
+	if len(codes) == 0 {
+		return values, nil
+	}
+
+	const query = `
+SELECT code, COALESCE(value, default_value, '')
+FROM sys_option
+WHERE code = ANY($1);
+`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(codes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var code, val string
+		if err := rows.Scan(&code, &val); err != nil {
+			return nil, err
+		}
+		values[code] = val
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
@@ -0,0 +1,204 @@
+package syslog
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	domain "voc-go-backend/internal/domain/syslog"
+)
+
+// 异步写入默认参数。
+const (
+	defaultAsyncQueueSize     = 10000
+	defaultAsyncBatchSize     = 100
+	defaultAsyncFlushInterval = time.Second
+	// maxAsyncBatchSize 保证单条 INSERT 的参数个数不超过 PostgreSQL 上限 65535
+	maxAsyncBatchSize = 1000
+	// asyncWriteTimeout 为单次批量写入的超时时间
+	asyncWriteTimeout = 10 * time.Second
+)
+
+// AsyncOptions 为异步写入参数，零值使用默认值。
+type AsyncOptions struct {
+	// QueueSize 为队列容量，默认 10000
+	QueueSize int
+	// BatchSize 为单次写入的最大条数，默认 100，最大 1000
+	BatchSize int
+	// FlushInterval 为未攒满一批时的写入间隔，默认 1 秒
+	FlushInterval time.Duration
+}
+
+// batchWriter 为异步写入使用的底层仓储，由 PgRepository 实现。
+type batchWriter interface {
+	Save(ctx context.Context, rec *domain.Record) error
+	SaveBatch(ctx context.Context, recs []*domain.Record) error
+}
+
+// AsyncRepository 异步批量写入系统日志：Save 仅将记录放入内存队列，
+// 由后台协程在攒满 BatchSize 条或每隔 FlushInterval 时批量插入。
+// 队列已满时直接丢弃日志并计数，避免数据库变慢拖慢业务请求；Close 时写入队列中剩余的记录。
+type AsyncRepository struct {
+	repo  batchWriter
+	opts  AsyncOptions
+	queue chan *domain.Record
+	done  chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+
+	written atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64
+}
+
+var (
+	_ domain.Repository    = (*AsyncRepository)(nil)
+	_ domain.StatsReporter = (*AsyncRepository)(nil)
+)
+
+// NewAsyncRepository 创建异步日志仓储并启动后台写入协程。
+func NewAsyncRepository(repo *PgRepository, opts AsyncOptions) *AsyncRepository {
+	return newAsyncRepository(repo, opts)
+}
+
+func newAsyncRepository(repo batchWriter, opts AsyncOptions) *AsyncRepository {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultAsyncQueueSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultAsyncBatchSize
+	}
+	if opts.BatchSize > maxAsyncBatchSize {
+		opts.BatchSize = maxAsyncBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultAsyncFlushInterval
+	}
+	r := &AsyncRepository{
+		repo:  repo,
+		opts:  opts,
+		queue: make(chan *domain.Record, opts.QueueSize),
+		done:  make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Save 将日志记录放入写入队列，不等待落库。
+// 队列已满或已关闭时丢弃记录并计入 dropped，不返回错误，由后台协程汇总打印。
+func (r *AsyncRepository) Save(_ context.Context, rec *domain.Record) error {
+	if rec == nil {
+		return nil
+	}
+	prepareRecord(rec)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		r.dropped.Add(1)
+		return nil
+	}
+	select {
+	case r.queue <- rec:
+	default:
+		r.dropped.Add(1)
+	}
+	return nil
+}
+
+// Stats 返回写入队列状态。
+func (r *AsyncRepository) Stats() domain.WriterStats {
+	return domain.WriterStats{
+		Queued:   len(r.queue),
+		Capacity: cap(r.queue),
+		Written:  r.written.Load(),
+		Dropped:  r.dropped.Load(),
+		Failed:   r.failed.Load(),
+	}
+}
+
+// Close 停止接收新日志，并等待队列中剩余的记录写入完成或 ctx 结束。
+func (r *AsyncRepository) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 为后台写入协程。
+func (r *AsyncRepository) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*domain.Record, 0, r.opts.BatchSize)
+	var reported int64
+	for {
+		select {
+		case rec, ok := <-r.queue:
+			if !ok {
+				r.flush(batch)
+				return
+			}
+			batch = append(batch, rec)
+			if len(batch) >= r.opts.BatchSize {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			r.flush(batch)
+			batch = batch[:0]
+			if dropped := r.dropped.Load(); dropped > reported {
+				log.Printf("[syslog] queue full, dropped %d records (total %d)", dropped-reported, dropped)
+				reported = dropped
+			}
+		}
+	}
+}
+
+// flush 批量写入日志；整批失败时逐条重试，避免个别异常记录导致整批丢失。
+func (r *AsyncRepository) flush(batch []*domain.Record) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), asyncWriteTimeout)
+	defer cancel()
+
+	err := r.repo.SaveBatch(ctx, batch)
+	if err == nil {
+		r.written.Add(int64(len(batch)))
+		return
+	}
+	if len(batch) == 1 || ctx.Err() != nil {
+		r.failed.Add(int64(len(batch)))
+		log.Printf("[syslog] batch save failed: count=%d err=%v", len(batch), err)
+		return
+	}
+
+	failed := 0
+	for _, rec := range batch {
+		if saveErr := r.repo.Save(ctx, rec); saveErr != nil {
+			failed++
+			err = saveErr
+			continue
+		}
+		r.written.Add(1)
+	}
+	if failed > 0 {
+		r.failed.Add(int64(failed))
+		log.Printf("[syslog] batch save failed: count=%d failed=%d err=%v", len(batch), failed, err)
+	}
+}
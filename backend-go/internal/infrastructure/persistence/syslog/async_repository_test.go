@@ -0,0 +1,179 @@
+package syslog
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	domain "voc-go-backend/internal/domain/syslog"
+)
+
+// fakeWriter 记录每次批量写入的条数；Module 为 "bad" 的记录写入失败。
+type fakeWriter struct {
+	mu      sync.Mutex
+	batches []int
+	saved   int
+
+	// started 非 nil 时在首次批量写入开始时关闭，随后等待 release
+	started chan struct{}
+	release chan struct{}
+}
+
+var errBadRecord = errors.New("bad record")
+
+func (f *fakeWriter) Save(_ context.Context, rec *domain.Record) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if rec.Module == "bad" {
+		return errBadRecord
+	}
+	f.saved++
+	return nil
+}
+
+func (f *fakeWriter) SaveBatch(_ context.Context, recs []*domain.Record) error {
+	if f.started != nil {
+		close(f.started)
+		f.started = nil
+		<-f.release
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, len(recs))
+	for _, rec := range recs {
+		if rec.Module == "bad" {
+			return errBadRecord
+		}
+	}
+	f.saved += len(recs)
+	return nil
+}
+
+func closeAsync(t *testing.T, r *AsyncRepository) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+func TestNewAsyncRepositoryOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts AsyncOptions
+		want AsyncOptions
+	}{
+		{"defaults", AsyncOptions{}, AsyncOptions{QueueSize: 10000, BatchSize: 100, FlushInterval: time.Second}},
+		{"configured", AsyncOptions{QueueSize: 10, BatchSize: 5, FlushInterval: time.Minute}, AsyncOptions{QueueSize: 10, BatchSize: 5, FlushInterval: time.Minute}},
+		{"batch size clamped", AsyncOptions{BatchSize: 5000}, AsyncOptions{QueueSize: 10000, BatchSize: 1000, FlushInterval: time.Second}},
+		{"negative values", AsyncOptions{QueueSize: -1, BatchSize: -1, FlushInterval: -1}, AsyncOptions{QueueSize: 10000, BatchSize: 100, FlushInterval: time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newAsyncRepository(&fakeWriter{}, tt.opts)
+			defer closeAsync(t, r)
+			if r.opts != tt.want {
+				t.Fatalf("opts = %+v, want %+v", r.opts, tt.want)
+			}
+			if got := r.Stats().Capacity; got != tt.want.QueueSize {
+				t.Fatalf("Stats().Capacity = %d, want %d", got, tt.want.QueueSize)
+			}
+		})
+	}
+}
+
+func TestAsyncRepositoryFlush(t *testing.T) {
+	tests := []struct {
+		name        string
+		modules     []string
+		batchSize   int
+		wantBatches []int
+		wantWritten int64
+		wantFailed  int64
+	}{
+		{"split into batches", []string{"a", "b", "c", "d", "e"}, 2, []int{2, 2, 1}, 5, 0},
+		{"bad record retried one by one", []string{"a", "bad", "c"}, 3, []int{3}, 2, 1},
+		{"single bad record", []string{"bad"}, 1, []int{1}, 0, 1},
+		{"empty", nil, 2, nil, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &fakeWriter{}
+			r := newAsyncRepository(w, AsyncOptions{BatchSize: tt.batchSize, FlushInterval: time.Hour})
+			for _, module := range tt.modules {
+				if err := r.Save(context.Background(), &domain.Record{Module: module}); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+			}
+			closeAsync(t, r)
+
+			if len(w.batches) != len(tt.wantBatches) {
+				t.Fatalf("batches = %v, want %v", w.batches, tt.wantBatches)
+			}
+			for i := range w.batches {
+				if w.batches[i] != tt.wantBatches[i] {
+					t.Fatalf("batches = %v, want %v", w.batches, tt.wantBatches)
+				}
+			}
+			stats := r.Stats()
+			if stats.Written != tt.wantWritten || stats.Failed != tt.wantFailed || stats.Dropped != 0 {
+				t.Fatalf("Stats() = %+v, want written=%d failed=%d", stats, tt.wantWritten, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestAsyncRepositorySavePreparesRecord(t *testing.T) {
+	r := newAsyncRepository(&fakeWriter{}, AsyncOptions{})
+	defer closeAsync(t, r)
+
+	rec := &domain.Record{}
+	if err := r.Save(context.Background(), rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.ID == 0 || rec.CreateTime.IsZero() {
+		t.Fatalf("Save() did not fill ID/CreateTime: %+v", rec)
+	}
+	if err := r.Save(context.Background(), nil); err != nil {
+		t.Fatalf("Save(nil) error = %v", err)
+	}
+}
+
+func TestAsyncRepositoryDrop(t *testing.T) {
+	w := &fakeWriter{started: make(chan struct{}), release: make(chan struct{})}
+	started := w.started
+	r := newAsyncRepository(w, AsyncOptions{QueueSize: 1, BatchSize: 1, FlushInterval: time.Hour})
+
+	ctx := context.Background()
+	_ = r.Save(ctx, &domain.Record{})
+	<-started // 后台协程正在写入第一条，队列为空
+	_ = r.Save(ctx, &domain.Record{})
+	_ = r.Save(ctx, &domain.Record{}) // 队列已满
+	close(w.release)
+	closeAsync(t, r)
+	_ = r.Save(ctx, &domain.Record{}) // 已关闭
+
+	stats := r.Stats()
+	if stats.Written != 2 || stats.Dropped != 2 || stats.Queued != 0 {
+		t.Fatalf("Stats() = %+v, want written=2 dropped=2", stats)
+	}
+}
+
+func TestAsyncRepositoryCloseTimeout(t *testing.T) {
+	w := &fakeWriter{started: make(chan struct{}), release: make(chan struct{})}
+	started := w.started
+	r := newAsyncRepository(w, AsyncOptions{BatchSize: 1, FlushInterval: time.Hour})
+	_ = r.Save(context.Background(), &domain.Record{})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := r.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(w.release)
+	closeAsync(t, r)
+}
@@ -3,12 +3,41 @@ package syslog
 import (
 	"context"
 	"database/sql"
+	"strconv"
+	"strings"
 	"time"
 
 	domain "voc-go-backend/internal/domain/syslog"
 	"voc-go-backend/internal/infrastructure/id"
 )
 
+// sysLogColumns 为写入 sys_log 的字段，顺序与 recordArgs 一致。
+const sysLogColumns = `
+    id,
+    trace_id,
+    description,
+    module,
+    request_url,
+    request_method,
+    request_headers,
+    request_body,
+    status_code,
+    response_headers,
+    response_body,
+    time_taken,
+    ip,
+    address,
+    browser,
+    os,
+    status,
+    error_msg,
+    create_user,
+    create_time
+`
+
+// sysLogColumnCount 为 sysLogColumns 的字段数。
+const sysLogColumnCount = 20
+
 // PgRepository 基于 PostgreSQL 的系统日志仓储实现。
 type PgRepository struct {
 	db *sql.DB
@@ -26,43 +55,53 @@ func (r *PgRepository) Save(ctx context.Context, rec *domain.Record) error {
 	if rec == nil {
 		return nil
 	}
+	return r.SaveBatch(ctx, []*domain.Record{rec})
+}
+
+// SaveBatch 使用一条多行 INSERT 写入多条日志记录。
+func (r *PgRepository) SaveBatch(ctx context.Context, recs []*domain.Record) error {
+	if len(recs) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString("INSERT INTO sys_log (")
+	b.WriteString(sysLogColumns)
+	b.WriteString(") VALUES ")
+	args := make([]any, 0, len(recs)*sysLogColumnCount)
+	for i, rec := range recs {
+		prepareRecord(rec)
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j := 1; j <= sysLogColumnCount; j++ {
+			if j > 1 {
+				b.WriteString(", ")
+			}
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(len(args) + j))
+		}
+		b.WriteByte(')')
+		args = append(args, recordArgs(rec)...)
+	}
+
+	_, err := r.db.ExecContext(ctx, b.String(), args...)
+	return err
+}
+
+// prepareRecord 补全未设置的 ID 与创建时间。
+func prepareRecord(rec *domain.Record) {
 	if rec.ID == 0 {
 		rec.ID = id.Next()
 	}
 	if rec.CreateTime.IsZero() {
 		rec.CreateTime = time.Now()
 	}
+}
 
-	const query = `
-INSERT INTO sys_log (
-    id,
-    trace_id,
-    description,
-    module,
-    request_url,
-    request_method,
-    request_headers,
-    request_body,
-    status_code,
-    response_headers,
-    response_body,
-    time_taken,
-    ip,
-    address,
-    browser,
-    os,
-    status,
-    error_msg,
-    create_user,
-    create_time
-) VALUES (
-    $1, $2, $3, $4, $5,
-    $6, $7, $8, $9, $10,
-    $11, $12, $13, $14, $15,
-    $16, $17, $18, $19, $20
-);
-`
-
+// recordArgs 返回与 sysLogColumns 对应的参数。
+func recordArgs(rec *domain.Record) []any {
 	var createUser sql.NullInt64
 	if rec.CreateUser != nil {
 		createUser = sql.NullInt64{
@@ -71,9 +110,7 @@ INSERT INTO sys_log (
 		}
 	}
 
-	_, err := r.db.ExecContext(
-		ctx,
-		query,
+	return []any{
 		rec.ID,
 		rec.TraceID,
 		rec.Description,
@@ -94,7 +131,5 @@ INSERT INTO sys_log (
 		rec.ErrorMsg,
 		createUser,
 		rec.CreateTime,
-	)
-	return err
+	}
 }
-
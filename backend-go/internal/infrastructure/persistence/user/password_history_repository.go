@@ -0,0 +1,80 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	domain "voc-go-backend/internal/domain/user"
+	"voc-go-backend/internal/infrastructure/id"
+)
+
+// PgPasswordHistoryRepository implements domain.PasswordHistoryRepository using PostgreSQL.
+type PgPasswordHistoryRepository struct {
+	db *sql.DB
+}
+
+// NewPgPasswordHistoryRepository creates a new PgPasswordHistoryRepository.
+func NewPgPasswordHistoryRepository(db *sql.DB) *PgPasswordHistoryRepository {
+	return &PgPasswordHistoryRepository{db: db}
+}
+
+var _ domain.PasswordHistoryRepository = (*PgPasswordHistoryRepository)(nil)
+
+// ListRecent loads the newest password hashes of a user from sys_user_password_history.
+func (r *PgPasswordHistoryRepository) ListRecent(ctx context.Context, userID int64, limit int) ([]string, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	const query = `
+SELECT password
+FROM sys_user_password_history
+WHERE user_id = $1
+ORDER BY create_time DESC, id DESC
+LIMIT $2;
+`
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []string
+	for rows.Next() {
+		var pwd string
+		if err := rows.Scan(&pwd); err != nil {
+			return nil, err
+		}
+		list = append(list, pwd)
+	}
+	return list, rows.Err()
+}
+
+// AddPasswordHistory inserts a replaced password hash within tx and prunes
+// entries beyond domain.PasswordHistoryKeep. Callers that overwrite
+// sys_user.password in their own transaction (e.g. user import) use it so that
+// all password changes share one history writer.
+func AddPasswordHistory(ctx context.Context, tx *sql.Tx, userID int64, encoded string) error {
+	if encoded == "" {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO sys_user_password_history (id, user_id, password, create_time) VALUES ($1, $2, $3, $4)`,
+		id.Next(), userID, encoded, time.Now(),
+	); err != nil {
+		return err
+	}
+
+	const prune = `
+DELETE FROM sys_user_password_history
+WHERE user_id = $1
+  AND id NOT IN (
+      SELECT id FROM sys_user_password_history
+      WHERE user_id = $1
+      ORDER BY create_time DESC, id DESC
+      LIMIT $2
+  );
+`
+	_, err := tx.ExecContext(ctx, prune, userID, domain.PasswordHistoryKeep)
+	return err
+}
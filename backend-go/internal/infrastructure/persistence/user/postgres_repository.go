@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	domain "voc-go-backend/internal/domain/user"
 )
@@ -210,3 +211,93 @@ LIMIT 1;
 	return &u, nil
 }
 
+
+// ExistsByPhone checks whether the phone is used by another user.
+func (r *PgRepository) ExistsByPhone(ctx context.Context, phone string, excludeID int64) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM sys_user WHERE phone = $1 AND id <> $2)`, phone, excludeID,
+	).Scan(&exists)
+	return exists, err
+}
+
+// ExistsByEmail checks whether the email is used by another user.
+func (r *PgRepository) ExistsByEmail(ctx context.Context, email string, excludeID int64) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM sys_user WHERE email = $1 AND id <> $2)`, email, excludeID,
+	).Scan(&exists)
+	return exists, err
+}
+
+// UpdateBasicInfo updates nickname and gender in sys_user.
+func (r *PgRepository) UpdateBasicInfo(ctx context.Context, id int64, nickname string, gender int16) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE sys_user SET nickname = $1, gender = $2, update_user = $3, update_time = $4 WHERE id = $3`,
+		nickname, gender, id, time.Now(),
+	)
+	return err
+}
+
+// UpdateAvatar updates avatar in sys_user and returns the previous value.
+// The old row is locked first so the returned avatar is the one actually replaced.
+func (r *PgRepository) UpdateAvatar(ctx context.Context, id int64, avatar string) (string, error) {
+	var old sql.NullString
+	err := r.db.QueryRowContext(ctx, `
+UPDATE sys_user AS u
+SET avatar = $1, update_user = $2, update_time = $3
+FROM (SELECT id, avatar FROM sys_user WHERE id = $2 FOR UPDATE) AS prev
+WHERE u.id = prev.id
+RETURNING prev.avatar`,
+		avatar, id, time.Now(),
+	).Scan(&old)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return old.String, err
+}
+
+// UpdatePassword updates password and pwd_reset_time in sys_user and records
+// the replaced hash in sys_user_password_history within one transaction.
+func (r *PgRepository) UpdatePassword(ctx context.Context, id int64, encoded string, resetTime time.Time, operatorID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var replaced sql.NullString
+	if err := tx.QueryRowContext(ctx,
+		`SELECT password FROM sys_user WHERE id = $1 FOR UPDATE`, id,
+	).Scan(&replaced); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE sys_user SET password = $1, pwd_reset_time = $2, update_user = $3, update_time = $2 WHERE id = $4`,
+		encoded, resetTime, operatorID, id,
+	); err != nil {
+		return err
+	}
+	if err := AddPasswordHistory(ctx, tx, id, replaced.String); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdatePhone updates phone in sys_user.
+func (r *PgRepository) UpdatePhone(ctx context.Context, id int64, phone string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE sys_user SET phone = $1, update_user = $2, update_time = $3 WHERE id = $2`,
+		phone, id, time.Now(),
+	)
+	return err
+}
+
+// UpdateEmail updates email in sys_user.
+func (r *PgRepository) UpdateEmail(ctx context.Context, id int64, email string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE sys_user SET email = $1, update_user = $2, update_time = $3 WHERE id = $2`,
+		email, id, time.Now(),
+	)
+	return err
+}
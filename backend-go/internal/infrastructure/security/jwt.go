@@ -1,6 +1,9 @@
 package security
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"strings"
 	"time"
@@ -8,11 +11,23 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// ErrTokenRevoked is returned by Parse when the token has been revoked
+// (logout / force logout) before its natural expiry.
+var ErrTokenRevoked = errors.New("token revoked")
+
+// TokenRevocationStore records revoked token IDs (jti) until they would have
+// expired anyway, so that a revoked token is rejected immediately.
+type TokenRevocationStore interface {
+	Revoke(ctx context.Context, jti string, ttl time.Duration) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
 // TokenService issues JWT tokens compatible with the front-end expectation
 // (plain string token returned by /auth/login).
 type TokenService struct {
-	secret []byte
-	ttl    time.Duration
+	secret  []byte
+	ttl     time.Duration
+	revoked TokenRevocationStore
 }
 
 // NewTokenService creates a new TokenService with the given secret and TTL.
@@ -26,28 +41,98 @@ func NewTokenService(secret string, ttl time.Duration) *TokenService {
 	}
 }
 
+// SetRevocationStore enables server-side revocation checks in Parse.
+func (s *TokenService) SetRevocationStore(store TokenRevocationStore) {
+	s.revoked = store
+}
+
 // Claims defines minimal JWT claims we care about.
+// RegisteredClaims.ID carries the jti used for revocation.
 type Claims struct {
 	UserID int64 `json:"userId"`
 	jwt.RegisteredClaims
 }
 
-// Generate issues a token for the given user.
+// Generate issues a token for the given user using the default TTL.
 func (s *TokenService) Generate(userID int64) (string, error) {
+	token, _, err := s.GenerateWithTTL(userID, 0)
+	return token, err
+}
+
+// GenerateWithTTL issues a token that expires after ttl (the default TTL when
+// ttl <= 0) and returns it together with its expiry time.
+func (s *TokenService) GenerateWithTTL(userID int64, ttl time.Duration) (string, time.Time, error) {
+	if ttl <= 0 {
+		ttl = s.ttl
+	}
+	jti, err := newTokenID()
+	if err != nil {
+		return "", time.Time{}, err
+	}
 	now := time.Now()
+	expiresAt := now.Add(ttl)
 	claims := Claims{
 		UserID: userID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secret)
+	signed, err := token.SignedString(s.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
 }
 
-// Parse extracts Claims from a token string and validates signature and expiry.
+// Parse extracts Claims from a token string and validates signature, expiry
+// and revocation state.
 func (s *TokenService) Parse(tokenStr string) (*Claims, error) {
+	return s.ParseContext(context.Background(), tokenStr)
+}
+
+// ParseContext is like Parse but uses ctx for the revocation lookup.
+func (s *TokenService) ParseContext(ctx context.Context, tokenStr string) (*Claims, error) {
+	claims, err := s.parseSigned(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	if s.revoked != nil {
+		revoked, err := s.revoked.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
+	}
+	return claims, nil
+}
+
+// Revoke invalidates the given token until its original expiry.
+// Tokens that are already invalid or expired are ignored.
+func (s *TokenService) Revoke(ctx context.Context, tokenStr string) error {
+	if s.revoked == nil {
+		return nil
+	}
+	claims, err := s.parseSigned(tokenStr)
+	if err != nil {
+		return nil
+	}
+	ttl := s.ttl
+	if claims.ExpiresAt != nil {
+		ttl = time.Until(claims.ExpiresAt.Time)
+	}
+	if ttl <= 0 {
+		return nil
+	}
+	return s.revoked.Revoke(ctx, claims.ID, ttl)
+}
+
+// parseSigned validates signature and expiry without consulting the revocation store.
+func (s *TokenService) parseSigned(tokenStr string) (*Claims, error) {
 	if tokenStr == "" {
 		return nil, errors.New("empty token")
 	}
@@ -65,6 +150,18 @@ func (s *TokenService) Parse(tokenStr string) (*Claims, error) {
 	if !ok || !token.Valid {
 		return nil, errors.New("invalid token")
 	}
+	// Tokens issued before jti was introduced cannot be revoked, reject them.
+	if claims.ID == "" {
+		return nil, errors.New("token missing jti")
+	}
 	return claims, nil
 }
 
+// newTokenID returns a random 128-bit hex string used as jti.
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
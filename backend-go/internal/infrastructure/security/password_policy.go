@@ -0,0 +1,221 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	optiondomain "voc-go-backend/internal/domain/option"
+	userdomain "voc-go-backend/internal/domain/user"
+)
+
+// Password policy option codes (sys_option, category PASSWORD).
+const (
+	OptionPasswordMinLength             = "PASSWORD_MIN_LENGTH"
+	OptionPasswordRequireSymbols        = "PASSWORD_REQUIRE_SYMBOLS"
+	OptionPasswordAllowContainUsername  = "PASSWORD_ALLOW_CONTAIN_USERNAME"
+	OptionPasswordRepetitionTimes       = "PASSWORD_REPETITION_TIMES"
+	OptionPasswordExpirationDays        = "PASSWORD_EXPIRATION_DAYS"
+	OptionPasswordExpirationWarningDays = "PASSWORD_EXPIRATION_WARNING_DAYS"
+
+	// PasswordMaxLength is the fixed upper bound of password length.
+	PasswordMaxLength = 32
+)
+
+// passwordSymbols mirrors Java RegexConstants.SPECIAL_CHARACTER.
+const passwordSymbols = "-_`~!@#$%^&*()+=|{}':;\",[].<>/?！￥…（）—【】‘；：”“’。，、？\\"
+
+// PasswordPolicyError is a policy violation whose message can be shown to the user.
+type PasswordPolicyError struct {
+	msg string
+}
+
+func (e *PasswordPolicyError) Error() string { return e.msg }
+
+func policyErrorf(format string, args ...any) error {
+	return &PasswordPolicyError{msg: fmt.Sprintf(format, args...)}
+}
+
+// PasswordPolicy holds the effective PASSWORD_* options.
+type PasswordPolicy struct {
+	MinLength            int
+	RequireSymbols       bool
+	AllowContainUsername bool
+	RepetitionTimes      int
+}
+
+// Check validates the static rules (length, letters+digits, symbols, username),
+// mirroring Java PasswordPolicyEnum.
+func (p PasswordPolicy) Check(username, raw string) error {
+	length := len([]rune(raw))
+	if length < p.MinLength {
+		return policyErrorf("密码最小长度为 %d 个字符", p.MinLength)
+	}
+	var hasLetter, hasDigit, hasSymbol bool
+	for _, ch := range raw {
+		switch {
+		case ch >= '0' && ch <= '9':
+			hasDigit = true
+		case (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z'):
+			hasLetter = true
+		case strings.ContainsRune(passwordSymbols, ch) || unicode.IsSpace(ch):
+			hasSymbol = true
+		}
+	}
+	if length > PasswordMaxLength || !hasLetter || !hasDigit {
+		return policyErrorf("密码长度为 %d-%d 个字符，支持大小写字母、数字、特殊字符，至少包含字母和数字", p.MinLength, PasswordMaxLength)
+	}
+	if p.RequireSymbols && !hasSymbol {
+		return policyErrorf("密码必须包含特殊字符")
+	}
+	if !p.AllowContainUsername && username != "" {
+		lower := strings.ToLower(raw)
+		name := strings.ToLower(username)
+		if strings.Contains(lower, name) || strings.Contains(lower, reverseString(name)) {
+			return policyErrorf("密码不允许包含正反序用户名")
+		}
+	}
+	return nil
+}
+
+// PasswordPolicyValidator loads the password policy from sys_option and checks
+// new passwords against it, including reuse of recent passwords.
+type PasswordPolicyValidator struct {
+	options  optiondomain.Repository
+	history  userdomain.PasswordHistoryRepository
+	verifier PasswordVerifier
+}
+
+// NewPasswordPolicyValidator creates a PasswordPolicyValidator.
+func NewPasswordPolicyValidator(
+	options optiondomain.Repository,
+	history userdomain.PasswordHistoryRepository,
+	verifier PasswordVerifier,
+) *PasswordPolicyValidator {
+	return &PasswordPolicyValidator{
+		options:  options,
+		history:  history,
+		verifier: verifier,
+	}
+}
+
+// Policy reads the current policy; defaults match the sys_option seeds.
+func (v *PasswordPolicyValidator) Policy(ctx context.Context) (PasswordPolicy, error) {
+	values, err := v.options.GetValues(ctx,
+		OptionPasswordMinLength,
+		OptionPasswordRequireSymbols,
+		OptionPasswordAllowContainUsername,
+		OptionPasswordRepetitionTimes,
+	)
+	if err != nil {
+		return PasswordPolicy{}, err
+	}
+	p := PasswordPolicy{
+		MinLength:            values.Int(OptionPasswordMinLength, 8),
+		RequireSymbols:       values.Bool(OptionPasswordRequireSymbols, false),
+		AllowContainUsername: values.Bool(OptionPasswordAllowContainUsername, true),
+		RepetitionTimes:      values.Int(OptionPasswordRepetitionTimes, 3),
+	}
+	if p.MinLength < 1 {
+		p.MinLength = 1
+	}
+	if p.MinLength > PasswordMaxLength {
+		p.MinLength = PasswordMaxLength
+	}
+	return p, nil
+}
+
+// Validate checks raw against the policy for the given user. For an existing
+// user (userID > 0) the current password hash and the recent history are
+// checked for reuse. Violations are returned as *PasswordPolicyError.
+func (v *PasswordPolicyValidator) Validate(ctx context.Context, userID int64, username, currentEncoded, raw string) error {
+	p, err := v.Policy(ctx)
+	if err != nil {
+		return err
+	}
+	if err := p.Check(username, raw); err != nil {
+		return err
+	}
+	if userID <= 0 || p.RepetitionTimes <= 0 {
+		return nil
+	}
+
+	// 当前密码计为最近一次，其余从历史表中取。
+	limit := p.RepetitionTimes
+	candidates := make([]string, 0, limit)
+	if currentEncoded != "" {
+		candidates = append(candidates, currentEncoded)
+		limit--
+	}
+	recent, err := v.history.ListRecent(ctx, userID, limit)
+	if err != nil {
+		return err
+	}
+	candidates = append(candidates, recent...)
+	for _, encoded := range candidates {
+		ok, err := v.verifier.Verify(raw, encoded)
+		if err != nil {
+			continue
+		}
+		if ok {
+			return policyErrorf("新密码不得与历史前 %d 次密码重复", p.RepetitionTimes)
+		}
+	}
+	return nil
+}
+
+func reverseString(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+// PasswordExpiry describes when a user's password expires (PASSWORD_EXPIRATION_DAYS).
+type PasswordExpiry struct {
+	// Expired is true once the password is older than the expiration period.
+	Expired bool
+	// ExpireTime is nil when passwords never expire.
+	ExpireTime *time.Time
+	// DaysLeft is the number of days until expiry (0 or negative once expired).
+	DaysLeft int
+	// Warning is true within PASSWORD_EXPIRATION_WARNING_DAYS before expiry.
+	Warning bool
+}
+
+// ComputePasswordExpiry derives the expiry state from pwd_reset_time.
+// expirationDays <= 0 means passwords never expire; a user without
+// pwd_reset_time is treated as never expiring as well.
+func ComputePasswordExpiry(pwdResetTime *time.Time, expirationDays, warningDays int, now time.Time) PasswordExpiry {
+	var e PasswordExpiry
+	if expirationDays <= 0 || pwdResetTime == nil {
+		return e
+	}
+	expireAt := pwdResetTime.AddDate(0, 0, expirationDays)
+	e.ExpireTime = &expireAt
+	e.Expired = !now.Before(expireAt)
+	left := expireAt.Sub(now)
+	e.DaysLeft = int((left + 24*time.Hour - 1) / (24 * time.Hour))
+	if e.Expired {
+		e.DaysLeft = 0
+	}
+	e.Warning = !e.Expired && warningDays > 0 && e.DaysLeft <= warningDays
+	return e
+}
+
+// Expiry reads the expiration options and computes the expiry state.
+func (v *PasswordPolicyValidator) Expiry(ctx context.Context, pwdResetTime *time.Time) (PasswordExpiry, error) {
+	values, err := v.options.GetValues(ctx, OptionPasswordExpirationDays, OptionPasswordExpirationWarningDays)
+	if err != nil {
+		return PasswordExpiry{}, err
+	}
+	return ComputePasswordExpiry(
+		pwdResetTime,
+		values.Int(OptionPasswordExpirationDays, 0),
+		values.Int(OptionPasswordExpirationWarningDays, 0),
+		time.Now(),
+	), nil
+}
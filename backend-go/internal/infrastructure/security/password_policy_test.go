@@ -0,0 +1,169 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	optiondomain "voc-go-backend/internal/domain/option"
+)
+
+type fakeOptions optiondomain.Values
+
+func (f fakeOptions) GetValues(_ context.Context, codes ...string) (optiondomain.Values, error) {
+	values := optiondomain.Values{}
+	for _, code := range codes {
+		if v, ok := f[code]; ok {
+			values[code] = v
+		}
+	}
+	return values, nil
+}
+
+type fakeHistory []string
+
+func (f fakeHistory) ListRecent(_ context.Context, _ int64, limit int) ([]string, error) {
+	if limit > len(f) {
+		limit = len(f)
+	}
+	return f[:limit], nil
+}
+
+// plainVerifier 以明文比较代替 BCrypt，便于构造历史密码。
+type plainVerifier struct{}
+
+func (plainVerifier) Verify(raw, encoded string) (bool, error) { return raw == encoded, nil }
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		username string
+		raw      string
+		wantErr  bool
+	}{
+		{"valid", PasswordPolicy{MinLength: 8, AllowContainUsername: true}, "admin", "abc12345", false},
+		{"too short", PasswordPolicy{MinLength: 8, AllowContainUsername: true}, "admin", "abc123", true},
+		{"too long", PasswordPolicy{MinLength: 8, AllowContainUsername: true}, "admin", "a1234567890123456789012345678901234", true},
+		{"digits only", PasswordPolicy{MinLength: 8, AllowContainUsername: true}, "admin", "12345678", true},
+		{"letters only", PasswordPolicy{MinLength: 8, AllowContainUsername: true}, "admin", "abcdefgh", true},
+		{"symbol required", PasswordPolicy{MinLength: 8, RequireSymbols: true, AllowContainUsername: true}, "admin", "abc12345", true},
+		{"symbol present", PasswordPolicy{MinLength: 8, RequireSymbols: true, AllowContainUsername: true}, "admin", "abc123@45", false},
+		{"contains username", PasswordPolicy{MinLength: 8}, "admin", "xAdmin123", true},
+		{"contains reversed username", PasswordPolicy{MinLength: 8}, "admin", "nimda1234", true},
+		{"username allowed", PasswordPolicy{MinLength: 8, AllowContainUsername: true}, "admin", "admin1234", false},
+		{"length counted in runes", PasswordPolicy{MinLength: 4, AllowContainUsername: true}, "admin", "密码a1", false},
+		{"rune length too short", PasswordPolicy{MinLength: 5, AllowContainUsername: true}, "admin", "密码a1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.username, tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			var policyErr *PasswordPolicyError
+			if err != nil && !errors.As(err, &policyErr) {
+				t.Fatalf("Check(%q) error type = %T, want *PasswordPolicyError", tt.raw, err)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyValidatorPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		options fakeOptions
+		want    PasswordPolicy
+	}{
+		{"defaults", fakeOptions{}, PasswordPolicy{MinLength: 8, AllowContainUsername: true, RepetitionTimes: 3}},
+		{"configured", fakeOptions{
+			OptionPasswordMinLength:            "10",
+			OptionPasswordRequireSymbols:       "1",
+			OptionPasswordAllowContainUsername: "0",
+			OptionPasswordRepetitionTimes:      "5",
+		}, PasswordPolicy{MinLength: 10, RequireSymbols: true, RepetitionTimes: 5}},
+		{"min length clamped low", fakeOptions{OptionPasswordMinLength: "0"}, PasswordPolicy{MinLength: 1, AllowContainUsername: true, RepetitionTimes: 3}},
+		{"min length clamped high", fakeOptions{OptionPasswordMinLength: "64"}, PasswordPolicy{MinLength: PasswordMaxLength, AllowContainUsername: true, RepetitionTimes: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewPasswordPolicyValidator(tt.options, fakeHistory{}, plainVerifier{})
+			got, err := v.Policy(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("Policy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyValidatorValidate(t *testing.T) {
+	history := fakeHistory{"hist1234", "hist5678", "hist9012"}
+	tests := []struct {
+		name        string
+		repetitions string
+		userID      int64
+		current     string
+		raw         string
+		wantErr     bool
+	}{
+		{"new user skips history", "3", 0, "", "hist1234", false},
+		{"reuse current", "3", 1, "curr1234", "curr1234", true},
+		{"reuse recent history", "3", 1, "curr1234", "hist5678", true},
+		{"history beyond limit", "3", 1, "curr1234", "hist9012", false},
+		{"history without current", "3", 1, "", "hist9012", true},
+		{"repetition disabled", "0", 1, "curr1234", "curr1234", false},
+		{"fresh password", "3", 1, "curr1234", "fresh123", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := fakeOptions{OptionPasswordRepetitionTimes: tt.repetitions}
+			v := NewPasswordPolicyValidator(options, history, plainVerifier{})
+			err := v.Validate(context.Background(), tt.userID, "admin", tt.current, tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestComputePasswordExpiry(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) *time.Time {
+		t := now.AddDate(0, 0, -d)
+		return &t
+	}
+	tests := []struct {
+		name           string
+		resetTime      *time.Time
+		expirationDays int
+		warningDays    int
+		wantExpired    bool
+		wantDaysLeft   int
+		wantWarning    bool
+		wantExpireTime bool
+	}{
+		{"never expires", daysAgo(100), 0, 7, false, 0, false, false},
+		{"no reset time", nil, 30, 7, false, 0, false, false},
+		{"fresh", daysAgo(1), 30, 7, false, 29, false, true},
+		{"within warning", daysAgo(25), 30, 7, false, 5, true, true},
+		{"expires exactly now", daysAgo(30), 30, 7, true, 0, false, true},
+		{"expired", daysAgo(45), 30, 7, true, 0, false, true},
+		{"warning disabled", daysAgo(29), 30, 0, false, 1, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputePasswordExpiry(tt.resetTime, tt.expirationDays, tt.warningDays, now)
+			if got.Expired != tt.wantExpired || got.DaysLeft != tt.wantDaysLeft || got.Warning != tt.wantWarning {
+				t.Fatalf("ComputePasswordExpiry() = %+v, want expired=%v daysLeft=%d warning=%v",
+					got, tt.wantExpired, tt.wantDaysLeft, tt.wantWarning)
+			}
+			if (got.ExpireTime != nil) != tt.wantExpireTime {
+				t.Fatalf("ExpireTime = %v, want set=%v", got.ExpireTime, tt.wantExpireTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+// Package storage 抽象文件存储后端（本地磁盘、S3 兼容对象存储等）。
+// 新增存储类型时实现 Driver 并通过 Register 注册对应的 sys_storage.type 即可，
+// 上层 handler 只依赖 Driver 接口。
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 存储类型，与 Java StorageTypeEnum 一致：1=本地存储，2=对象存储（S3 兼容，如 MinIO）。
+const (
+	TypeLocal int16 = 1
+	TypeS3    int16 = 2
+)
+
+// DefaultLocalBucket 本地存储未配置存储路径时使用的目录。
+const DefaultLocalBucket = "./data/file"
+
+var (
+	// ErrNotFound 对象不存在。
+	ErrNotFound = errors.New("storage: object not found")
+	// ErrNotSupported 当前驱动不支持该操作（如本地存储生成预签名 URL）。
+	ErrNotSupported = errors.New("storage: operation not supported")
+)
+
+// Config 表示 sys_storage 中的一条存储配置。
+type Config struct {
+	ID         int64
+	Name       string
+	Code       string
+	Type       int16
+	AccessKey  string
+	SecretKey  string
+	Endpoint   string
+	BucketName string
+	Domain     string
+	Region     string
+	IsDefault  bool
+	Status     int16
+	// PublicAccess 为 false 时文件不提供公开 URL，只能经鉴权的下载接口访问。
+	PublicAccess bool
+}
+
+// fingerprint 返回影响驱动连接的配置摘要，配置变更后缓存的驱动随之失效。
+func (c *Config) fingerprint() string {
+	return strings.Join([]string{
+		fmt.Sprint(c.Type), c.AccessKey, c.SecretKey, c.Endpoint, c.BucketName, c.Region,
+	}, "\x00")
+}
+
+// ObjectInfo 描述存储中的一个对象。
+type ObjectInfo struct {
+	Path         string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// Driver 为存储后端的统一操作接口，path 为 sys_file.path 形式的逻辑路径（如 /2025/1/1/a.jpg）。
+type Driver interface {
+	// Put 写入对象，size 未知时传 -1。
+	Put(ctx context.Context, path string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象内容，不存在时返回 ErrNotFound。
+	Get(ctx context.Context, path string) (io.ReadCloser, error)
+	// Delete 删除对象，对象不存在不视为错误。
+	Delete(ctx context.Context, path string) error
+	// Stat 查询对象信息，不存在时返回 ErrNotFound。
+	Stat(ctx context.Context, path string) (*ObjectInfo, error)
+	// Presign 生成带有效期的临时访问 URL，不支持时返回 ErrNotSupported。
+	Presign(ctx context.Context, path string, expiry time.Duration) (string, error)
+	// List 列出 prefix 目录下（含子目录）的全部对象。
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// Factory 根据存储配置创建驱动。
+type Factory func(cfg *Config) (Driver, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[int16]Factory)
+)
+
+// Register 注册存储类型对应的驱动工厂，重复注册时覆盖。
+func Register(storageType int16, f Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[storageType] = f
+}
+
+// Open 按存储类型创建新的驱动实例（不使用缓存）。
+func Open(cfg *Config) (Driver, error) {
+	if cfg == nil {
+		return nil, errors.New("storage: config is nil")
+	}
+	factoriesMu.RLock()
+	f, ok := factories[cfg.Type]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("storage: unsupported storage type %d", cfg.Type)
+	}
+	return f(cfg)
+}
+
+// Manager 按 Config.ID 缓存驱动实例，避免每次请求都新建客户端。
+// 配置内容变化（如修改密钥、Endpoint）时自动重建。
+type Manager struct {
+	mu      sync.Mutex
+	drivers map[int64]cachedDriver
+}
+
+type cachedDriver struct {
+	fingerprint string
+	driver      Driver
+}
+
+// NewManager 创建驱动缓存。
+func NewManager() *Manager {
+	return &Manager{drivers: make(map[int64]cachedDriver)}
+}
+
+// Driver 返回配置对应的驱动，命中缓存且配置未变化时复用。
+func (m *Manager) Driver(cfg *Config) (Driver, error) {
+	if cfg == nil {
+		return nil, errors.New("storage: config is nil")
+	}
+	fp := cfg.fingerprint()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cached, ok := m.drivers[cfg.ID]; ok && cached.fingerprint == fp {
+		return cached.driver, nil
+	}
+	d, err := Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	m.drivers[cfg.ID] = cachedDriver{fingerprint: fp, driver: d}
+	return d, nil
+}
+
+// Evict 移除指定存储的缓存驱动，存储配置修改或删除后调用。
+func (m *Manager) Evict(id int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.drivers, id)
+}
+
+// ObjectKey 将逻辑路径转换为不以 / 开头的规范化对象键，并去除 .. 等路径穿越片段。
+func ObjectKey(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+strings.TrimSpace(p)), "/")
+}
+
+// Part 描述分片上传中已上传的一个分片。
+type Part struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+	Size   int64  `json:"size"`
+}
+
+// MultipartUploader 为支持分片上传的驱动实现的可选接口。
+// 对象存储使用原生 Multipart Upload，本地存储将分片暂存在根目录的 .multipart 下，合并时再写入目标文件。
+type MultipartUploader interface {
+	// InitiateMultipart 开始分片上传，返回驱动侧的上传 ID。
+	InitiateMultipart(ctx context.Context, path, contentType string) (string, error)
+	// UploadPart 上传编号为 number（从 1 开始）的分片，重复上传同一编号时覆盖。
+	UploadPart(ctx context.Context, path, uploadID string, number int, r io.Reader, size int64) (Part, error)
+	// CompleteMultipart 按编号顺序合并分片为最终对象。
+	CompleteMultipart(ctx context.Context, path, uploadID string, parts []Part) error
+	// AbortMultipart 取消上传并清理已上传的分片。
+	AbortMultipart(ctx context.Context, path, uploadID string) error
+}
@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(TypeLocal, func(cfg *Config) (Driver, error) {
+		return NewLocalDriver(cfg.BucketName), nil
+	})
+}
+
+// LocalDriver 将对象保存在本地目录，sys_storage.bucket_name 为根目录。
+type LocalDriver struct {
+	root string
+}
+
+var _ MultipartUploader = (*LocalDriver)(nil)
+
+// NewLocalDriver 创建本地存储驱动，root 为空时使用 DefaultLocalBucket。
+func NewLocalDriver(root string) *LocalDriver {
+	if strings.TrimSpace(root) == "" {
+		root = DefaultLocalBucket
+	}
+	return &LocalDriver{root: root}
+}
+
+func (d *LocalDriver) abs(p string) string {
+	return filepath.Join(d.root, filepath.FromSlash(ObjectKey(p)))
+}
+
+// Put 写入临时文件后重命名，避免读到写了一半的文件。
+func (d *LocalDriver) Put(_ context.Context, p string, r io.Reader, _ int64, _ string) error {
+	dst := d.abs(p)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (d *LocalDriver) Get(_ context.Context, p string) (io.ReadCloser, error) {
+	f, err := os.Open(d.abs(p))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (d *LocalDriver) Delete(_ context.Context, p string) error {
+	err := os.Remove(d.abs(p))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (d *LocalDriver) Stat(_ context.Context, p string) (*ObjectInfo, error) {
+	fi, err := os.Stat(d.abs(p))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return nil, ErrNotFound
+	}
+	return localObjectInfo("/"+ObjectKey(p), fi), nil
+}
+
+// Presign 本地存储通过静态路径直接访问，不提供预签名 URL。
+func (d *LocalDriver) Presign(context.Context, string, time.Duration) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (d *LocalDriver) List(_ context.Context, prefix string) ([]ObjectInfo, error) {
+	base := d.abs(prefix)
+	var list []ObjectInfo
+	err := filepath.WalkDir(base, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() && entry.Name() == localMultipartDir {
+			return fs.SkipDir
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".upload-") {
+			return nil
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(d.root, p)
+		if err != nil {
+			return err
+		}
+		list = append(list, *localObjectInfo("/"+filepath.ToSlash(rel), fi))
+		return nil
+	})
+	return list, err
+}
+
+// localMultipartDir 本地分片上传的暂存目录，位于存储根目录下。
+const localMultipartDir = ".multipart"
+
+func (d *LocalDriver) partDir(uploadID string) (string, error) {
+	if _, err := hex.DecodeString(uploadID); err != nil || uploadID == "" {
+		return "", ErrNotFound
+	}
+	return filepath.Join(d.root, localMultipartDir, uploadID), nil
+}
+
+func (d *LocalDriver) InitiateMultipart(context.Context, string, string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	uploadID := hex.EncodeToString(buf)
+	dir, _ := d.partDir(uploadID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return uploadID, nil
+}
+
+// UploadPart 分片先写临时文件再重命名，重传的分片不会与旧内容混在一起。
+func (d *LocalDriver) UploadPart(_ context.Context, _ string, uploadID string, number int, r io.Reader, _ int64) (Part, error) {
+	dir, err := d.partDir(uploadID)
+	if err != nil {
+		return Part{}, err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Part{}, ErrNotFound
+		}
+		return Part{}, err
+	}
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return Part{}, err
+	}
+	hash := md5.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(dir, strconv.Itoa(number)))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return Part{}, err
+	}
+	return Part{Number: number, ETag: hex.EncodeToString(hash.Sum(nil)), Size: size}, nil
+}
+
+func (d *LocalDriver) CompleteMultipart(ctx context.Context, p, uploadID string, parts []Part) error {
+	dir, err := d.partDir(uploadID)
+	if err != nil {
+		return err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		for _, part := range parts {
+			f, err := os.Open(filepath.Join(dir, strconv.Itoa(part.Number)))
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					err = ErrNotFound
+				}
+				pw.CloseWithError(err)
+				return
+			}
+			_, err = io.Copy(pw, f)
+			f.Close()
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.Close()
+	}()
+	if err := d.Put(ctx, p, pr, -1, ""); err != nil {
+		pr.CloseWithError(err)
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+func (d *LocalDriver) AbortMultipart(_ context.Context, _ string, uploadID string) error {
+	dir, err := d.partDir(uploadID)
+	if err != nil {
+		return nil
+	}
+	return os.RemoveAll(dir)
+}
+
+func localObjectInfo(p string, fi fs.FileInfo) *ObjectInfo {
+	return &ObjectInfo{
+		Path:         p,
+		Size:         fi.Size(),
+		ContentType:  mime.TypeByExtension(path.Ext(p)),
+		LastModified: fi.ModTime(),
+	}
+}
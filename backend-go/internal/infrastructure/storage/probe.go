@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// probeContent 为连通性测试写入的对象内容。
+var probeContent = []byte("voc storage connectivity probe")
+
+// ProbeError 描述连通性测试失败的步骤。
+type ProbeError struct {
+	// Step 为失败的操作：put、stat 或 delete
+	Step string
+	Err  error
+}
+
+func (e *ProbeError) Error() string { return fmt.Sprintf("storage probe %s: %v", e.Step, e.Err) }
+func (e *ProbeError) Unwrap() error { return e.Err }
+
+// Probe 依次写入、查询、删除一个临时对象，验证存储配置（地址、密钥、桶及权限）可用。
+func Probe(ctx context.Context, d Driver) error {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	p := "/.storage-probe-" + hex.EncodeToString(b)
+
+	if err := d.Put(ctx, p, bytes.NewReader(probeContent), int64(len(probeContent)), "text/plain"); err != nil {
+		return &ProbeError{Step: "put", Err: err}
+	}
+	info, err := d.Stat(ctx, p)
+	if err == nil && info.Size != int64(len(probeContent)) {
+		err = fmt.Errorf("size mismatch: got %d, want %d", info.Size, len(probeContent))
+	}
+	if err != nil {
+		_ = d.Delete(ctx, p)
+		return &ProbeError{Step: "stat", Err: err}
+	}
+	if err := d.Delete(ctx, p); err != nil {
+		return &ProbeError{Step: "delete", Err: err}
+	}
+	return nil
+}
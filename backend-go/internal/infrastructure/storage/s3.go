@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+func init() {
+	Register(TypeS3, func(cfg *Config) (Driver, error) {
+		return NewS3Driver(cfg)
+	})
+}
+
+// S3Driver 基于 minio-go 的 S3 兼容对象存储驱动（MinIO、七牛、OSS 等）。
+type S3Driver struct {
+	client *minio.Client
+	bucket string
+	region string
+
+	bucketMu sync.Mutex
+	bucketOK bool
+}
+
+var _ MultipartUploader = (*S3Driver)(nil)
+
+// NewS3Driver 根据存储配置创建对象存储驱动。
+// Endpoint 可带 http(s):// 前缀，https 时启用 TLS。
+func NewS3Driver(cfg *Config) (*S3Driver, error) {
+	endpoint := strings.TrimSpace(cfg.Endpoint)
+	if endpoint == "" || strings.TrimSpace(cfg.AccessKey) == "" ||
+		strings.TrimSpace(cfg.SecretKey) == "" || strings.TrimSpace(cfg.BucketName) == "" {
+		return nil, errors.New("对象存储配置不完整")
+	}
+	secure := false
+	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		secure = u.Scheme == "https"
+		endpoint = u.Host
+	}
+	region := strings.TrimSpace(cfg.Region)
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: secure,
+		// Region 用于兼容 S3 协议的对象存储（如七牛等），默认留空即可。
+		Region: region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &S3Driver{client: client, bucket: cfg.BucketName, region: region}, nil
+}
+
+// ensureBucket 首次写入前确认 Bucket 存在，不存在时创建。
+func (d *S3Driver) ensureBucket(ctx context.Context) error {
+	d.bucketMu.Lock()
+	defer d.bucketMu.Unlock()
+	if d.bucketOK {
+		return nil
+	}
+	exists, err := d.client.BucketExists(ctx, d.bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		// 七牛等服务端创建 Bucket 时需要带上 Region，这里复用存储配置中的 Region。
+		if err := d.client.MakeBucket(ctx, d.bucket, minio.MakeBucketOptions{Region: d.region}); err != nil {
+			return err
+		}
+	}
+	d.bucketOK = true
+	return nil
+}
+
+func (d *S3Driver) Put(ctx context.Context, p string, r io.Reader, size int64, contentType string) error {
+	if err := d.ensureBucket(ctx); err != nil {
+		return err
+	}
+	_, err := d.client.PutObject(ctx, d.bucket, ObjectKey(p), r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+func (d *S3Driver) Get(ctx context.Context, p string) (io.ReadCloser, error) {
+	obj, err := d.client.GetObject(ctx, d.bucket, ObjectKey(p), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, translateS3Error(err)
+	}
+	// GetObject 延迟发起请求，先 Stat 以便及时返回不存在错误。
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, translateS3Error(err)
+	}
+	return obj, nil
+}
+
+func (d *S3Driver) Delete(ctx context.Context, p string) error {
+	err := d.client.RemoveObject(ctx, d.bucket, ObjectKey(p), minio.RemoveObjectOptions{})
+	if errors.Is(translateS3Error(err), ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (d *S3Driver) Stat(ctx context.Context, p string) (*ObjectInfo, error) {
+	info, err := d.client.StatObject(ctx, d.bucket, ObjectKey(p), minio.StatObjectOptions{})
+	if err != nil {
+		return nil, translateS3Error(err)
+	}
+	return &ObjectInfo{
+		Path:         "/" + info.Key,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		LastModified: info.LastModified,
+	}, nil
+}
+
+func (d *S3Driver) Presign(ctx context.Context, p string, expiry time.Duration) (string, error) {
+	u, err := d.client.PresignedGetObject(ctx, d.bucket, ObjectKey(p), expiry, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (d *S3Driver) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	key := ObjectKey(prefix)
+	if key != "" {
+		key += "/"
+	}
+	var list []ObjectInfo
+	for obj := range d.client.ListObjects(ctx, d.bucket, minio.ListObjectsOptions{Prefix: key, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		list = append(list, ObjectInfo{
+			Path:         "/" + obj.Key,
+			Size:         obj.Size,
+			ContentType:  obj.ContentType,
+			LastModified: obj.LastModified,
+		})
+	}
+	return list, nil
+}
+
+func (d *S3Driver) core() minio.Core {
+	return minio.Core{Client: d.client}
+}
+
+func (d *S3Driver) InitiateMultipart(ctx context.Context, p, contentType string) (string, error) {
+	if err := d.ensureBucket(ctx); err != nil {
+		return "", err
+	}
+	return d.core().NewMultipartUpload(ctx, d.bucket, ObjectKey(p), minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+}
+
+func (d *S3Driver) UploadPart(ctx context.Context, p, uploadID string, number int, r io.Reader, size int64) (Part, error) {
+	part, err := d.core().PutObjectPart(ctx, d.bucket, ObjectKey(p), uploadID, number, r, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return Part{}, translateS3Error(err)
+	}
+	return Part{Number: number, ETag: part.ETag, Size: part.Size}, nil
+}
+
+func (d *S3Driver) CompleteMultipart(ctx context.Context, p, uploadID string, parts []Part) error {
+	complete := make([]minio.CompletePart, len(parts))
+	for i, part := range parts {
+		complete[i] = minio.CompletePart{PartNumber: part.Number, ETag: part.ETag}
+	}
+	_, err := d.core().CompleteMultipartUpload(ctx, d.bucket, ObjectKey(p), uploadID, complete, minio.PutObjectOptions{})
+	return translateS3Error(err)
+}
+
+func (d *S3Driver) AbortMultipart(ctx context.Context, p, uploadID string) error {
+	err := d.core().AbortMultipartUpload(ctx, d.bucket, ObjectKey(p), uploadID)
+	if errors.Is(translateS3Error(err), ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func translateS3Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound", "NoSuchUpload":
+		return ErrNotFound
+	}
+	return err
+}
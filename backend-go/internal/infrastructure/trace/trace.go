@@ -0,0 +1,63 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+// maxIDLength 为外部传入链路 ID 的最大长度，超出时重新生成。
+const maxIDLength = 64
+
+type contextKey struct{}
+
+// NewID 生成链路 ID（32 位十六进制字符串）。
+func NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// ValidID 判断外部传入的链路 ID 是否可用：非空、不超过 64 个字符，且仅包含字母、数字、- _ .。
+func ValidID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// WithID 返回携带链路 ID 的 context。
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext 返回 context 中的链路 ID，不存在时返回空字符串。
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Printf 同 log.Printf，context 中存在链路 ID 时在日志前加上 [trace=xxx] 前缀。
+func Printf(ctx context.Context, format string, v ...any) {
+	if id := FromContext(ctx); id != "" {
+		log.Print("[trace=" + id + "] " + fmt.Sprintf(format, v...))
+		return
+	}
+	log.Printf(format, v...)
+}
@@ -0,0 +1,92 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestValidID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{"empty", "", false},
+		{"hex", "0123456789abcdef0123456789abcdef", true},
+		{"allowed symbols", "req-1_A.b", true},
+		{"max length", strings.Repeat("a", 64), true},
+		{"too long", strings.Repeat("a", 65), false},
+		{"space", "abc def", false},
+		{"newline", "abc\ndef", false},
+		{"slash", "a/b", false},
+		{"non ascii", "链路", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidID(tt.id); got != tt.want {
+				t.Fatalf("ValidID(%q) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewID(t *testing.T) {
+	a, b := NewID(), NewID()
+	if len(a) != 32 || !ValidID(a) {
+		t.Fatalf("NewID() = %q, want 32 hex characters", a)
+	}
+	if a == b {
+		t.Fatalf("NewID() returned duplicate %q", a)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"nil", nil, ""},
+		{"missing", context.Background(), ""},
+		{"set", WithID(context.Background(), "abc"), "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromContext(tt.ctx); got != tt.want {
+				t.Fatalf("FromContext() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintf(t *testing.T) {
+	var buf bytes.Buffer
+	flags, out := log.Flags(), log.Writer()
+	log.SetFlags(0)
+	log.SetOutput(&buf)
+	defer func() {
+		log.SetFlags(flags)
+		log.SetOutput(out)
+	}()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"without id", context.Background(), "hello 1\n"},
+		{"with id", WithID(context.Background(), "abc"), "[trace=abc] hello 1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			Printf(tt.ctx, "hello %d", 1)
+			if got := buf.String(); got != tt.want {
+				t.Fatalf("Printf() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
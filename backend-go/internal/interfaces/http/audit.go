@@ -0,0 +1,58 @@
+package http
+
+import "github.com/gin-gonic/gin"
+
+// auditContextKey 为路由审计元数据在 gin.Context 中的键。
+const auditContextKey = "sysLogAudit"
+
+// AuditMeta 为路由声明的系统日志元数据，由 sysLogMiddleware 读取。
+type AuditMeta struct {
+	// Module 为所属模块，如 用户管理
+	Module string
+	// Description 为操作描述，如 删除用户
+	Description string
+	// Skip 为 true 时不记录日志，用于健康检查、文件下载等
+	Skip bool
+	// NoRequestBody 为 true 时不记录请求体，用于文件上传等
+	NoRequestBody bool
+	// NoResponseBody 为 true 时不记录响应体，用于导出、日志详情等
+	NoResponseBody bool
+}
+
+// AuditOption 调整路由的日志记录方式。
+type AuditOption func(*AuditMeta)
+
+// AuditNoRequestBody 不记录请求体。
+func AuditNoRequestBody(m *AuditMeta) { m.NoRequestBody = true }
+
+// AuditNoResponseBody 不记录响应体。
+func AuditNoResponseBody(m *AuditMeta) { m.NoResponseBody = true }
+
+// Audit 声明路由的所属模块与操作描述。
+// 应放在路由处理链首位（鉴权中间件之前），鉴权失败的请求同样按该元数据记录，例如：
+//
+//	r.DELETE("/system/user", Audit("用户管理", "删除用户"), am.RequirePermission("system:user:delete"), h.DeleteUser)
+func Audit(module, description string, opts ...AuditOption) gin.HandlerFunc {
+	meta := &AuditMeta{Module: module, Description: description}
+	for _, opt := range opts {
+		opt(meta)
+	}
+	return func(c *gin.Context) {
+		c.Set(auditContextKey, meta)
+	}
+}
+
+// NoAudit 声明路由不记录系统日志。
+func NoAudit() gin.HandlerFunc {
+	return Audit("", "", func(m *AuditMeta) { m.Skip = true })
+}
+
+// auditMetaFrom 返回路由声明的审计元数据，未声明时返回 nil。
+func auditMetaFrom(c *gin.Context) *AuditMeta {
+	if v, ok := c.Get(auditContextKey); ok {
+		if meta, ok := v.(*AuditMeta); ok {
+			return meta
+		}
+	}
+	return nil
+}
@@ -8,31 +8,34 @@ import (
 	"github.com/redis/go-redis/v9"
 
 	"voc-go-backend/internal/application/auth"
+	"voc-go-backend/internal/infrastructure/clientinfo"
 )
 
 // AuthHandler 暴露认证相关 HTTP 接口。
 type AuthHandler struct {
 	svc    *auth.Service
-	online *OnlineStore
+	online OnlineStore
 	db     *sql.DB
 	redis  *redis.Client
+	client *clientinfo.Resolver
 }
 
 // NewAuthHandler 创建认证接口处理器。
-// 其中 db 用于读取登录相关配置（如是否启用验证码）。
-func NewAuthHandler(svc *auth.Service, online *OnlineStore, db *sql.DB, redisClient *redis.Client) *AuthHandler {
+// 其中 db 用于读取登录相关配置（如是否启用验证码），client 用于解析在线用户的登录地点、浏览器与操作系统。
+func NewAuthHandler(svc *auth.Service, online OnlineStore, db *sql.DB, redisClient *redis.Client, client *clientinfo.Resolver) *AuthHandler {
 	return &AuthHandler{
 		svc:    svc,
 		online: online,
 		db:     db,
 		redis:  redisClient,
+		client: client,
 	}
 }
 
 // RegisterAuthRoutes 注册 /auth 相关路由。
 func (h *AuthHandler) RegisterAuthRoutes(r *gin.Engine) {
-	r.POST("/auth/login", h.Login)
-	r.POST("/auth/logout", h.Logout)
+	r.POST("/auth/login", Audit("登录", "用户登录"), h.Login)
+	r.POST("/auth/logout", Audit("登录", "用户退出登录"), h.Logout)
 }
 
 // Login 处理 POST /auth/login。
@@ -98,6 +101,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		}
 	}
 
+	req.ClientIP = c.ClientIP()
 	resp, err := h.svc.Login(c.Request.Context(), req)
 	if err != nil {
 		// Treat any error returned by the service as a 400-style business error,
@@ -106,9 +110,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// 登录成功后记录在线用户信息（仅在当前 Go 进程内维护内存状态）。
+	// 登录成功后记录在线会话，超时时间取自 sys_client 的 timeout / active_timeout。
 	if h.online != nil && resp != nil {
-		h.online.RecordLogin(c, resp.UserID, resp.Username, resp.Nickname, req.ClientID, resp.Token)
+		if err := h.online.Save(c.Request.Context(), newOnlineSession(c, resp, h.client)); err != nil {
+			Fail(c, "500", "记录在线用户失败")
+			return
+		}
 	}
 
 	// Successful login, return LoginResp as data.
@@ -117,9 +124,9 @@ func (h *AuthHandler) Login(c *gin.Context) {
 }
 
 // Logout 处理 POST /auth/logout。
-// 前端仅依赖服务端返回成功，本实现主要用于清理 Go 进程内的在线用户列表。
+// 前端仅依赖服务端返回成功；服务端吊销当前 Token 并清理在线用户列表。
 // @Summary 用户登出
-// @Description 基于 Authorization Bearer Token 进行登出，仅清理服务端在线用户。
+// @Description 基于 Authorization Bearer Token 进行登出，吊销 Token 并清理服务端在线用户。
 // @Tags 认证
 // @Produce json
 // @Success 200 {object} map[string]interface{} "统一响应包装，data 为 true/false"
@@ -130,8 +137,17 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	if strings.HasPrefix(strings.ToLower(token), "bearer ") {
 		token = strings.TrimSpace(token[7:])
 	}
+	if token != "" {
+		if err := h.svc.Logout(c.Request.Context(), token); err != nil {
+			Fail(c, "500", "退出登录失败")
+			return
+		}
+	}
 	if token != "" && h.online != nil {
-		h.online.RemoveByToken(token)
+		if err := h.online.RemoveByToken(c.Request.Context(), token); err != nil {
+			Fail(c, "500", "退出登录失败")
+			return
+		}
 	}
 	OK(c, true)
 }
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	optiondomain "voc-go-backend/internal/domain/option"
+	"voc-go-backend/internal/domain/user"
+	"voc-go-backend/internal/infrastructure/security"
+)
+
+// pwdResetUsers 返回指定 pwd_reset_time 的用户，并记录查询次数。
+type pwdResetUsers struct {
+	user.Repository
+	resetTime time.Time
+	calls     int
+}
+
+func (r *pwdResetUsers) GetByID(_ context.Context, id int64) (*user.User, error) {
+	r.calls++
+	t := r.resetTime
+	return &user.User{ID: id, PwdResetTime: &t}, nil
+}
+
+type expirationOptions struct {
+	days string
+}
+
+func (o *expirationOptions) GetValues(_ context.Context, _ ...string) (optiondomain.Values, error) {
+	return optiondomain.Values{security.OptionPasswordExpirationDays: o.days}, nil
+}
+
+func TestAuthMiddlewarePasswordExpireAt(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name        string
+		days        string
+		resetAgo    time.Duration
+		wantExpired bool
+		wantNever   bool
+	}{
+		{"disabled", "0", 400 * 24 * time.Hour, false, true},
+		{"not expired", "30", 24 * time.Hour, false, false},
+		{"expired", "30", 31 * 24 * time.Hour, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &pwdResetUsers{resetTime: now.Add(-tt.resetAgo)}
+			m := &AuthMiddleware{}
+			m.EnforcePasswordExpiry(users, security.NewPasswordPolicyValidator(&expirationOptions{days: tt.days}, nil, nil))
+
+			expireAt, found, err := m.passwordExpireAt(context.Background(), 1, now.Add(-time.Hour))
+			if err != nil || !found {
+				t.Fatalf("passwordExpireAt() found=%v err=%v", found, err)
+			}
+			if (expireAt == nil) != tt.wantNever {
+				t.Fatalf("expireAt = %v, want never=%v", expireAt, tt.wantNever)
+			}
+			if expired := expireAt != nil && !now.Before(*expireAt); expired != tt.wantExpired {
+				t.Fatalf("expired = %v, want %v", expired, tt.wantExpired)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewarePasswordExpiryCache(t *testing.T) {
+	users := &pwdResetUsers{resetTime: time.Now().Add(-40 * 24 * time.Hour)}
+	options := &expirationOptions{days: "0"}
+	m := &AuthMiddleware{}
+	m.EnforcePasswordExpiry(users, security.NewPasswordPolicyValidator(options, nil, nil))
+	ctx := context.Background()
+	issued := time.Now().Add(-time.Hour)
+
+	if expireAt, _, _ := m.passwordExpireAt(ctx, 1, issued); expireAt != nil {
+		t.Fatalf("expireAt = %v, want nil before the option is enabled", expireAt)
+	}
+	// 配置开启后在缓存有效期内沿用缓存
+	options.days = "30"
+	if expireAt, _, _ := m.passwordExpireAt(ctx, 1, issued); expireAt != nil || users.calls != 1 {
+		t.Fatalf("expireAt = %v, calls = %d, want cached nil", expireAt, users.calls)
+	}
+	// 缓存过期后按新配置计算
+	m.pwdExpiry[1] = pwdExpiryEntry{loadedAt: time.Now().Add(-pwdExpiryCacheTTL)}
+	if expireAt, _, _ := m.passwordExpireAt(ctx, 1, issued); expireAt == nil || users.calls != 2 {
+		t.Fatalf("expireAt = %v, calls = %d, want reloaded expiry", expireAt, users.calls)
+	}
+	// 缓存之后签发的令牌（如修改密码后重新登录）重新计算
+	users.resetTime = time.Now()
+	if expireAt, _, _ := m.passwordExpireAt(ctx, 1, time.Now().Add(time.Second)); expireAt == nil || !time.Now().Before(*expireAt) || users.calls != 3 {
+		t.Fatalf("expireAt = %v, calls = %d, want fresh expiry for a newer token", expireAt, users.calls)
+	}
+}
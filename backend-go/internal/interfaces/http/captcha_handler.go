@@ -36,7 +36,7 @@ func NewCaptchaHandler(db *sql.DB, redisClient *redis.Client) *CaptchaHandler {
 
 // RegisterCaptchaRoutes registers /captcha endpoints.
 func (h *CaptchaHandler) RegisterCaptchaRoutes(r *gin.Engine) {
-	r.GET("/captcha/image", h.GetImageCaptcha)
+	r.GET("/captcha/image", Audit("验证码", "获取图片验证码", AuditNoResponseBody), h.GetImageCaptcha)
 }
 
 // GetImageCaptcha 获取登录图片验证码。
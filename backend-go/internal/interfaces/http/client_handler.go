@@ -57,12 +57,12 @@ func NewClientHandler(db *sql.DB, tokenSvc *security.TokenService) *ClientHandle
 }
 
 // RegisterClientRoutes 注册客户端配置路由。
-func (h *ClientHandler) RegisterClientRoutes(r *gin.Engine) {
-	r.GET("/system/client", h.ListClientPage)
-	r.GET("/system/client/:id", h.GetClient)
-	r.POST("/system/client", h.CreateClient)
-	r.PUT("/system/client/:id", h.UpdateClient)
-	r.DELETE("/system/client", h.DeleteClient)
+func (h *ClientHandler) RegisterClientRoutes(r *gin.Engine, am *AuthMiddleware) {
+	r.GET("/system/client", Audit("客户端配置", "分页查询客户端"), am.RequirePermission("system:client:list"), h.ListClientPage)
+	r.GET("/system/client/:id", Audit("客户端配置", "查询客户端详情"), am.RequirePermission("system:client:get"), h.GetClient)
+	r.POST("/system/client", Audit("客户端配置", "新增客户端"), am.RequirePermission("system:client:create"), h.CreateClient)
+	r.PUT("/system/client/:id", Audit("客户端配置", "修改客户端"), am.RequirePermission("system:client:update"), h.UpdateClient)
+	r.DELETE("/system/client", Audit("客户端配置", "删除客户端"), am.RequirePermission("system:client:delete"), h.DeleteClient)
 }
 
 func (h *ClientHandler) currentUserID(c *gin.Context) int64 {
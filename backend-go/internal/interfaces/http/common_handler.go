@@ -41,13 +41,14 @@ func NewCommonHandler(db *sql.DB) *CommonHandler {
 }
 
 // RegisterCommonRoutes registers /common endpoints.
-func (h *CommonHandler) RegisterCommonRoutes(r *gin.Engine) {
-	r.GET("/common/dict/option/site", h.ListSiteOptions)
-	r.GET("/common/tree/menu", h.ListMenuTree)
-	r.GET("/common/tree/dept", h.ListDeptTree)
-	r.GET("/common/dict/user", h.ListUserDict)
-	r.GET("/common/dict/role", h.ListRoleDict)
-	r.GET("/common/dict/:code", h.ListDictByCode)
+func (h *CommonHandler) RegisterCommonRoutes(r *gin.Engine, am *AuthMiddleware) {
+	// 站点配置在登录页即需加载，保持匿名可访问。
+	r.GET("/common/dict/option/site", Audit("通用接口", "查询网站配置"), h.ListSiteOptions)
+	r.GET("/common/tree/menu", Audit("通用接口", "查询菜单树"), am.RequireLogin(), h.ListMenuTree)
+	r.GET("/common/tree/dept", Audit("通用接口", "查询部门树"), am.RequireLogin(), h.ListDeptTree)
+	r.GET("/common/dict/user", Audit("通用接口", "查询用户字典"), am.RequireLogin(), h.ListUserDict)
+	r.GET("/common/dict/role", Audit("通用接口", "查询角色字典"), am.RequireLogin(), h.ListRoleDict)
+	r.GET("/common/dict/:code", Audit("通用接口", "查询字典数据"), am.RequireLogin(), h.ListDictByCode)
 }
 
 // ListSiteOptions 返回基础网站配置字典数据（用于前端初始化站点标题、图标等）。
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	rbac "voc-go-backend/internal/domain/rbac"
+)
+
+func TestDataScopeAppendWhere(t *testing.T) {
+	const base = "WHERE u.status = $1"
+	baseArgs := []any{int16(1)}
+	tests := []struct {
+		name      string
+		filter    *dataScopeFilter
+		wantConds []string
+		wantArgs  []any
+	}{
+		{
+			name:   "nil filter",
+			filter: nil,
+		},
+		{
+			name:   "all data",
+			filter: &dataScopeFilter{userID: 7, deptID: 3, all: true, roles: []rbac.Role{{ID: 1, DataScope: rbac.DataScopeAll}}},
+		},
+		{
+			name:      "no roles falls back to self",
+			filter:    &dataScopeFilter{userID: 7, deptID: 3},
+			wantConds: []string{"u.create_user = $2"},
+			wantArgs:  []any{int64(7)},
+		},
+		{
+			name:      "self",
+			filter:    &dataScopeFilter{userID: 7, deptID: 3, roles: []rbac.Role{{ID: 1, DataScope: rbac.DataScopeSelf}}},
+			wantConds: []string{"u.create_user = $2"},
+			wantArgs:  []any{int64(7)},
+		},
+		{
+			name:      "dept",
+			filter:    &dataScopeFilter{userID: 7, deptID: 3, roles: []rbac.Role{{ID: 1, DataScope: rbac.DataScopeDept}}},
+			wantConds: []string{"u.dept_id = $2"},
+			wantArgs:  []any{int64(3)},
+		},
+		{
+			name:      "dept and child",
+			filter:    &dataScopeFilter{userID: 7, deptID: 3, roles: []rbac.Role{{ID: 1, DataScope: rbac.DataScopeDeptAndChild}}},
+			wantConds: []string{"u.dept_id IN (\n    WITH RECURSIVE dept_tree AS (\n        SELECT id FROM sys_dept WHERE id = $2"},
+			wantArgs:  []any{int64(3)},
+		},
+		{
+			name:      "custom roles",
+			filter:    &dataScopeFilter{userID: 7, deptID: 3, roles: []rbac.Role{{ID: 11, DataScope: rbac.DataScopeCustom}, {ID: 12, DataScope: rbac.DataScopeCustom}}},
+			wantConds: []string{"role_id = $2", "role_id = $3"},
+			wantArgs:  []any{int64(11), int64(12)},
+		},
+		{
+			name: "union of scopes without duplicates",
+			filter: &dataScopeFilter{userID: 7, deptID: 3, roles: []rbac.Role{
+				{ID: 1, DataScope: rbac.DataScopeDept},
+				{ID: 2, DataScope: rbac.DataScopeSelf},
+				{ID: 3, DataScope: rbac.DataScopeDept},
+				{ID: 4, DataScope: rbac.DataScopeSelf},
+			}},
+			wantConds: []string{"u.dept_id = $2 OR u.create_user = $3"},
+			wantArgs:  []any{int64(3), int64(7)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]any(nil), baseArgs...)
+			where, gotArgs, argPos := tt.filter.appendWhere(base, args, 2, userDataScopeColumns)
+
+			if len(tt.wantConds) == 0 {
+				if where != base || argPos != 2 || len(gotArgs) != len(baseArgs) {
+					t.Fatalf("appendWhere() = %q, %v, %d; want unchanged", where, gotArgs, argPos)
+				}
+				return
+			}
+			if !strings.HasPrefix(where, base+" AND (") || !strings.HasSuffix(where, ")") {
+				t.Fatalf("appendWhere() = %q, want base AND (...)", where)
+			}
+			for _, cond := range tt.wantConds {
+				if !strings.Contains(where, cond) {
+					t.Errorf("appendWhere() = %q, want it to contain %q", where, cond)
+				}
+			}
+			wantArgs := append(append([]any(nil), baseArgs...), tt.wantArgs...)
+			if !reflect.DeepEqual(gotArgs, wantArgs) {
+				t.Errorf("args = %v, want %v", gotArgs, wantArgs)
+			}
+			if want := 2 + len(tt.wantArgs); argPos != want {
+				t.Errorf("argPos = %d, want %d", argPos, want)
+			}
+		})
+	}
+}
+
+func TestDataScopeAppendWhereColumns(t *testing.T) {
+	f := &dataScopeFilter{userID: 7, deptID: 3, roles: []rbac.Role{
+		{ID: 1, DataScope: rbac.DataScopeDept},
+		{ID: 2, DataScope: rbac.DataScopeSelf},
+	}}
+	tests := []struct {
+		name string
+		cols dataScopeColumns
+		want string
+	}{
+		{"user", userDataScopeColumns, "WHERE 1=1 AND (u.dept_id = $1 OR u.create_user = $2)"},
+		{"dept", deptDataScopeColumns, "WHERE 1=1 AND (d.id = $1 OR d.create_user = $2)"},
+		{"log", logDataScopeColumns, "WHERE 1=1 AND (t2.dept_id = $1 OR t1.create_user = $2)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, _, _ := f.appendWhere("WHERE 1=1", nil, 1, tt.cols)
+			if where != tt.want {
+				t.Fatalf("appendWhere() = %q, want %q", where, tt.want)
+			}
+		})
+	}
+}
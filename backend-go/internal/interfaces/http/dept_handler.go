@@ -2,7 +2,6 @@ package http
 
 import (
 	"database/sql"
-	"encoding/csv"
 	"strconv"
 	"strings"
 	"time"
@@ -59,13 +58,13 @@ func NewDeptHandler(db *sql.DB, tokenSvc *security.TokenService) *DeptHandler {
 }
 
 // RegisterDeptRoutes registers /system/dept related routes.
-func (h *DeptHandler) RegisterDeptRoutes(r *gin.Engine) {
-	r.GET("/system/dept/tree", h.ListDeptTree)
-	r.GET("/system/dept/:id", h.GetDept)
-	r.POST("/system/dept", h.CreateDept)
-	r.PUT("/system/dept/:id", h.UpdateDept)
-	r.DELETE("/system/dept", h.DeleteDept)
-	r.GET("/system/dept/export", h.ExportDept)
+func (h *DeptHandler) RegisterDeptRoutes(r *gin.Engine, am *AuthMiddleware) {
+	r.GET("/system/dept/tree", Audit("部门管理", "查询部门树"), am.RequirePermission("system:dept:list"), h.ListDeptTree)
+	r.GET("/system/dept/:id", Audit("部门管理", "查询部门详情"), am.RequirePermission("system:dept:get"), h.GetDept)
+	r.POST("/system/dept", Audit("部门管理", "新增部门"), am.RequirePermission("system:dept:create"), h.CreateDept)
+	r.PUT("/system/dept/:id", Audit("部门管理", "修改部门"), am.RequirePermission("system:dept:update"), h.UpdateDept)
+	r.DELETE("/system/dept", Audit("部门管理", "删除部门"), am.RequirePermission("system:dept:delete"), h.DeleteDept)
+	r.GET("/system/dept/export", Audit("部门管理", "导出部门", AuditNoResponseBody), am.RequirePermission("system:dept:export"), h.ExportDept)
 }
 
 // currentUserID extracts user id from JWT, similar to SystemUserHandler.currentUserID.
@@ -108,6 +107,13 @@ func (h *DeptHandler) ListDeptTree(c *gin.Context) {
 		argPos++
 	}
 
+	scope, err := loadDataScope(c.Request.Context(), h.db, contextUserID(c))
+	if err != nil {
+		Fail(c, "500", "查询部门失败")
+		return
+	}
+	where, args, _ = scope.appendWhere(where, args, argPos, deptDataScopeColumns)
+
 	query := `
 SELECT d.id,
        d.name,
@@ -571,8 +577,23 @@ LIMIT 1;
 	OK(c, true)
 }
 
-// ExportDept handles GET /system/dept/export and streams a simple CSV file.
-// 前端只需要一个可下载的文件，这里用 CSV 简化实现。
+// deptExportColumns 与 Java DeptResp 的 @ExcelProperty 保持一致。
+var deptExportColumns = []exportColumn{
+	{"id", "ID"},
+	{"name", "名称"},
+	{"parentId", "上级部门 ID"},
+	{"status", "状态"},
+	{"sort", "排序"},
+	{"isSystem", "系统内置"},
+	{"description", "描述"},
+	{"createUserString", "创建人"},
+	{"createTime", "创建时间"},
+	{"updateUserString", "修改人"},
+	{"updateTime", "修改时间"},
+}
+
+// ExportDept handles GET /system/dept/export.
+// 筛选条件与列表查询一致，支持 format（xlsx/csv）与 columns 参数。
 func (h *DeptHandler) ExportDept(c *gin.Context) {
 	desc := strings.TrimSpace(c.Query("description"))
 	statusStr := strings.TrimSpace(c.Query("status"))
@@ -598,6 +619,13 @@ func (h *DeptHandler) ExportDept(c *gin.Context) {
 		argPos++
 	}
 
+	scope, err := loadDataScope(c.Request.Context(), h.db, contextUserID(c))
+	if err != nil {
+		Fail(c, "500", "导出部门失败")
+		return
+	}
+	where, args, _ = scope.appendWhere(where, args, argPos, deptDataScopeColumns)
+
 	query := `
 SELECT d.id,
        d.name,
@@ -624,22 +652,20 @@ ORDER BY d.sort ASC, d.id ASC;
 	}
 	defer rows.Close()
 
-	c.Header("Content-Type", "text/csv; charset=utf-8")
-	c.Header("Content-Disposition", "attachment; filename=\"dept_export.csv\"")
-
-	writer := csv.NewWriter(c.Writer)
-	_ = writer.Write([]string{"ID", "名称", "上级部门ID", "状态", "排序", "系统内置", "描述", "创建时间", "创建人", "修改时间", "修改人"})
-
+	exporter := newTableExporter(c, "部门数据", deptExportColumns)
+	if exporter == nil {
+		return
+	}
 	for rows.Next() {
 		var (
-			id, parentID          int64
-			name, description     string
-			statusVal             int16
-			sortVal               int32
-			isSystem              bool
-			createTime            time.Time
+			id, parentID           int64
+			name, description      string
+			statusVal              int16
+			sortVal                int32
+			isSystem               bool
+			createTime             time.Time
 			createUser, updateUser string
-			updateTime            sql.NullTime
+			updateTime             sql.NullTime
 		)
 		if err := rows.Scan(
 			&id,
@@ -654,28 +680,31 @@ ORDER BY d.sort ASC, d.id ASC;
 			&updateTime,
 			&updateUser,
 		); err != nil {
-			continue
+			exporter.Finish(c, err)
+			return
 		}
 
 		ut := ""
 		if updateTime.Valid {
-			ut = updateTime.Time.Format(time.RFC3339)
+			ut = formatTime(updateTime.Time)
 		}
 
-		record := []string{
+		if err := exporter.Write([]string{
 			strconv.FormatInt(id, 10),
 			name,
 			strconv.FormatInt(parentID, 10),
-			strconv.FormatInt(int64(statusVal), 10),
+			exportStatusText(statusVal),
 			strconv.FormatInt(int64(sortVal), 10),
-			strconv.FormatBool(isSystem),
+			exportBoolText(isSystem),
 			description,
-			createTime.Format(time.RFC3339),
 			createUser,
-			ut,
+			formatTime(createTime),
 			updateUser,
+			ut,
+		}); err != nil {
+			exporter.Finish(c, err)
+			return
 		}
-		_ = writer.Write(record)
 	}
-	writer.Flush()
+	exporter.Finish(c, rows.Err())
 }
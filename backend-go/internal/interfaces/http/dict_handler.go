@@ -70,21 +70,21 @@ func NewDictHandler(db *sql.DB, tokenSvc *security.TokenService) *DictHandler {
 }
 
 // RegisterDictRoutes registers dictionary management routes.
-func (h *DictHandler) RegisterDictRoutes(r *gin.Engine) {
+func (h *DictHandler) RegisterDictRoutes(r *gin.Engine, am *AuthMiddleware) {
 	// 字典本身
-	r.GET("/system/dict/list", h.ListDict)
-	r.GET("/system/dict/:id", h.GetDict)
-	r.POST("/system/dict", h.CreateDict)
-	r.PUT("/system/dict/:id", h.UpdateDict)
-	r.DELETE("/system/dict", h.DeleteDict)
-	r.DELETE("/system/dict/cache/:code", h.ClearDictCache)
+	r.GET("/system/dict/list", Audit("字典管理", "查询字典列表"), am.RequirePermission("system:dict:list"), h.ListDict)
+	r.GET("/system/dict/:id", Audit("字典管理", "查询字典详情"), am.RequirePermission("system:dict:get"), h.GetDict)
+	r.POST("/system/dict", Audit("字典管理", "新增字典"), am.RequirePermission("system:dict:create"), h.CreateDict)
+	r.PUT("/system/dict/:id", Audit("字典管理", "修改字典"), am.RequirePermission("system:dict:update"), h.UpdateDict)
+	r.DELETE("/system/dict", Audit("字典管理", "删除字典"), am.RequirePermission("system:dict:delete"), h.DeleteDict)
+	r.DELETE("/system/dict/cache/:code", Audit("字典管理", "清除字典缓存"), am.RequirePermission("system:dict:item:clearCache"), h.ClearDictCache)
 
 	// 字典项
-	r.GET("/system/dict/item", h.ListDictItem)
-	r.GET("/system/dict/item/:id", h.GetDictItem)
-	r.POST("/system/dict/item", h.CreateDictItem)
-	r.PUT("/system/dict/item/:id", h.UpdateDictItem)
-	r.DELETE("/system/dict/item", h.DeleteDictItem)
+	r.GET("/system/dict/item", Audit("字典管理", "分页查询字典项"), am.RequirePermission("system:dict:item:list"), h.ListDictItem)
+	r.GET("/system/dict/item/:id", Audit("字典管理", "查询字典项详情"), am.RequirePermission("system:dict:item:get"), h.GetDictItem)
+	r.POST("/system/dict/item", Audit("字典管理", "新增字典项"), am.RequirePermission("system:dict:item:create"), h.CreateDictItem)
+	r.PUT("/system/dict/item/:id", Audit("字典管理", "修改字典项"), am.RequirePermission("system:dict:item:update"), h.UpdateDictItem)
+	r.DELETE("/system/dict/item", Audit("字典管理", "删除字典项"), am.RequirePermission("system:dict:item:delete"), h.DeleteDictItem)
 }
 
 func formatTimePtr(t *time.Time) string {
@@ -0,0 +1,199 @@
+package http
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"voc-go-backend/internal/infrastructure/excel"
+	"voc-go-backend/internal/infrastructure/trace"
+)
+
+// 导出格式，通过查询参数 format 指定，默认 xlsx。
+const (
+	exportFormatXLSX = "xlsx"
+	exportFormatCSV  = "csv"
+)
+
+// exportColumn 描述一个可导出的列，Key 供调用方通过 columns 参数选择，Title 为表头。
+type exportColumn struct {
+	Key   string
+	Title string
+}
+
+// tableExporter 以流式方式将行写入响应，支持 xlsx/csv 两种格式及列选择。
+// 行数据按全部列的顺序提供，由 exporter 按所选列输出。
+type tableExporter struct {
+	selected []int
+	xlsx     *excel.Writer
+	csv      *csv.Writer
+}
+
+// newTableExporter 解析 format / columns 参数并写出响应头与表头行。
+// columns 为逗号分隔的列 Key（也可重复传参），为空时导出全部列；
+// 参数不合法时已写出错误响应并返回 nil。
+// 文件名与 Java 一致：{name}_{yyyyMMddHHmmss}.{ext}。
+func newTableExporter(c *gin.Context, name string, columns []exportColumn) *tableExporter {
+	format := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", exportFormatXLSX)))
+	if format != exportFormatXLSX && format != exportFormatCSV {
+		Fail(c, "400", "不支持的导出格式")
+		return nil
+	}
+
+	selected, ok := selectExportColumns(c.QueryArray("columns"), columns)
+	if !ok {
+		Fail(c, "400", "导出列不正确")
+		return nil
+	}
+
+	filename := name + "_" + time.Now().Format("20060102150405") + "." + format
+	c.Header("Content-Disposition", "attachment; filename="+url.PathEscape(filename))
+	c.Header("Access-Control-Expose-Headers", "Content-Disposition, "+TraceIDHeader)
+	c.Status(http.StatusOK)
+
+	header := make([]string, len(selected))
+	for i, idx := range selected {
+		header[i] = columns[idx].Title
+	}
+
+	e := &tableExporter{selected: selected}
+	if format == exportFormatCSV {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		// UTF-8 BOM，避免 Excel 打开中文乱码
+		_, _ = c.Writer.WriteString("\uFEFF")
+		e.csv = csv.NewWriter(c.Writer)
+		_ = e.csv.Write(header)
+		return e
+	}
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w, err := excel.NewWriter(c.Writer, name)
+	if err != nil {
+		abortExport(c, err)
+		return nil
+	}
+	e.xlsx = w
+	_ = w.WriteRow(header)
+	return e
+}
+
+// selectExportColumns 将所选列 Key 转换为列下标，保持调用方给定的顺序。
+func selectExportColumns(keys []string, columns []exportColumn) ([]int, bool) {
+	var wanted []string
+	for _, k := range keys {
+		for _, part := range strings.Split(k, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				wanted = append(wanted, part)
+			}
+		}
+	}
+	if len(wanted) == 0 {
+		all := make([]int, len(columns))
+		for i := range columns {
+			all[i] = i
+		}
+		return all, true
+	}
+
+	index := make(map[string]int, len(columns))
+	for i, col := range columns {
+		index[col.Key] = i
+	}
+	seen := make(map[int]struct{}, len(wanted))
+	selected := make([]int, 0, len(wanted))
+	for _, k := range wanted {
+		i, ok := index[k]
+		if !ok {
+			return nil, false
+		}
+		if _, dup := seen[i]; dup {
+			continue
+		}
+		seen[i] = struct{}{}
+		selected = append(selected, i)
+	}
+	return selected, true
+}
+
+// Write 写出一行，record 与构造时传入的列一一对应。
+func (e *tableExporter) Write(record []string) error {
+	cells := make([]string, len(e.selected))
+	for i, idx := range e.selected {
+		if idx < len(record) {
+			cells[i] = record[idx]
+		}
+	}
+	if e.csv != nil {
+		return e.csv.Write(cells)
+	}
+	return e.xlsx.WriteRow(cells)
+}
+
+// Close 刷新缓冲并结束文件。
+func (e *tableExporter) Close() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+	return e.xlsx.Close()
+}
+
+// Finish 结束导出：err 为查询、扫描或写出过程中的错误（通常为 rows.Err()），
+// 为 nil 时正常结束文件，否则中断响应，避免客户端收到格式完整但数据缺失的文件。
+func (e *tableExporter) Finish(c *gin.Context, err error) {
+	if err == nil {
+		err = e.Close()
+	}
+	if err != nil {
+		abortExport(c, err)
+	}
+}
+
+// abortExport 在响应头已写出后中断导出：不写文件结尾，并尽量关闭底层连接，
+// 使客户端得到不完整的响应而非看似成功的文件。
+func abortExport(c *gin.Context, err error) {
+	trace.Printf(c.Request.Context(), "[export] aborted: path=%s err=%v", c.Request.URL.Path, err)
+	_ = c.Error(err)
+	c.Abort()
+	if conn, _, herr := c.Writer.Hijack(); herr == nil {
+		_ = conn.Close()
+	}
+}
+
+// 导出时常用的枚举文本，与 Java 枚举描述保持一致。
+
+func exportStatusText(status int16) string {
+	if status == 1 {
+		return "启用"
+	}
+	return "禁用"
+}
+
+func exportGenderText(gender int16) string {
+	switch gender {
+	case 1:
+		return "男"
+	case 2:
+		return "女"
+	default:
+		return "未知"
+	}
+}
+
+func exportBoolText(v bool) string {
+	if v {
+		return "是"
+	}
+	return "否"
+}
+
+func exportLogStatusText(status int16) string {
+	if status == 1 {
+		return "成功"
+	}
+	return "失败"
+}
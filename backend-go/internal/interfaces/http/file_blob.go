@@ -0,0 +1,192 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path"
+	"time"
+
+	"github.com/lib/pq"
+
+	"voc-go-backend/internal/infrastructure/id"
+)
+
+// fileBlob 为 sys_file_blob 中的一个物理对象。
+// 同一存储中内容相同（SHA256 与大小一致）的文件只保存一份，sys_file.blob_id 指向该对象；
+// ref_count 为引用它的 sys_file 记录数，最后一条记录删除时才删除物理文件及缩略图。
+type fileBlob struct {
+	ID        int64
+	StorageID int64
+	Path      string
+	Metadata  string
+	ThumbName string
+	ThumbSize *int64
+	ThumbMeta string
+}
+
+// errBlobReleased 准备复用的对象在登记引用前已被删除。
+var errBlobReleased = errors.New("file blob released")
+
+const fileBlobColumns = `id, storage_id, path, COALESCE(metadata, ''),
+       COALESCE(thumbnail_name, ''), thumbnail_size, COALESCE(thumbnail_metadata, '')`
+
+func scanFileBlob(row *sql.Row) (*fileBlob, error) {
+	var (
+		b         fileBlob
+		thumbSize sql.NullInt64
+	)
+	err := row.Scan(&b.ID, &b.StorageID, &b.Path, &b.Metadata, &b.ThumbName, &thumbSize, &b.ThumbMeta)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if thumbSize.Valid {
+		b.ThumbSize = &thumbSize.Int64
+	}
+	return &b, nil
+}
+
+// findBlob 查找指定存储中内容相同的对象，不存在时返回 nil。
+func findBlob(ctx context.Context, q rowQueryer, storageID int64, sha string, size int64) (*fileBlob, error) {
+	const query = `
+SELECT ` + fileBlobColumns + `
+FROM sys_file_blob
+WHERE storage_id = $1 AND sha256 = $2 AND size = $3 AND ref_count > 0
+ORDER BY id
+LIMIT 1;
+`
+	return scanFileBlob(q.QueryRowContext(ctx, query, storageID, sha, size))
+}
+
+// useBlob 让文件复用已有对象，文件名、路径与缩略图均取自该对象。
+func (f *uploadedFile) useBlob(b *fileBlob) {
+	f.blobID = b.ID
+	f.name = path.Base(b.Path)
+	f.path = b.Path
+	f.metadata = b.Metadata
+	f.thumbName = b.ThumbName
+	f.thumbSize = b.ThumbSize
+	f.thumbMeta = b.ThumbMeta
+}
+
+// acquireBlob 在事务中为即将写入的 sys_file 记录登记对象引用。
+// f.blobID 非 0 时引用该已有对象；否则 f 为刚写入存储的新对象，
+// 若并发上传已登记了相同内容的对象，则改为引用已有对象并返回需要清理的多余对象。
+func acquireBlob(ctx context.Context, tx *sql.Tx, storageID int64, f *uploadedFile) (*fileBlob, error) {
+	if f.blobID != 0 {
+		ok, err := retainBlob(ctx, tx, f.blobID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errBlobReleased
+		}
+		return nil, nil
+	}
+
+	// 相同内容的并发上传串行登记，避免各自新建对象
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('sys_file_blob:' || $1));`, f.sha256); err != nil {
+		return nil, err
+	}
+	existing, err := findBlob(ctx, tx, storageID, f.sha256, f.size)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		ok, err := retainBlob(ctx, tx, existing.ID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			redundant := &fileBlob{StorageID: storageID, Path: f.path, ThumbName: f.thumbName}
+			f.useBlob(existing)
+			return redundant, nil
+		}
+	}
+
+	const insertSQL = `
+INSERT INTO sys_file_blob (
+    id, storage_id, path, sha256, size, metadata,
+    thumbnail_name, thumbnail_size, thumbnail_metadata, ref_count, create_time
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 1, $10);
+`
+	blobID := id.Next()
+	if _, err := tx.ExecContext(ctx, insertSQL,
+		blobID, storageID, f.path, f.sha256, f.size, f.metadata,
+		f.thumbName, f.thumbSize, f.thumbMeta, time.Now(),
+	); err != nil {
+		return nil, err
+	}
+	f.blobID = blobID
+	return nil, nil
+}
+
+// retainBlob 引用计数加一，对象已被删除时返回 false。
+func retainBlob(ctx context.Context, tx *sql.Tx, blobID int64) (bool, error) {
+	res, err := tx.ExecContext(ctx, `UPDATE sys_file_blob SET ref_count = ref_count + 1 WHERE id = $1 AND ref_count > 0;`, blobID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// releaseBlobs 在事务中释放已删除 sys_file 记录对对象的引用（同一对象可出现多次），
+// 返回引用归零、需要删除物理文件的对象，调用方应在事务提交后调用 removeBlobObjects。
+func releaseBlobs(ctx context.Context, tx *sql.Tx, blobIDs []int64) ([]fileBlob, error) {
+	if len(blobIDs) == 0 {
+		return nil, nil
+	}
+	const releaseSQL = `
+UPDATE sys_file_blob AS b
+SET ref_count = b.ref_count - r.n
+FROM (SELECT id, COUNT(*) AS n FROM unnest($1::BIGINT[]) AS id GROUP BY id) AS r
+WHERE b.id = r.id
+RETURNING b.id, b.storage_id, b.path, COALESCE(b.thumbnail_name, ''), b.ref_count;
+`
+	rows, err := tx.QueryContext(ctx, releaseSQL, pq.Int64Array(blobIDs))
+	if err != nil {
+		return nil, err
+	}
+	var (
+		released []fileBlob
+		ids      []int64
+	)
+	for rows.Next() {
+		var (
+			b        fileBlob
+			refCount int
+		)
+		if err := rows.Scan(&b.ID, &b.StorageID, &b.Path, &b.ThumbName, &refCount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if refCount <= 0 {
+			released = append(released, b)
+			ids = append(ids, b.ID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM sys_file_blob WHERE id = ANY($1);`, pq.Int64Array(ids)); err != nil {
+			return nil, err
+		}
+	}
+	return released, nil
+}
+
+// removeBlobObjects 尽力删除对象的物理文件及缩略图。
+func (h *FileHandler) removeBlobObjects(ctx context.Context, blobs []fileBlob) {
+	for _, b := range blobs {
+		h.removeStoredObject(ctx, b.StorageID, b.Path)
+		if b.ThumbName != "" {
+			h.removeStoredObject(ctx, b.StorageID, thumbnailStoragePath(b.Path, b.ThumbName))
+		}
+	}
+}
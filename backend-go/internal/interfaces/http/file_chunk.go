@@ -0,0 +1,717 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"voc-go-backend/internal/infrastructure/id"
+	"voc-go-backend/internal/infrastructure/storage"
+)
+
+// 分片上传：init（可秒传）→ 逐个 PUT 分片 → complete 合并并登记 sys_file。
+// 上传状态保存在 Redis，客户端断网或刷新页面后可按 uploadId 查询已上传分片并续传；
+// 对象存储使用原生 Multipart Upload，分片直接流式写入存储，服务端不缓存整个文件。
+const (
+	// fileChunkKeyPrefix 上传任务：SYSTEM:FILE_UPLOAD:{uploadId}，已上传分片：{key}:PARTS
+	fileChunkKeyPrefix = "SYSTEM:FILE_UPLOAD:"
+	// fileChunkHashKeyPrefix 用户未完成的同一文件上传任务：SYSTEM:FILE_UPLOAD_HASH:{userId}:{sha256}
+	fileChunkHashKeyPrefix = "SYSTEM:FILE_UPLOAD_HASH:"
+	// fileChunkPendingKey 未完成的上传任务（ZSET，member 为 fileChunkPending JSON，score 为过期时间毫秒），
+	// 任务 key 过期后据此中止存储侧的 Multipart Upload。
+	fileChunkPendingKey = "SYSTEM:FILE_UPLOAD_PENDING"
+	// fileChunkPurgeBatch 后台清理每批处理的过期任务数。
+	fileChunkPurgeBatch = 100
+	// fileChunkPurgeRetry 中止失败的任务延后重试的时间。
+	fileChunkPurgeRetry = time.Hour
+	// fileChunkTTL 上传任务有效期，每次上传分片后续期。
+	fileChunkTTL = 24 * time.Hour
+	// fileChunkLockTTL 合并分片时的互斥锁有效期。
+	fileChunkLockTTL = 30 * time.Minute
+
+	// S3 要求除最后一片外每片不小于 5MB，且分片数不超过 10000。
+	fileChunkMinSize     int64 = 5 << 20
+	fileChunkDefaultSize int64 = 8 << 20
+	fileChunkMaxSize     int64 = 64 << 20
+	fileChunkMaxCount    int64 = 10000
+)
+
+// fileChunkInitReq 对应 POST /system/file/chunk/init。
+type fileChunkInitReq struct {
+	FileName    string `json:"fileName"`
+	FileSize    int64  `json:"fileSize"`
+	FileHash    string `json:"fileHash"` // 文件 SHA256（十六进制）
+	ContentType string `json:"contentType"`
+	ParentPath  string `json:"parentPath"`
+	ChunkSize   int64  `json:"chunkSize"` // 期望的分片大小，可选
+}
+
+// FileChunkUploadResp 分片上传任务信息。
+// Instant 为 true 时表示已秒传，File 为上传结果，无需再上传分片。
+type FileChunkUploadResp struct {
+	Instant        bool            `json:"instant"`
+	File           *FileUploadResp `json:"file,omitempty"`
+	UploadID       string          `json:"uploadId,omitempty"`
+	ChunkSize      int64           `json:"chunkSize,omitempty"`
+	ChunkCount     int             `json:"chunkCount,omitempty"`
+	UploadedChunks []int           `json:"uploadedChunks"`
+}
+
+// fileChunkSession 为缓存在 Redis 中的上传任务。
+type fileChunkSession struct {
+	UploadID    string `json:"uploadId"`
+	UserID      int64  `json:"userId"`
+	StorageID   int64  `json:"storageId"`
+	MultipartID string `json:"multipartId"` // 存储驱动侧的上传 ID
+	FileName    string `json:"fileName"`
+	StoredName  string `json:"storedName"`
+	Extension   string `json:"extension"`
+	ContentType string `json:"contentType"`
+	ParentPath  string `json:"parentPath"`
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	Hash        string `json:"hash"`
+	ChunkSize   int64  `json:"chunkSize"`
+	ChunkCount  int    `json:"chunkCount"`
+}
+
+// fileChunkPending 为中止过期上传所需的信息，上传任务 key 过期后仍保留在 fileChunkPendingKey 中。
+type fileChunkPending struct {
+	UploadID    string `json:"uploadId"`
+	StorageID   int64  `json:"storageId"`
+	Path        string `json:"path"`
+	MultipartID string `json:"multipartId"`
+}
+
+func (s *fileChunkSession) key() string      { return fileChunkKeyPrefix + s.UploadID }
+func (s *fileChunkSession) partsKey() string { return s.key() + ":PARTS" }
+func (s *fileChunkSession) hashKey() string {
+	return fileChunkHashKeyPrefix + strconv.FormatInt(s.UserID, 10) + ":" + s.Hash
+}
+
+// pendingMember 返回任务在 fileChunkPendingKey 中的 member，同一任务始终相同。
+func (s *fileChunkSession) pendingMember() string {
+	data, _ := json.Marshal(fileChunkPending{
+		UploadID:    s.UploadID,
+		StorageID:   s.StorageID,
+		Path:        s.Path,
+		MultipartID: s.MultipartID,
+	})
+	return string(data)
+}
+
+// pendingZ 返回以当前时间续期后的 fileChunkPendingKey 成员。
+func (s *fileChunkSession) pendingZ() redis.Z {
+	return redis.Z{
+		Score:  float64(time.Now().Add(fileChunkTTL).UnixMilli()),
+		Member: s.pendingMember(),
+	}
+}
+
+// partSize 返回第 number 个分片应有的大小。
+func (s *fileChunkSession) partSize(number int) int64 {
+	if number == s.ChunkCount {
+		return s.Size - int64(s.ChunkCount-1)*s.ChunkSize
+	}
+	return s.ChunkSize
+}
+
+// InitChunkUpload handles POST /system/file/chunk/init.
+// 已存在相同 SHA256 的文件时直接秒传；存在未完成的同一文件上传任务时返回该任务以便续传。
+func (h *FileHandler) InitChunkUpload(c *gin.Context) {
+	userID := contextUserID(c)
+	var req fileChunkInitReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Fail(c, "400", "请求参数不正确")
+		return
+	}
+	req.FileName = strings.TrimSpace(req.FileName)
+	req.FileHash = strings.ToLower(strings.TrimSpace(req.FileHash))
+	req.ParentPath = normalizeParentPath(req.ParentPath)
+	if req.FileName == "" {
+		Fail(c, "400", "文件名不能为空")
+		return
+	}
+	if req.FileSize <= 0 {
+		Fail(c, "400", "文件不能为空")
+		return
+	}
+	if b, err := hex.DecodeString(req.FileHash); err != nil || len(b) != sha256.Size {
+		Fail(c, "400", "文件哈希不正确")
+		return
+	}
+	ctx := c.Request.Context()
+
+	if !checkChunkQuota(c, checkUserQuota(ctx, h.db, userID, req.FileSize)) {
+		return
+	}
+	// 秒传只复用当前用户本就能读取的内容（自己的文件，或拥有文件查询权限），
+	// 避免仅凭哈希取得他人文件。
+	readAll, err := h.auth.HasAnyPermission(c, "system:file:list")
+	if err != nil {
+		Fail(c, "500", "校验访问权限失败")
+		return
+	}
+	if file, err := h.instantUpload(ctx, &req, userID, readAll); err != nil {
+		var quotaErr *fileQuotaError
+		if errors.As(err, &quotaErr) {
+			Fail(c, "400", quotaErr.msg)
+			return
+		}
+		Fail(c, "500", "初始化上传失败")
+		return
+	} else if file != nil {
+		OK(c, FileChunkUploadResp{Instant: true, File: file, UploadedChunks: []int{}})
+		return
+	}
+
+	// 续传：同一用户在同一目录上传同一文件
+	if uploadID, err := h.redis.Get(ctx, fileChunkHashKeyPrefix+strconv.FormatInt(userID, 10)+":"+req.FileHash).Result(); err == nil {
+		if s, err := h.loadChunkSession(ctx, uploadID, userID); err == nil &&
+			s.Size == req.FileSize && s.ParentPath == req.ParentPath {
+			resp, err := h.chunkUploadStatus(ctx, s)
+			if err != nil {
+				Fail(c, "500", "初始化上传失败")
+				return
+			}
+			OK(c, resp)
+			return
+		}
+	}
+
+	storageCfg, err := h.getDefaultStorage(ctx)
+	if err != nil {
+		Fail(c, "500", "获取存储配置失败")
+		return
+	}
+	driver, err := h.storages.Driver(storageCfg)
+	if err != nil {
+		Fail(c, "500", "获取存储配置失败")
+		return
+	}
+	uploader, ok := driver.(storage.MultipartUploader)
+	if !ok {
+		Fail(c, "400", "当前存储不支持分片上传")
+		return
+	}
+	if !checkChunkQuota(c, checkStorageQuota(ctx, h.db, storageCfg, req.FileSize)) {
+		return
+	}
+
+	ext := extensionFromFilename(req.FileName)
+	storedName := strconv.FormatInt(id.Next(), 10)
+	if ext != "" {
+		storedName += "." + ext
+	}
+	chunkSize := fileChunkSizeFor(req.FileSize, req.ChunkSize)
+	s := &fileChunkSession{
+		UserID:      userID,
+		StorageID:   storageCfg.ID,
+		FileName:    req.FileName,
+		StoredName:  storedName,
+		Extension:   ext,
+		ContentType: strings.TrimSpace(req.ContentType),
+		ParentPath:  req.ParentPath,
+		Path:        joinStoragePath(req.ParentPath, storedName),
+		Size:        req.FileSize,
+		Hash:        req.FileHash,
+		ChunkSize:   chunkSize,
+		ChunkCount:  int((req.FileSize + chunkSize - 1) / chunkSize),
+	}
+	if s.UploadID, err = newImportKey(); err != nil {
+		Fail(c, "500", "初始化上传失败")
+		return
+	}
+	if s.MultipartID, err = uploader.InitiateMultipart(ctx, s.Path, s.ContentType); err != nil {
+		Fail(c, "500", "初始化上传失败")
+		return
+	}
+	if err := h.saveChunkSession(ctx, s); err != nil {
+		_ = uploader.AbortMultipart(ctx, s.Path, s.MultipartID)
+		Fail(c, "500", "初始化上传失败")
+		return
+	}
+	OK(c, FileChunkUploadResp{
+		UploadID:       s.UploadID,
+		ChunkSize:      s.ChunkSize,
+		ChunkCount:     s.ChunkCount,
+		UploadedChunks: []int{},
+	})
+}
+
+// checkChunkQuota 处理配额校验结果，校验未通过时返回 false 并写入错误响应。
+func checkChunkQuota(c *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+	var quotaErr *fileQuotaError
+	if errors.As(err, &quotaErr) {
+		Fail(c, "400", quotaErr.msg)
+		return false
+	}
+	Fail(c, "500", "校验存储配额失败")
+	return false
+}
+
+// fileChunkSizeFor 计算分片大小：优先使用客户端期望值，并保证满足 S3 分片大小与数量限制。
+func fileChunkSizeFor(fileSize, requested int64) int64 {
+	size := requested
+	if size <= 0 {
+		size = fileChunkDefaultSize
+	}
+	size = min(max(size, fileChunkMinSize), fileChunkMaxSize)
+	if least := (fileSize + fileChunkMaxCount - 1) / fileChunkMaxCount; size < least {
+		size = least
+	}
+	return size
+}
+
+// instantUpload 秒传：已有相同 SHA256 且大小一致的对象时，新增一条引用该对象的 sys_file 记录。
+// readAll 为 false 时仅复用该用户自己的文件（含回收站）所引用的对象。
+// 优先复用默认存储中的对象；没有可复用的对象时返回 nil。
+func (h *FileHandler) instantUpload(ctx context.Context, req *fileChunkInitReq, userID int64, readAll bool) (*FileUploadResp, error) {
+	defaultCfg, err := h.getDefaultStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	const query = `
+SELECT ` + fileBlobColumns + `
+FROM sys_file_blob AS b
+WHERE sha256 = $1 AND size = $2 AND ref_count > 0
+  AND ($4 OR EXISTS (SELECT 1 FROM sys_file WHERE blob_id = b.id AND create_user = $5)
+          OR EXISTS (SELECT 1 FROM sys_file_recycle WHERE blob_id = b.id AND create_user = $5))
+ORDER BY storage_id = $3 DESC, id
+LIMIT 1;
+`
+	blob, err := scanFileBlob(h.db.QueryRowContext(ctx, query, req.FileHash, req.FileSize, defaultCfg.ID, readAll, userID))
+	if err != nil || blob == nil {
+		return nil, err
+	}
+
+	storageCfg, err := h.storageForFile(ctx, blob.StorageID)
+	if err != nil {
+		return nil, err
+	}
+	driver, err := h.storages.Driver(storageCfg)
+	if err != nil {
+		return nil, err
+	}
+	// 对象已被手动清理或存储不可用时退回正常上传
+	if _, err := driver.Stat(ctx, blob.Path); err != nil {
+		return nil, nil
+	}
+
+	f := &uploadedFile{
+		originalName: req.FileName,
+		parentPath:   req.ParentPath,
+		ext:          extensionFromFilename(req.FileName),
+		contentType:  strings.TrimSpace(req.ContentType),
+		sha256:       req.FileHash,
+		size:         req.FileSize,
+	}
+	f.useBlob(blob)
+	resp, err := h.saveFileRecord(ctx, storageCfg, f, userID)
+	if errors.Is(err, errBlobReleased) {
+		return nil, nil
+	}
+	return resp, err
+}
+
+// GetChunkUpload handles GET /system/file/chunk/:uploadId，返回已上传的分片，用于断点续传。
+func (h *FileHandler) GetChunkUpload(c *gin.Context) {
+	s, ok := h.chunkSessionFromRequest(c)
+	if !ok {
+		return
+	}
+	resp, err := h.chunkUploadStatus(c.Request.Context(), s)
+	if err != nil {
+		Fail(c, "500", "查询上传任务失败")
+		return
+	}
+	OK(c, resp)
+}
+
+// UploadChunk handles PUT /system/file/chunk/:uploadId/:partNumber.
+// 请求体为分片的原始内容，Content-Length 必须与分片大小一致；重复上传同一分片会覆盖。
+func (h *FileHandler) UploadChunk(c *gin.Context) {
+	s, ok := h.chunkSessionFromRequest(c)
+	if !ok {
+		return
+	}
+	number, err := strconv.Atoi(c.Param("partNumber"))
+	if err != nil || number < 1 || number > s.ChunkCount {
+		Fail(c, "400", "分片序号不正确")
+		return
+	}
+	size := s.partSize(number)
+	if c.Request.ContentLength != size {
+		Fail(c, "400", "分片大小不正确")
+		return
+	}
+	ctx := c.Request.Context()
+
+	uploader, err := h.chunkUploader(ctx, s)
+	if err != nil {
+		Fail(c, "500", "获取存储配置失败")
+		return
+	}
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, size)
+	part, err := uploader.UploadPart(ctx, s.Path, s.MultipartID, number, body, size)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			Fail(c, "404", "上传任务不存在或已过期")
+			return
+		}
+		Fail(c, "500", "上传分片失败")
+		return
+	}
+	part.Size = size
+
+	data, _ := json.Marshal(part)
+	pipe := h.redis.TxPipeline()
+	pipe.HSet(ctx, s.partsKey(), strconv.Itoa(number), data)
+	pipe.Expire(ctx, s.partsKey(), fileChunkTTL)
+	pipe.Expire(ctx, s.key(), fileChunkTTL)
+	pipe.Expire(ctx, s.hashKey(), fileChunkTTL)
+	pipe.ZAdd(ctx, fileChunkPendingKey, s.pendingZ())
+	if _, err := pipe.Exec(ctx); err != nil {
+		Fail(c, "500", "上传分片失败")
+		return
+	}
+	OK(c, true)
+}
+
+// CompleteChunkUpload handles POST /system/file/chunk/:uploadId/complete.
+// 合并全部分片后校验大小与 SHA256，校验通过才登记 sys_file；
+// 期间已有相同内容登记时改为引用已有对象，合并出的对象随之删除。
+func (h *FileHandler) CompleteChunkUpload(c *gin.Context) {
+	s, ok := h.chunkSessionFromRequest(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	lockKey := s.key() + ":LOCK"
+	locked, err := h.redis.SetNX(ctx, lockKey, 1, fileChunkLockTTL).Result()
+	if err != nil {
+		Fail(c, "500", "合并分片失败")
+		return
+	}
+	if !locked {
+		Fail(c, "400", "文件正在合并，请勿重复提交")
+		return
+	}
+	defer h.redis.Del(context.WithoutCancel(ctx), lockKey)
+
+	uploaded, err := h.uploadedChunks(ctx, s)
+	if err != nil {
+		Fail(c, "500", "合并分片失败")
+		return
+	}
+	parts := make([]storage.Part, 0, s.ChunkCount)
+	for number := 1; number <= s.ChunkCount; number++ {
+		part, ok := uploaded[number]
+		if !ok {
+			Fail(c, "400", fmt.Sprintf("分片 %d 尚未上传", number))
+			return
+		}
+		parts = append(parts, part)
+	}
+
+	storageCfg, err := h.storageForFile(ctx, s.StorageID)
+	if err != nil {
+		Fail(c, "500", "获取存储配置失败")
+		return
+	}
+	driver, err := h.storages.Driver(storageCfg)
+	if err != nil {
+		Fail(c, "500", "获取存储配置失败")
+		return
+	}
+	uploader, ok := driver.(storage.MultipartUploader)
+	if !ok {
+		Fail(c, "400", "当前存储不支持分片上传")
+		return
+	}
+	// 初始化后配额可能已被其它上传占用；未通过时保留任务，释放空间后可重试。
+	if !checkChunkQuota(c, checkUserQuota(ctx, h.db, s.UserID, s.Size)) {
+		return
+	}
+	if !checkChunkQuota(c, checkStorageQuota(ctx, h.db, storageCfg, s.Size)) {
+		return
+	}
+	if err := uploader.CompleteMultipart(ctx, s.Path, s.MultipartID, parts); err != nil {
+		Fail(c, "500", "合并分片失败")
+		return
+	}
+
+	f := &uploadedFile{
+		name:         s.StoredName,
+		originalName: s.FileName,
+		parentPath:   s.ParentPath,
+		path:         s.Path,
+		ext:          s.Extension,
+		contentType:  s.ContentType,
+		sha256:       s.Hash,
+		size:         s.Size,
+	}
+	if err := verifyChunkUpload(ctx, driver, f); err != nil {
+		_ = driver.Delete(ctx, s.Path)
+		h.clearChunkSession(ctx, s)
+		Fail(c, "400", "文件校验失败，请重新上传")
+		return
+	}
+
+	resp, err := h.saveFileRecord(ctx, storageCfg, f, s.UserID)
+	if err != nil {
+		// Multipart Upload 已完成，无法续传：删除合并出的对象及缩略图（重复删除无害）并结束任务。
+		h.removeBlobObjects(context.WithoutCancel(ctx), []fileBlob{{StorageID: storageCfg.ID, Path: f.path, ThumbName: f.thumbName}})
+		h.clearChunkSession(ctx, s)
+		var quotaErr *fileQuotaError
+		if errors.As(err, &quotaErr) {
+			Fail(c, "400", quotaErr.msg)
+			return
+		}
+		Fail(c, "500", "保存文件记录失败")
+		return
+	}
+	h.clearChunkSession(ctx, s)
+	OK(c, resp)
+}
+
+// verifyChunkUpload 读取合并后的对象校验大小与 SHA256，图片顺带生成缩略图。
+// 秒传依赖 sha256 定位已有内容，因此必须以服务端计算的哈希为准。
+func verifyChunkUpload(ctx context.Context, driver storage.Driver, f *uploadedFile) error {
+	rc, err := driver.Get(ctx, f.path)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	sha, size, err := sha256Hex(rc)
+	if err != nil {
+		return err
+	}
+	if size != f.size || sha != f.sha256 {
+		return errors.New("file hash mismatch")
+	}
+
+	if rs, ok := rc.(io.ReadSeeker); ok && supportsThumbnail(f.ext) {
+		if _, err := rs.Seek(0, io.SeekStart); err == nil {
+			if thumb, err := putThumbnail(ctx, driver, rs, f.ext, f.path); err == nil {
+				f.setThumbnail(thumb)
+			}
+		}
+	}
+	return nil
+}
+
+// AbortChunkUpload handles DELETE /system/file/chunk/:uploadId，取消上传并清理已上传的分片。
+func (h *FileHandler) AbortChunkUpload(c *gin.Context) {
+	s, ok := h.chunkSessionFromRequest(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	if uploader, err := h.chunkUploader(ctx, s); err == nil {
+		_ = uploader.AbortMultipart(ctx, s.Path, s.MultipartID)
+	}
+	h.clearChunkSession(ctx, s)
+	OK(c, true)
+}
+
+// chunkSessionFromRequest 按路径参数 uploadId 读取当前用户的上传任务，失败时已写出错误响应。
+func (h *FileHandler) chunkSessionFromRequest(c *gin.Context) (*fileChunkSession, bool) {
+	s, err := h.loadChunkSession(c.Request.Context(), c.Param("uploadId"), contextUserID(c))
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			Fail(c, "404", "上传任务不存在或已过期")
+			return nil, false
+		}
+		Fail(c, "500", "查询上传任务失败")
+		return nil, false
+	}
+	return s, true
+}
+
+// loadChunkSession 读取上传任务，不存在或不属于该用户时返回 redis.Nil。
+func (h *FileHandler) loadChunkSession(ctx context.Context, uploadID string, userID int64) (*fileChunkSession, error) {
+	if uploadID == "" {
+		return nil, redis.Nil
+	}
+	raw, err := h.redis.Get(ctx, fileChunkKeyPrefix+uploadID).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var s fileChunkSession
+	if err := json.Unmarshal(raw, &s); err != nil || s.UserID != userID || s.UploadID != uploadID {
+		return nil, redis.Nil
+	}
+	return &s, nil
+}
+
+func (h *FileHandler) saveChunkSession(ctx context.Context, s *fileChunkSession) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	pipe := h.redis.TxPipeline()
+	pipe.Set(ctx, s.key(), data, fileChunkTTL)
+	pipe.Set(ctx, s.hashKey(), s.UploadID, fileChunkTTL)
+	pipe.ZAdd(ctx, fileChunkPendingKey, s.pendingZ())
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (h *FileHandler) clearChunkSession(ctx context.Context, s *fileChunkSession) {
+	ctx = context.WithoutCancel(ctx)
+	pipe := h.redis.TxPipeline()
+	pipe.Del(ctx, s.key(), s.partsKey(), s.hashKey())
+	pipe.ZRem(ctx, fileChunkPendingKey, s.pendingMember())
+	_, _ = pipe.Exec(ctx)
+}
+
+// StartChunkUploadPurge 启动后台任务，每隔 interval 中止超过有效期仍未完成的分片上传，
+// 释放对象存储中未完成的 Multipart Upload 以及本地存储的 .multipart 临时目录。
+// 多实例部署时各实例可同时运行，以 ZREM 的结果决定由哪个实例处理。
+func (h *FileHandler) StartChunkUploadPurge(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if n, err := h.purgeStaleChunkUploads(ctx); err != nil {
+				log.Printf("purge stale chunk uploads failed: %v", err)
+			} else if n > 0 {
+				log.Printf("aborted %d stale chunk uploads", n)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// purgeStaleChunkUploads 分批中止已过期的上传任务，返回中止数量。
+// 任务 key 仍存在（刚续期）时按其剩余有效期重新计分；中止失败的任务延后重试。
+func (h *FileHandler) purgeStaleChunkUploads(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		now := time.Now()
+		members, err := h.redis.ZRangeArgs(ctx, redis.ZRangeArgs{
+			Key:     fileChunkPendingKey,
+			Start:   "-inf",
+			Stop:    strconv.FormatInt(now.UnixMilli(), 10),
+			ByScore: true,
+			Count:   fileChunkPurgeBatch,
+		}).Result()
+		if err != nil {
+			return total, err
+		}
+		for _, member := range members {
+			var p fileChunkPending
+			if json.Unmarshal([]byte(member), &p) != nil {
+				_ = h.redis.ZRem(ctx, fileChunkPendingKey, member).Err()
+				continue
+			}
+			ttl, err := h.redis.PTTL(ctx, fileChunkKeyPrefix+p.UploadID).Result()
+			if err != nil {
+				return total, err
+			}
+			if ttl > 0 {
+				_ = h.redis.ZAdd(ctx, fileChunkPendingKey, redis.Z{Score: float64(now.Add(ttl).UnixMilli()), Member: member}).Err()
+				continue
+			}
+			removed, err := h.redis.ZRem(ctx, fileChunkPendingKey, member).Result()
+			if err != nil {
+				return total, err
+			}
+			if removed == 0 {
+				continue // 已由其它实例处理
+			}
+			uploader, err := h.chunkUploader(ctx, &fileChunkSession{StorageID: p.StorageID})
+			if err == nil {
+				err = uploader.AbortMultipart(ctx, p.Path, p.MultipartID)
+			}
+			if err != nil {
+				log.Printf("abort stale chunk upload %s failed: %v", p.UploadID, err)
+				_ = h.redis.ZAdd(ctx, fileChunkPendingKey, redis.Z{Score: float64(now.Add(fileChunkPurgeRetry).UnixMilli()), Member: member}).Err()
+				continue
+			}
+			total++
+		}
+		if len(members) < fileChunkPurgeBatch {
+			return total, nil
+		}
+	}
+}
+
+// uploadedChunks 返回已上传的分片，按分片序号索引。
+func (h *FileHandler) uploadedChunks(ctx context.Context, s *fileChunkSession) (map[int]storage.Part, error) {
+	fields, err := h.redis.HGetAll(ctx, s.partsKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+	parts := make(map[int]storage.Part, len(fields))
+	for k, v := range fields {
+		number, err := strconv.Atoi(k)
+		if err != nil {
+			continue
+		}
+		var part storage.Part
+		if json.Unmarshal([]byte(v), &part) != nil {
+			continue
+		}
+		parts[number] = part
+	}
+	return parts, nil
+}
+
+func (h *FileHandler) chunkUploadStatus(ctx context.Context, s *fileChunkSession) (*FileChunkUploadResp, error) {
+	uploaded, err := h.uploadedChunks(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	numbers := make([]int, 0, len(uploaded))
+	for number := range uploaded {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+	return &FileChunkUploadResp{
+		UploadID:       s.UploadID,
+		ChunkSize:      s.ChunkSize,
+		ChunkCount:     s.ChunkCount,
+		UploadedChunks: numbers,
+	}, nil
+}
+
+// chunkUploader 返回上传任务所在存储的分片上传驱动。
+func (h *FileHandler) chunkUploader(ctx context.Context, s *fileChunkSession) (storage.MultipartUploader, error) {
+	storageCfg, err := h.storageForFile(ctx, s.StorageID)
+	if err != nil {
+		return nil, err
+	}
+	driver, err := h.storages.Driver(storageCfg)
+	if err != nil {
+		return nil, err
+	}
+	uploader, ok := driver.(storage.MultipartUploader)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return uploader, nil
+}
@@ -0,0 +1,50 @@
+package http
+
+import "testing"
+
+func TestFileChunkSizeFor(t *testing.T) {
+	const mb = int64(1 << 20)
+	tests := []struct {
+		name      string
+		fileSize  int64
+		requested int64
+		want      int64
+	}{
+		{"default size", 100 * mb, 0, fileChunkDefaultSize},
+		{"negative request uses default", 100 * mb, -1, fileChunkDefaultSize},
+		{"requested size kept", 100 * mb, 16 * mb, 16 * mb},
+		{"raised to S3 minimum", 100 * mb, 1 * mb, fileChunkMinSize},
+		{"capped at maximum", 100 * mb, 1024 * mb, fileChunkMaxSize},
+		{"small file still uses minimum", 1, 0, fileChunkDefaultSize},
+		{"grown to stay within max count", 200_000 * mb, 0, 20 * mb},
+		{"max count rounds up", 10000*fileChunkMaxSize + 1, 0, fileChunkMaxSize + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fileChunkSizeFor(tt.fileSize, tt.requested)
+			if got != tt.want {
+				t.Fatalf("fileChunkSizeFor(%d, %d) = %d, want %d", tt.fileSize, tt.requested, got, tt.want)
+			}
+			if count := (tt.fileSize + got - 1) / got; count > fileChunkMaxCount {
+				t.Fatalf("chunk count %d exceeds %d", count, fileChunkMaxCount)
+			}
+		})
+	}
+}
+
+func TestFileChunkSessionPartSize(t *testing.T) {
+	s := &fileChunkSession{Size: 20, ChunkSize: 8, ChunkCount: 3}
+	tests := []struct {
+		number int
+		want   int64
+	}{
+		{1, 8},
+		{2, 8},
+		{3, 4},
+	}
+	for _, tt := range tests {
+		if got := s.partSize(tt.number); got != tt.want {
+			t.Errorf("partSize(%d) = %d, want %d", tt.number, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"voc-go-backend/internal/infrastructure/storage"
+)
+
+// filePresignExpiry 对象存储预签名下载链接的有效期。
+const filePresignExpiry = 5 * time.Minute
+
+// fileDownloadURL 返回鉴权下载接口地址，thumbnail 为 true 时下载缩略图。
+func fileDownloadURL(fileID int64, thumbnail bool) string {
+	u := "/system/file/" + strconv.FormatInt(fileID, 10) + "/download"
+	if thumbnail {
+		u += "?thumbnail=true"
+	}
+	return u
+}
+
+// DownloadFile handles GET /system/file/:id/download.
+// 仅文件上传者或拥有文件管理查询权限的用户可以下载；
+// 本地存储由服务端直接输出文件内容，对象存储重定向到短期有效的预签名 URL。
+func (h *FileHandler) DownloadFile(c *gin.Context) {
+	fileID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || fileID <= 0 {
+		Fail(c, "400", "ID 参数不正确")
+		return
+	}
+	ctx := c.Request.Context()
+
+	const query = `
+SELECT original_name, path, COALESCE(thumbnail_name, ''),
+       COALESCE(content_type, ''), type, storage_id, create_user
+FROM sys_file
+WHERE id = $1;
+`
+	var (
+		originalName, fullPath, thumbName, contentType string
+		fileType                                       int16
+		storageID, createUser                          int64
+	)
+	if err := h.db.QueryRowContext(ctx, query, fileID).Scan(
+		&originalName, &fullPath, &thumbName, &contentType, &fileType, &storageID, &createUser,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			Fail(c, "404", "文件不存在")
+			return
+		}
+		Fail(c, "500", "下载文件失败")
+		return
+	}
+	if fileType == 0 {
+		Fail(c, "400", "文件夹不支持下载")
+		return
+	}
+
+	if createUser != contextUserID(c) {
+		allowed, err := h.auth.HasAnyPermission(c, "system:file:list")
+		if err != nil {
+			Fail(c, "500", "校验访问权限失败")
+			return
+		}
+		if !allowed {
+			Fail(c, "403", "没有访问权限，请联系管理员授权")
+			return
+		}
+	}
+
+	if c.Query("thumbnail") == "true" && thumbName != "" {
+		fullPath = thumbnailStoragePath(fullPath, thumbName)
+		contentType = ""
+	}
+
+	storageCfg, err := h.storageForFile(ctx, storageID)
+	if err != nil {
+		Fail(c, "500", "获取存储配置失败")
+		return
+	}
+	driver, err := h.storages.Driver(storageCfg)
+	if err != nil {
+		Fail(c, "500", "获取存储配置失败")
+		return
+	}
+
+	if presigned, err := driver.Presign(ctx, fullPath, filePresignExpiry); err == nil {
+		c.Redirect(http.StatusFound, presigned)
+		return
+	} else if !errors.Is(err, storage.ErrNotSupported) {
+		Fail(c, "500", "下载文件失败")
+		return
+	}
+
+	info, err := driver.Stat(ctx, fullPath)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			Fail(c, "404", "文件不存在")
+			return
+		}
+		Fail(c, "500", "下载文件失败")
+		return
+	}
+	rc, err := driver.Get(ctx, fullPath)
+	if err != nil {
+		Fail(c, "500", "下载文件失败")
+		return
+	}
+	defer rc.Close()
+
+	if contentType == "" {
+		contentType = info.ContentType
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Disposition", "attachment; filename="+url.PathEscape(originalName))
+	c.Header("Access-Control-Expose-Headers", "Content-Disposition, "+TraceIDHeader)
+	c.DataFromReader(http.StatusOK, info.Size, contentType, rc, nil)
+}
+
+// RegisterLocalFileRoutes 提供本地存储文件的公开访问（替代 r.Static）。
+// 仅输出 sys_file 中登记过、且所属存储允许公开访问的文件，
+// 关闭公开访问的存储只能通过 DownloadFile 鉴权下载。
+func (h *FileHandler) RegisterLocalFileRoutes(r *gin.Engine, root string) {
+	serve := func(c *gin.Context) {
+		h.serveLocalFile(c, root)
+	}
+	r.GET("/file/*filepath", NoAudit(), serve)
+	r.HEAD("/file/*filepath", NoAudit(), serve)
+}
+
+func (h *FileHandler) serveLocalFile(c *gin.Context, root string) {
+	key := storage.ObjectKey(c.Param("filepath"))
+	if key == "" {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	fullPath := "/" + key
+
+	// 文件本身或其缩略图（缩略图与原文件同目录，name 为原文件的存储文件名）
+	const query = `
+SELECT COALESCE(s.public_access, TRUE)
+FROM sys_file AS f
+LEFT JOIN sys_storage AS s ON s.id = f.storage_id
+WHERE f.type <> 0
+  AND (f.path = $1 OR (f.thumbnail_name = $2 AND f.path = $3 || f.name))
+LIMIT 1;
+`
+	dir := path.Dir(fullPath)
+	if dir != "/" {
+		dir += "/"
+	}
+	var public bool
+	err := h.db.QueryRowContext(c.Request.Context(), query, fullPath, path.Base(fullPath), dir).Scan(&public)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	if err != nil || !public {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.File(filepath.Join(root, filepath.FromSlash(key)))
+}
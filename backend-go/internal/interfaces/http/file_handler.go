@@ -5,10 +5,10 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -16,12 +16,11 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
-	"github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/redis/go-redis/v9"
 
 	"voc-go-backend/internal/infrastructure/id"
 	"voc-go-backend/internal/infrastructure/security"
+	"voc-go-backend/internal/infrastructure/storage"
 )
 
 // FileItem matches the front-end FileItem type in admin/src/apis/system/type.ts.
@@ -75,29 +74,55 @@ type FileUploadResp struct {
 type FileHandler struct {
 	db       *sql.DB
 	tokenSvc *security.TokenService
+	storages *storage.Manager
+	auth     *AuthMiddleware
+	redis    *redis.Client
 }
 
-func NewFileHandler(db *sql.DB, tokenSvc *security.TokenService) *FileHandler {
+func NewFileHandler(db *sql.DB, tokenSvc *security.TokenService, storages *storage.Manager, redisClient *redis.Client) *FileHandler {
 	return &FileHandler{
 		db:       db,
 		tokenSvc: tokenSvc,
+		storages: storages,
+		redis:    redisClient,
 	}
 }
 
 // RegisterFileRoutes registers all file-related routes.
-func (h *FileHandler) RegisterFileRoutes(r *gin.Engine) {
+func (h *FileHandler) RegisterFileRoutes(r *gin.Engine, am *AuthMiddleware) {
+	h.auth = am
+
 	// System file management
-	r.GET("/system/file", h.ListFile)
-	r.POST("/system/file/upload", h.UploadFile)
-	r.POST("/system/file/dir", h.CreateDir)
-	r.GET("/system/file/dir/:id/size", h.CalcDirSize)
-	r.GET("/system/file/statistics", h.Statistics)
-	r.GET("/system/file/check", h.CheckFile)
-	r.PUT("/system/file/:id", h.UpdateFile)
-	r.DELETE("/system/file", h.DeleteFile)
+	r.GET("/system/file", Audit("文件管理", "分页查询文件"), am.RequirePermission("system:file:list"), h.ListFile)
+	r.POST("/system/file/upload", Audit("文件管理", "上传文件", AuditNoRequestBody), am.RequirePermission("system:file:upload"), h.UploadFile)
+	r.POST("/system/file/dir", Audit("文件管理", "创建文件夹"), am.RequirePermission("system:file:createDir"), h.CreateDir)
+	r.GET("/system/file/dir/:id/size", Audit("文件管理", "计算文件夹大小"), am.RequirePermission("system:file:calcDirSize"), h.CalcDirSize)
+	r.GET("/system/file/statistics", Audit("文件管理", "查询文件资源统计"), am.RequirePermission("system:file:list"), h.Statistics)
+	// 秒传校验属于上传流程的一部分，沿用上传权限。
+	r.GET("/system/file/check", Audit("文件管理", "检测文件是否存在"), am.RequirePermission("system:file:upload"), h.CheckFile)
+	// 分片上传（断点续传、秒传）
+	r.POST("/system/file/chunk/init", Audit("文件管理", "初始化分片上传"), am.RequirePermission("system:file:upload"), h.InitChunkUpload)
+	r.GET("/system/file/chunk/:uploadId", Audit("文件管理", "查询分片上传进度"), am.RequirePermission("system:file:upload"), h.GetChunkUpload)
+	r.PUT("/system/file/chunk/:uploadId/:partNumber", Audit("文件管理", "上传分片", AuditNoRequestBody), am.RequirePermission("system:file:upload"), h.UploadChunk)
+	r.POST("/system/file/chunk/:uploadId/complete", Audit("文件管理", "完成分片上传"), am.RequirePermission("system:file:upload"), h.CompleteChunkUpload)
+	r.DELETE("/system/file/chunk/:uploadId", Audit("文件管理", "取消分片上传"), am.RequirePermission("system:file:upload"), h.AbortChunkUpload)
+	r.PUT("/system/file/:id", Audit("文件管理", "重命名文件"), am.RequirePermission("system:file:update"), h.UpdateFile)
+	r.DELETE("/system/file", Audit("文件管理", "删除文件"), am.RequirePermission("system:file:delete"), h.DeleteFile)
+	r.POST("/system/file/move", Audit("文件管理", "移动文件"), am.RequirePermission("system:file:move"), h.MoveFile)
+	r.POST("/system/file/copy", Audit("文件管理", "复制文件"), am.RequirePermission("system:file:copy"), h.CopyFile)
+	r.GET("/system/file/usage", Audit("文件管理", "查询存储空间使用情况"), am.RequireLogin(), h.GetFileUsage)
+	r.GET("/system/file/quota", Audit("文件管理", "查询存储配额"), am.RequirePermission("system:file:quota:list"), h.ListFileQuota)
+	r.POST("/system/file/quota", Audit("文件管理", "保存存储配额"), am.RequirePermission("system:file:quota:update"), h.SaveFileQuota)
+	r.DELETE("/system/file/quota", Audit("文件管理", "删除存储配额"), am.RequirePermission("system:file:quota:update"), h.DeleteFileQuota)
+	r.GET("/system/file/recycle", Audit("文件管理", "查询回收站"), am.RequirePermission("system:file:recycle:list"), h.ListRecycle)
+	r.POST("/system/file/recycle/restore", Audit("文件管理", "还原文件"), am.RequirePermission("system:file:recycle:restore"), h.RestoreRecycle)
+	r.DELETE("/system/file/recycle", Audit("文件管理", "彻底删除文件"), am.RequirePermission("system:file:recycle:delete"), h.DeleteRecycle)
+	r.DELETE("/system/file/recycle/clean", Audit("文件管理", "清空回收站"), am.RequirePermission("system:file:recycle:delete"), h.CleanRecycle)
+	// 下载接口在 handler 内按上传者或 system:file:list 权限鉴权。
+	r.GET("/system/file/:id/download", NoAudit(), am.RequireLogin(), h.DownloadFile)
 
 	// Common upload (avatar, editor, etc.)
-	r.POST("/common/file", h.UploadFile)
+	r.POST("/common/file", Audit("文件管理", "上传文件", AuditNoRequestBody), am.RequireLogin(), h.UploadFile)
 }
 
 func (h *FileHandler) currentUserID(c *gin.Context) int64 {
@@ -110,28 +135,6 @@ func (h *FileHandler) currentUserID(c *gin.Context) int64 {
 	return claims.UserID
 }
 
-// StorageConfig 表示 sys_storage 中的一条存储配置。
-type StorageConfig struct {
-	ID         int64
-	Name       string
-	Code       string
-	Type       int16
-	AccessKey  string
-	SecretKey  string
-	Endpoint   string
-	BucketName string
-	Domain     string
-	Region     string
-	IsDefault  bool
-	Status     int16
-}
-
-// storageType 常量定义，与 Java StorageTypeEnum 一致：1=LOCAL，2=OSS(MinIO等)。
-const (
-	storageTypeLocal int16 = 1
-	storageTypeOSS   int16 = 2
-)
-
 // fileBaseURLPrefix returns the URL prefix used for local file URLs, e.g. "/file".
 func fileBaseURLPrefix() string {
 	prefix := os.Getenv("FILE_BASE_URL")
@@ -158,14 +161,14 @@ func buildLocalFileURL(path string) string {
 // buildStorageFileURL 根据存储配置构建文件访问 URL。
 // - 对象存储：使用 storage.Domain 作为前缀（需配置为 http(s) 开头）。
 // - 本地存储或未配置域名：退回到本地静态路径 /file。
-func buildStorageFileURL(storage *StorageConfig, fullPath string) string {
-	if storage == nil {
+func buildStorageFileURL(cfg *storage.Config, fullPath string) string {
+	if cfg == nil {
 		return buildLocalFileURL(fullPath)
 	}
-	switch storage.Type {
-	case storageTypeOSS:
+	switch cfg.Type {
+	case storage.TypeS3:
 		// 对象存储必须配置 Domain，形如 http://minio:9000/bucket/
-		domain := strings.TrimSpace(storage.Domain)
+		domain := strings.TrimSpace(cfg.Domain)
 		if domain == "" {
 			return buildLocalFileURL(fullPath)
 		}
@@ -178,8 +181,24 @@ func buildStorageFileURL(storage *StorageConfig, fullPath string) string {
 	}
 }
 
+// buildFileAccessURL 返回文件访问 URL：存储关闭公开访问时指向鉴权下载接口。
+func buildFileAccessURL(cfg *storage.Config, fileID int64, fullPath string) string {
+	if cfg != nil && !cfg.PublicAccess {
+		return fileDownloadURL(fileID, false)
+	}
+	return buildStorageFileURL(cfg, fullPath)
+}
+
+// buildFileThumbnailURL 与 buildFileAccessURL 相同，但指向缩略图。
+func buildFileThumbnailURL(cfg *storage.Config, fileID int64, thumbPath string) string {
+	if cfg != nil && !cfg.PublicAccess {
+		return fileDownloadURL(fileID, true)
+	}
+	return buildStorageFileURL(cfg, thumbPath)
+}
+
 // getDefaultStorage 查询默认存储；若未显式指定，则退回到本地存储（使用 ./data/file）。
-func (h *FileHandler) getDefaultStorage(ctx context.Context) (*StorageConfig, error) {
+func (h *FileHandler) getDefaultStorage(ctx context.Context) (*storage.Config, error) {
 	const query = `
 SELECT id, name, code, type,
        COALESCE(access_key, ''),
@@ -189,12 +208,13 @@ SELECT id, name, code, type,
        COALESCE(domain, ''),
        COALESCE(region, ''),
        COALESCE(is_default, FALSE),
-       COALESCE(status, 1)
+       COALESCE(status, 1),
+       COALESCE(public_access, TRUE)
 FROM sys_storage
 WHERE is_default = TRUE
 LIMIT 1;
 `
-	var cfg StorageConfig
+	var cfg storage.Config
 	err := h.db.QueryRowContext(ctx, query).
 		Scan(
 			&cfg.ID,
@@ -209,32 +229,34 @@ LIMIT 1;
 			&cfg.Region,
 			&cfg.IsDefault,
 			&cfg.Status,
+			&cfg.PublicAccess,
 		)
 	if err == sql.ErrNoRows {
 		// 没有配置默认存储时，按单一本地存储回退，保持兼容原有逻辑。
-		return &StorageConfig{
+		return &storage.Config{
 			ID:         1,
 			Name:       "本地存储",
 			Code:       "local",
-			Type:       storageTypeLocal,
-			BucketName: "./data/file",
-			Domain:     "",
-			IsDefault:  true,
-			Status:     1,
+			Type:       storage.TypeLocal,
+			BucketName: storage.DefaultLocalBucket,
+			Domain:       "",
+			IsDefault:    true,
+			Status:       1,
+			PublicAccess: true,
 		}, nil
 	}
 	if err != nil {
 		return nil, err
 	}
 	// 如果 BucketName 未配置，本地存储仍然使用默认路径。
-	if cfg.Type == storageTypeLocal && strings.TrimSpace(cfg.BucketName) == "" {
-		cfg.BucketName = "./data/file"
+	if cfg.Type == storage.TypeLocal && strings.TrimSpace(cfg.BucketName) == "" {
+		cfg.BucketName = storage.DefaultLocalBucket
 	}
 	return &cfg, nil
 }
 
 // getStorageByID 根据存储 ID 查询配置。
-func (h *FileHandler) getStorageByID(ctx context.Context, id int64) (*StorageConfig, error) {
+func (h *FileHandler) getStorageByID(ctx context.Context, id int64) (*storage.Config, error) {
 	const query = `
 SELECT id, name, code, type,
        COALESCE(access_key, ''),
@@ -244,11 +266,12 @@ SELECT id, name, code, type,
        COALESCE(domain, ''),
        COALESCE(region, ''),
        COALESCE(is_default, FALSE),
-       COALESCE(status, 1)
+       COALESCE(status, 1),
+       COALESCE(public_access, TRUE)
 FROM sys_storage
 WHERE id = $1;
 `
-	var cfg StorageConfig
+	var cfg storage.Config
 	err := h.db.QueryRowContext(ctx, query, id).
 		Scan(
 			&cfg.ID,
@@ -263,147 +286,44 @@ WHERE id = $1;
 			&cfg.Region,
 			&cfg.IsDefault,
 			&cfg.Status,
+			&cfg.PublicAccess,
 		)
 	if err != nil {
 		return nil, err
 	}
-	if cfg.Type == storageTypeLocal && strings.TrimSpace(cfg.BucketName) == "" {
-		cfg.BucketName = "./data/file"
+	if cfg.Type == storage.TypeLocal && strings.TrimSpace(cfg.BucketName) == "" {
+		cfg.BucketName = storage.DefaultLocalBucket
 	}
 	return &cfg, nil
 }
 
-// saveToLocal 将文件保存到本地目录，并返回哈希等信息。
-func saveToLocal(header *multipart.FileHeader, bucketPath, parentPath, storedName string) (fullPath, sha string, size int64, contentType string, err error) {
-	parentPath = normalizeParentPath(parentPath)
-
-	// Full logical path stored in DB, e.g. /2025/1/1/123.jpg
-	if parentPath == "/" {
-		fullPath = "/" + storedName
-	} else {
-		fullPath = parentPath + "/" + storedName
-	}
-
-	// Physical path on disk: bucketPath + relative path.
-	if strings.TrimSpace(bucketPath) == "" {
-		bucketPath = "./data/file"
-	}
-	relative := strings.TrimPrefix(fullPath, "/")
-	dstPath := filepath.Join(bucketPath, filepath.FromSlash(relative))
-	if err = os.MkdirAll(filepath.Dir(dstPath), 0o755); err != nil {
-		return
-	}
-
-	src, err := header.Open()
-	if err != nil {
-		return
-	}
-	defer src.Close()
-
-	dst, err := os.Create(dstPath)
-	if err != nil {
-		return
+// storageForFile 返回文件所属存储的配置。
+// 未配置 sys_storage 时上传使用的是回退的本地存储，此时按默认存储处理。
+func (h *FileHandler) storageForFile(ctx context.Context, storageID int64) (*storage.Config, error) {
+	cfg, err := h.getStorageByID(ctx, storageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return h.getDefaultStorage(ctx)
 	}
-	defer dst.Close()
-
-	h := sha256.New()
-	w := io.MultiWriter(dst, h)
-	written, err := io.Copy(w, src)
-	if err != nil {
-		return
-	}
-	size = written
-	sha = hex.EncodeToString(h.Sum(nil))
-	contentType = header.Header.Get("Content-Type")
-	return
+	return cfg, err
 }
 
-// saveToMinIO 将文件上传到 MinIO/对象存储，并返回哈希等信息。
-func saveToMinIO(ctx context.Context, header *multipart.FileHeader, storage *StorageConfig, parentPath, storedName string) (fullPath, sha string, size int64, contentType string, err error) {
-	if storage == nil {
-		err = fmt.Errorf("storage config is nil")
-		return
-	}
-	if strings.TrimSpace(storage.Endpoint) == "" || strings.TrimSpace(storage.AccessKey) == "" || strings.TrimSpace(storage.SecretKey) == "" || strings.TrimSpace(storage.BucketName) == "" {
-		err = fmt.Errorf("对象存储配置不完整")
-		return
-	}
-
+// joinStoragePath 拼接目录与文件名，得到 sys_file.path 形式的完整路径，如 /2025/1/1/123.jpg。
+func joinStoragePath(parentPath, name string) string {
 	parentPath = normalizeParentPath(parentPath)
 	if parentPath == "/" {
-		fullPath = "/" + storedName
-	} else {
-		fullPath = parentPath + "/" + storedName
-	}
-	objectName := strings.TrimPrefix(fullPath, "/")
-
-	contentType = header.Header.Get("Content-Type")
-	// 先计算 SHA256
-	src, err := header.Open()
-	if err != nil {
-		return
-	}
-	h := sha256.New()
-	written, err := io.Copy(h, src)
-	_ = src.Close()
-	if err != nil {
-		return
-	}
-	size = written
-	sha = hex.EncodeToString(h.Sum(nil))
-
-	// 创建 MinIO 客户端
-	endpoint := storage.Endpoint
-	secure := false
-	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
-		u, parseErr := url.Parse(endpoint)
-		if parseErr != nil {
-			err = parseErr
-			return
-		}
-		secure = u.Scheme == "https"
-		endpoint = u.Host
+		return "/" + name
 	}
+	return parentPath + "/" + name
+}
 
-	client, err := minio.New(endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(storage.AccessKey, storage.SecretKey, ""),
-		Secure: secure,
-		// Region 用于兼容 S3 协议的对象存储（如七牛等），默认留空即可。
-		Region: strings.TrimSpace(storage.Region),
-	})
-	if err != nil {
-		return
-	}
-
-	// 确保 Bucket 存在
-	exists, errBucket := client.BucketExists(ctx, storage.BucketName)
-	if errBucket != nil {
-		err = errBucket
-		return
-	}
-	if !exists {
-		if err = client.MakeBucket(ctx, storage.BucketName, minio.MakeBucketOptions{
-			// 七牛等服务端创建 Bucket 时需要带上 Region，这里复用存储配置中的 Region。
-			Region: strings.TrimSpace(storage.Region),
-		}); err != nil {
-			return
-		}
-	}
-
-	// 实际上传
-	src2, err := header.Open()
-	if err != nil {
-		return
-	}
-	defer src2.Close()
-
-	_, err = client.PutObject(ctx, storage.BucketName, objectName, src2, size, minio.PutObjectOptions{
-		ContentType: contentType,
-	})
+// sha256Hex 计算内容的 SHA256（十六进制）与大小。
+func sha256Hex(r io.Reader) (string, int64, error) {
+	hash := sha256.New()
+	size, err := io.Copy(hash, r)
 	if err != nil {
-		return
+		return "", 0, err
 	}
-	return
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
 }
 
 // normalizeParentPath ensures parent path is in the form "/xxx/yyy" (no trailing slash).
@@ -462,90 +382,245 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 		parentPath = "/"
 	}
 
+	resp, err := h.saveUpload(c.Request.Context(), header, parentPath, userID)
+	if err != nil {
+		var quotaErr *fileQuotaError
+		if errors.As(err, &quotaErr) {
+			Fail(c, "400", quotaErr.msg)
+			return
+		}
+		var uploadErr *fileUploadError
+		if errors.As(err, &uploadErr) {
+			Fail(c, "500", uploadErr.msg)
+			return
+		}
+		Fail(c, "500", "保存文件失败")
+		return
+	}
+	OK(c, resp)
+}
+
+// fileUploadError 携带上传流程中可直接返回给前端的错误提示。
+type fileUploadError struct {
+	msg string
+	err error
+}
+
+func (e *fileUploadError) Error() string { return e.msg + ": " + e.err.Error() }
+func (e *fileUploadError) Unwrap() error { return e.err }
+
+// saveUpload 将上传文件保存到默认存储并写入 sys_file，供文件管理、头像上传等场景复用。
+// 存储中已有相同内容的对象时直接引用该对象，不重复写入。
+func (h *FileHandler) saveUpload(ctx context.Context, header *multipart.FileHeader, parentPath string, userID int64) (*FileUploadResp, error) {
+	storageCfg, err := h.getDefaultStorage(ctx)
+	if err != nil {
+		return nil, &fileUploadError{msg: "获取存储配置失败", err: err}
+	}
+	driver, err := h.storages.Driver(storageCfg)
+	if err != nil {
+		return nil, &fileUploadError{msg: "获取存储配置失败", err: err}
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		return nil, &fileUploadError{msg: "保存文件失败", err: err}
+	}
+	defer src.Close()
+	sha, size, err := sha256Hex(src)
+	if err != nil {
+		return nil, &fileUploadError{msg: "保存文件失败", err: err}
+	}
+
 	ext := extensionFromFilename(header.Filename)
+	contentType := header.Header.Get("Content-Type")
+	f := &uploadedFile{
+		originalName: header.Filename,
+		parentPath:   parentPath,
+		ext:          ext,
+		contentType:  contentType,
+		sha256:       sha,
+		size:         size,
+	}
+
+	blob, err := findBlob(ctx, h.db, storageCfg.ID, sha, size)
+	if err != nil {
+		return nil, &fileUploadError{msg: "保存文件失败", err: err}
+	}
+	// 配额校验：复用已有对象时不占用存储空间
+	if err := checkUserQuota(ctx, h.db, userID, size); err != nil {
+		return nil, &fileUploadError{msg: "校验存储配额失败", err: err}
+	}
+	if blob == nil {
+		if err := checkStorageQuota(ctx, h.db, storageCfg, size); err != nil {
+			return nil, &fileUploadError{msg: "校验存储配额失败", err: err}
+		}
+	}
+	if blob != nil {
+		reuse := *f
+		reuse.useBlob(blob)
+		resp, err := h.saveFileRecord(ctx, storageCfg, &reuse, userID)
+		if err == nil {
+			return resp, nil
+		}
+		if !errors.Is(err, errBlobReleased) {
+			return nil, &fileUploadError{msg: "保存文件记录失败", err: err}
+		}
+		// 复用的对象刚被删除，改为写入新对象
+	}
+
 	newID := id.Next()
-	var storedName string
 	if ext != "" {
-		storedName = fmt.Sprintf("%d.%s", newID, ext)
+		f.name = fmt.Sprintf("%d.%s", newID, ext)
 	} else {
-		storedName = fmt.Sprintf("%d", newID)
+		f.name = fmt.Sprintf("%d", newID)
+	}
+	f.path = joinStoragePath(parentPath, f.name)
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, &fileUploadError{msg: "保存文件失败", err: err}
+	}
+	if err := driver.Put(ctx, f.path, src, size, contentType); err != nil {
+		return nil, &fileUploadError{msg: "保存文件失败", err: err}
+	}
+	// 图片生成缩略图，失败时不影响上传（与 Java 忽略缩略图异常一致）
+	if supportsThumbnail(ext) {
+		if _, err := src.Seek(0, io.SeekStart); err == nil {
+			if thumb, err := putThumbnail(ctx, driver, src, ext, f.path); err == nil {
+				f.setThumbnail(thumb)
+			}
+		}
 	}
 
-	// 根据默认存储类型，分别保存到本地或 MinIO。
-	ctx := c.Request.Context()
-	storageCfg, err := h.getDefaultStorage(ctx)
+	resp, err := h.saveFileRecord(ctx, storageCfg, f, userID)
 	if err != nil {
-		Fail(c, "500", "获取存储配置失败")
-		return
+		return nil, &fileUploadError{msg: "保存文件记录失败", err: err}
 	}
+	return resp, nil
+}
 
-	var (
-		fullPath    string
-		sha         string
-		size        int64
-		contentType string
-	)
-	switch storageCfg.Type {
-	case storageTypeOSS:
-		fullPath, sha, size, contentType, err = saveToMinIO(ctx, header, storageCfg, parentPath, storedName)
-	default:
-		fullPath, sha, size, contentType, err = saveToLocal(header, storageCfg.BucketName, parentPath, storedName)
+// uploadedFile 为已写入存储、待登记到 sys_file 的文件。
+// blobID 非 0 时表示复用已有对象，否则为新写入的对象。
+type uploadedFile struct {
+	id           int64
+	blobID       int64
+	name         string
+	originalName string
+	parentPath   string
+	path         string
+	ext          string
+	contentType  string
+	sha256       string
+	size         int64
+	metadata     string
+	thumbName    string
+	thumbSize    *int64
+	thumbMeta    string
+}
+
+// setThumbnail 记录缩略图信息及原图尺寸。
+func (f *uploadedFile) setThumbnail(thumb *fileThumbnail) {
+	f.metadata = marshalFileMetadata(thumb.SourceMetadata())
+	f.thumbName = thumb.Name
+	f.thumbSize = &thumb.Size
+	f.thumbMeta = marshalFileMetadata(thumb.Metadata())
+}
+
+// saveFileRecord 登记对象引用并写入 sys_file 记录，返回上传响应。
+// 新写入的对象在登记失败时删除，与并发上传的相同内容重复时改为引用已有对象并删除多余对象。
+func (h *FileHandler) saveFileRecord(ctx context.Context, storageCfg *storage.Config, f *uploadedFile, userID int64) (*FileUploadResp, error) {
+	var written []fileBlob
+	if f.blobID == 0 {
+		written = []fileBlob{{StorageID: storageCfg.ID, Path: f.path, ThumbName: f.thumbName}}
 	}
+
+	redundant, err := h.insertFileRecord(ctx, storageCfg, f, userID)
 	if err != nil {
-		Fail(c, "500", "保存文件失败")
-		return
+		h.removeBlobObjects(ctx, written)
+		return nil, err
+	}
+	if redundant != nil {
+		h.removeBlobObjects(ctx, []fileBlob{*redundant})
 	}
 
-	now := time.Now()
-	fileType := detectFileType(ext, contentType)
+	url := buildFileAccessURL(storageCfg, f.id, f.path)
+	thumbURL := url
+	if f.thumbName != "" {
+		thumbURL = buildFileThumbnailURL(storageCfg, f.id, thumbnailStoragePath(f.path, f.thumbName))
+	}
+	return &FileUploadResp{
+		ID:       strconv.FormatInt(f.id, 10),
+		URL:      url,
+		ThumbURL: thumbURL,
+		Metadata: parseFileMetadata(f.metadata),
+	}, nil
+}
+
+func (h *FileHandler) insertFileRecord(ctx context.Context, storageCfg *storage.Config, f *uploadedFile, userID int64) (*fileBlob, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 上传前的配额校验未加锁，同一用户的并发上传可能同时通过：此处按用户串行登记并复核配额。
+	if err := lockUserQuota(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	if err := checkUserQuota(ctx, tx, userID, f.size); err != nil {
+		return nil, err
+	}
+	newBlob := f.blobID == 0
+	redundant, err := acquireBlob(ctx, tx, storageCfg.ID, f)
+	if err != nil {
+		return nil, err
+	}
+	// 新登记的对象已计入存储用量
+	if newBlob && redundant == nil {
+		if err := checkStorageQuota(ctx, tx, storageCfg, 0); err != nil {
+			return nil, err
+		}
+	}
 
 	const insertSQL = `
 INSERT INTO sys_file (
     id, name, original_name, size, parent_path, path, extension, content_type,
     type, sha256, metadata, thumbnail_name, thumbnail_size, thumbnail_metadata,
-    storage_id, create_user, create_time
+    storage_id, blob_id, create_user, create_time
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7, $8,
     $9, $10, $11, $12, $13, $14,
-    $15, $16, $17
+    $15, $16, $17, $18
 );`
 
-	fileID := id.Next()
-	var meta string
-	_, err = h.db.ExecContext(
-		c.Request.Context(),
+	f.id = id.Next()
+	_, err = tx.ExecContext(
+		ctx,
 		insertSQL,
-		fileID,
-		storedName,
-		header.Filename,
-		size,
-		normalizeParentPath(parentPath),
-		fullPath,
-		ext,
-		contentType,
-		fileType,
-		sha,
-		meta,
-		"",
-		nil,
-		"",
+		f.id,
+		f.name,
+		f.originalName,
+		f.size,
+		normalizeParentPath(f.parentPath),
+		f.path,
+		f.ext,
+		f.contentType,
+		detectFileType(f.ext, f.contentType),
+		f.sha256,
+		f.metadata,
+		f.thumbName,
+		f.thumbSize,
+		f.thumbMeta,
 		storageCfg.ID,
+		f.blobID,
 		userID,
-		now,
+		time.Now(),
 	)
 	if err != nil {
-		Fail(c, "500", "保存文件记录失败")
-		return
+		return nil, err
 	}
-
-	url := buildStorageFileURL(storageCfg, fullPath)
-	resp := FileUploadResp{
-		ID:       strconv.FormatInt(fileID, 10),
-		URL:      url,
-		ThumbURL: url,
-		Metadata: map[string]string{},
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
-	OK(c, resp)
+	return redundant, nil
 }
 
 // ListFile handles GET /system/file (paged).
@@ -682,7 +757,7 @@ LIMIT $%d OFFSET $%d;
 			item.UpdateTime = formatTime(updateTimeVal.Time)
 		}
 		// 填充存储名称和访问 URL
-		var storageCfg *StorageConfig
+		var storageCfg *storage.Config
 		if item.StorageID > 0 {
 			storageCfg, _ = h.getStorageByID(c.Request.Context(), item.StorageID)
 		}
@@ -691,14 +766,10 @@ LIMIT $%d OFFSET $%d;
 		} else {
 			item.StorageName = "本地存储"
 		}
-		item.URL = buildStorageFileURL(storageCfg, item.Path)
+		item.URL = buildFileAccessURL(storageCfg, item.ID, item.Path)
 		if item.ThumbnailName != "" {
-			parent := item.ParentPath
-			if parent == "/" {
-				parent = ""
-			}
-			thumbPath := parent + "/" + item.ThumbnailName
-			item.ThumbnailURL = buildStorageFileURL(storageCfg, thumbPath)
+			thumbPath := thumbnailStoragePath(item.Path, item.ThumbnailName)
+			item.ThumbnailURL = buildFileThumbnailURL(storageCfg, item.ID, thumbPath)
 		} else {
 			item.ThumbnailURL = item.URL
 		}
@@ -818,14 +889,14 @@ WHERE id = $1;
 		return
 	}
 
-	const sumSQL = `
+	// 按 parent_path 统计：文件的 path 为对象位置，移动后不再位于文件夹路径下
+	sumSQL := `
 SELECT COALESCE(SUM(size), 0)
 FROM sys_file
-WHERE type <> 0 AND path LIKE $1;
+WHERE type <> 0 AND ` + descendantCond(1) + `;
 `
 	var total int64
-	prefix := strings.TrimRight(path, "/") + "/%"
-	if err := h.db.QueryRowContext(c.Request.Context(), sumSQL, prefix).Scan(&total); err != nil {
+	if err := h.db.QueryRowContext(c.Request.Context(), sumSQL, path).Scan(&total); err != nil {
 		Fail(c, "500", "计算文件夹大小失败")
 		return
 	}
@@ -962,7 +1033,7 @@ LIMIT 1;
 	if updateTimeVal.Valid {
 		item.UpdateTime = formatTime(updateTimeVal.Time)
 	}
-	var storageCfg *StorageConfig
+	var storageCfg *storage.Config
 	if item.StorageID > 0 {
 		storageCfg, _ = h.getStorageByID(c.Request.Context(), item.StorageID)
 	}
@@ -971,14 +1042,10 @@ LIMIT 1;
 	} else {
 		item.StorageName = "本地存储"
 	}
-	item.URL = buildStorageFileURL(storageCfg, item.Path)
+	item.URL = buildFileAccessURL(storageCfg, item.ID, item.Path)
 	if item.ThumbnailName != "" {
-		parent := item.ParentPath
-		if parent == "/" {
-			parent = ""
-		}
-		thumbPath := parent + "/" + item.ThumbnailName
-		item.ThumbnailURL = buildStorageFileURL(storageCfg, thumbPath)
+		thumbPath := thumbnailStoragePath(item.Path, item.ThumbnailName)
+		item.ThumbnailURL = buildFileThumbnailURL(storageCfg, item.ID, thumbPath)
 	} else {
 		item.ThumbnailURL = item.URL
 	}
@@ -1026,15 +1093,19 @@ UPDATE sys_file
 	OK(c, true)
 }
 
-// DeleteFile handles DELETE /system/file.
+// DeleteFile handles DELETE /system/file，文件及文件夹移入回收站。
+// 非空文件夹需传 recursive=true 确认，其下全部内容一并移入回收站。
 func (h *FileHandler) DeleteFile(c *gin.Context) {
 	userID := h.currentUserID(c)
 	if userID == 0 {
 		return
 	}
-	_ = userID
 
-	var req idsRequest
+	var req struct {
+		IDs []int64 `json:"ids"`
+		// Recursive 确认删除非空文件夹及其下全部内容
+		Recursive bool `json:"recursive"`
+	}
 	if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) == 0 {
 		Fail(c, "400", "ID 列表不能为空")
 		return
@@ -1056,8 +1127,7 @@ func (h *FileHandler) DeleteFile(c *gin.Context) {
 		storageID int64
 	}
 
-	var toDeleteFiles []fileRow
-
+	ids := req.IDs
 	for _, idVal := range req.IDs {
 		var row fileRow
 		const selectSQL = `
@@ -1081,28 +1151,23 @@ WHERE id = $1;
 		}
 
 		if row.fileType == 0 {
-			// Directory: ensure it's empty.
-			const childSQL = `
-SELECT 1 FROM sys_file
-WHERE parent_path = $1
-LIMIT 1;
-`
-			var dummy int
-			if err := tx.QueryRowContext(c.Request.Context(), childSQL, row.path).Scan(&dummy); err != nil && err != sql.ErrNoRows {
+			// Directory: ensure it's empty unless recursive deletion is confirmed.
+			childSQL := `SELECT id FROM sys_file WHERE ` + descendantCond(1) + `;`
+			childIDs, err := queryIDs(c.Request.Context(), tx, childSQL, row.path)
+			if err != nil {
 				Fail(c, "500", "删除文件失败")
 				return
-			} else if err == nil {
+			}
+			if len(childIDs) > 0 && !req.Recursive {
 				Fail(c, "400", fmt.Sprintf("文件夹 [%s] 不为空，请先删除文件夹下的内容", row.name))
 				return
 			}
-		} else {
-			toDeleteFiles = append(toDeleteFiles, row)
+			ids = append(ids, childIDs...)
 		}
 	}
 
-	// Delete DB records.
-	const deleteSQL = `DELETE FROM sys_file WHERE id = ANY($1);`
-	if _, err := tx.ExecContext(c.Request.Context(), deleteSQL, pq.Int64Array(req.IDs)); err != nil {
+	// Move DB records to the recycle bin; objects are kept until purged.
+	if err := moveToRecycle(c.Request.Context(), tx, ids, userID); err != nil {
 		Fail(c, "500", "删除文件失败")
 		return
 	}
@@ -1112,48 +1177,123 @@ LIMIT 1;
 		return
 	}
 
-	// Best-effort deletion of物理文件（本地）或对象存储文件（MinIO）。
-	for _, f := range toDeleteFiles {
-		if f.path == "" {
-			continue
+	OK(c, true)
+}
+
+// removeStoredObject 尽力删除存储中的文件，失败时忽略。
+func (h *FileHandler) removeStoredObject(ctx context.Context, storageID int64, path string) {
+	if path == "" {
+		return
+	}
+	storageCfg, err := h.getStorageByID(ctx, storageID)
+	if err != nil || storageCfg == nil {
+		return
+	}
+	driver, err := h.storages.Driver(storageCfg)
+	if err != nil {
+		return
+	}
+	_ = driver.Delete(ctx, path)
+}
+
+// recycleFileByURL 将某用户在指定目录下上传、访问地址为 url 的文件移入回收站。
+// 用于替换头像时回收旧头像，目录中的其他文件不受影响。
+func (h *FileHandler) recycleFileByURL(ctx context.Context, parentPath string, userID int64, url string) error {
+	if url == "" {
+		return nil
+	}
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const query = `
+SELECT id, path, storage_id
+FROM sys_file
+WHERE parent_path = $1 AND create_user = $2 AND type <> 0
+FOR UPDATE;
+`
+	rows, err := tx.QueryContext(ctx, query, normalizeParentPath(parentPath), userID)
+	if err != nil {
+		return err
+	}
+	type fileRow struct {
+		id        int64
+		path      string
+		storageID int64
+	}
+	var files []fileRow
+	for rows.Next() {
+		var f fileRow
+		if err := rows.Scan(&f.id, &f.path, &f.storageID); err != nil {
+			rows.Close()
+			return err
+		}
+		files = append(files, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var ids []int64
+	for _, f := range files {
+		cfg, err := h.storageForFile(ctx, f.storageID)
+		if err != nil {
+			return err
 		}
-		storageCfg, err := h.getStorageByID(c.Request.Context(), f.storageID)
-		if err != nil || storageCfg == nil {
-			continue
+		if buildFileAccessURL(cfg, f.id, f.path) == url {
+			ids = append(ids, f.id)
 		}
-		switch storageCfg.Type {
-		case storageTypeOSS:
-			// 删除 MinIO 对象
-			endpoint := storageCfg.Endpoint
-			secure := false
-			if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
-				u, parseErr := url.Parse(endpoint)
-				if parseErr != nil {
-					continue
-				}
-				secure = u.Scheme == "https"
-				endpoint = u.Host
-			}
-			client, err := minio.New(endpoint, &minio.Options{
-				Creds:  credentials.NewStaticV4(storageCfg.AccessKey, storageCfg.SecretKey, ""),
-				Secure: secure,
-			})
-			if err != nil {
-				continue
-			}
-			objectName := strings.TrimPrefix(f.path, "/")
-			_ = client.RemoveObject(c.Request.Context(), storageCfg.BucketName, objectName, minio.RemoveObjectOptions{})
-		default:
-			// 本地删除
-			rel := strings.TrimPrefix(f.path, "/")
-			bucket := storageCfg.BucketName
-			if strings.TrimSpace(bucket) == "" {
-				bucket = "./data/file"
-			}
-			abs := filepath.Join(bucket, filepath.FromSlash(rel))
-			_ = os.Remove(abs)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := moveToRecycle(ctx, tx, ids, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// queryIDs 执行查询并返回第一列的 ID 列表。
+func queryIDs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var idVal int64
+		if err := rows.Scan(&idVal); err != nil {
+			return nil, err
 		}
+		ids = append(ids, idVal)
 	}
+	return ids, rows.Err()
+}
 
-	OK(c, true)
+// deleteFileRows 执行 DELETE ... RETURNING blob_id，返回被删除文件引用的对象（文件夹没有对象）及删除的记录数。
+func deleteFileRows(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]int64, int, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var (
+		blobIDs []int64
+		n       int
+	)
+	for rows.Next() {
+		var blobID sql.NullInt64
+		if err := rows.Scan(&blobID); err != nil {
+			return nil, 0, err
+		}
+		n++
+		if blobID.Valid {
+			blobIDs = append(blobIDs, blobID.Int64)
+		}
+	}
+	return blobIDs, n, rows.Err()
 }
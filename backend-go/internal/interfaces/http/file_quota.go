@@ -0,0 +1,318 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+
+	"voc-go-backend/internal/infrastructure/id"
+	"voc-go-backend/internal/infrastructure/storage"
+)
+
+// 存储配额：
+//   - 存储配额为 sys_storage.quota，按存储中实际保存的对象（去重后，含缩略图与回收站）计算；
+//   - 用户配额在 sys_file_quota 中按用户或角色配置，按用户上传的文件大小（含回收站）计算。
+//     优先使用用户配置；否则取用户所属角色中最宽松的配置（任一角色为 0 即不限制）；均未配置时不限制。
+//
+// 配额均为字节数，0 表示不限制。上传写入存储前校验，秒传或复用已有对象时不占用存储配额。
+const (
+	fileQuotaTargetRole int16 = 1
+	fileQuotaTargetUser int16 = 2
+)
+
+// fileQuotaError 上传将超出配额。
+type fileQuotaError struct{ msg string }
+
+func (e *fileQuotaError) Error() string { return e.msg }
+
+// FileQuotaUsage 为配额及已使用空间（字节），Quota 为 0 表示不限制。
+type FileQuotaUsage struct {
+	Quota int64 `json:"quota"`
+	Used  int64 `json:"used"`
+}
+
+// FileUsageResp 对应 GET /system/file/usage：当前用户及默认存储的配额使用情况。
+type FileUsageResp struct {
+	User        FileQuotaUsage `json:"user"`
+	Storage     FileQuotaUsage `json:"storage"`
+	StorageID   int64          `json:"storageId"`
+	StorageName string         `json:"storageName"`
+}
+
+// FileQuotaItem 为一条角色或用户配额配置。
+type FileQuotaItem struct {
+	ID         int64  `json:"id"`
+	TargetType int16  `json:"targetType"`
+	TargetID   int64  `json:"targetId"`
+	TargetName string `json:"targetName"`
+	Quota      int64  `json:"quota"`
+	// Used 仅用户配额返回已使用空间
+	Used             int64  `json:"used"`
+	CreateUserString string `json:"createUserString"`
+	CreateTime       string `json:"createTime"`
+	UpdateUserString string `json:"updateUserString"`
+	UpdateTime       string `json:"updateTime"`
+}
+
+// fileQuotaReq 对应 POST /system/file/quota，同一角色或用户已有配置时覆盖。
+type fileQuotaReq struct {
+	TargetType int16 `json:"targetType"`
+	TargetID   int64 `json:"targetId"`
+	Quota      int64 `json:"quota"`
+}
+
+// userFileQuota 返回用户的有效配额。
+func userFileQuota(ctx context.Context, q rowQueryer, userID int64) (int64, error) {
+	const query = `
+SELECT COALESCE(
+    (SELECT quota FROM sys_file_quota WHERE target_type = $2 AND target_id = $1),
+    (SELECT CASE WHEN bool_or(q.quota = 0) THEN 0 ELSE MAX(q.quota) END
+     FROM sys_file_quota AS q
+     JOIN sys_user_role AS ur ON ur.role_id = q.target_id
+     WHERE q.target_type = $3 AND ur.user_id = $1),
+    0
+);
+`
+	var quota int64
+	err := q.QueryRowContext(ctx, query, userID, fileQuotaTargetUser, fileQuotaTargetRole).Scan(&quota)
+	return quota, err
+}
+
+// userFileUsage 返回用户上传文件的总大小（含回收站）。
+func userFileUsage(ctx context.Context, q rowQueryer, userID int64) (int64, error) {
+	const query = `
+SELECT (SELECT COALESCE(SUM(size), 0) FROM sys_file WHERE create_user = $1 AND type <> 0)
+     + (SELECT COALESCE(SUM(size), 0) FROM sys_file_recycle WHERE create_user = $1 AND type <> 0);
+`
+	var used int64
+	err := q.QueryRowContext(ctx, query, userID).Scan(&used)
+	return used, err
+}
+
+// storageUsage 返回存储的配额及已保存对象（含缩略图）的总大小。
+func storageUsage(ctx context.Context, q rowQueryer, storageID int64) (FileQuotaUsage, error) {
+	const query = `
+SELECT COALESCE((SELECT quota FROM sys_storage WHERE id = $1), 0),
+       COALESCE((SELECT SUM(size + COALESCE(thumbnail_size, 0)) FROM sys_file_blob WHERE storage_id = $1), 0);
+`
+	var u FileQuotaUsage
+	err := q.QueryRowContext(ctx, query, storageID).Scan(&u.Quota, &u.Used)
+	return u, err
+}
+
+// lockUserQuota 在事务中按用户加锁，同一用户的配额校验与文件登记串行执行直至事务结束。
+// 应在 acquireBlob 之前调用，保持先用户锁、后对象锁的顺序。
+func lockUserQuota(ctx context.Context, tx *sql.Tx, userID int64) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('sys_file_quota:' || $1));`, userID)
+	return err
+}
+
+// checkUserQuota 校验用户再上传 size 字节是否超出配额。
+// 在事务中调用时，已在该事务中写入的记录一并计入。
+func checkUserQuota(ctx context.Context, q rowQueryer, userID, size int64) error {
+	quota, err := userFileQuota(ctx, q, userID)
+	if err != nil || quota <= 0 {
+		return err
+	}
+	used, err := userFileUsage(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+	if used+size > quota {
+		return &fileQuotaError{msg: fmt.Sprintf("个人存储空间不足，已使用 %s，配额 %s", formatFileSize(used), formatFileSize(quota))}
+	}
+	return nil
+}
+
+// checkStorageQuota 校验存储再写入 size 字节是否超出配额。
+func checkStorageQuota(ctx context.Context, q rowQueryer, cfg *storage.Config, size int64) error {
+	u, err := storageUsage(ctx, q, cfg.ID)
+	if err != nil || u.Quota <= 0 {
+		return err
+	}
+	if u.Used+size > u.Quota {
+		return &fileQuotaError{msg: fmt.Sprintf("存储 [%s] 空间不足，已使用 %s，配额 %s", cfg.Name, formatFileSize(u.Used), formatFileSize(u.Quota))}
+	}
+	return nil
+}
+
+// formatFileSize 将字节数格式化为便于阅读的形式，如 1.5 GB。
+func formatFileSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit && exp < 4; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTP"[exp])
+}
+
+// GetFileUsage handles GET /system/file/usage，返回当前用户及默认存储的配额使用情况。
+func (h *FileHandler) GetFileUsage(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := contextUserID(c)
+
+	var (
+		resp FileUsageResp
+		err  error
+	)
+	if resp.User.Quota, err = userFileQuota(ctx, h.db, userID); err != nil {
+		Fail(c, "500", "查询存储配额失败")
+		return
+	}
+	if resp.User.Used, err = userFileUsage(ctx, h.db, userID); err != nil {
+		Fail(c, "500", "查询存储配额失败")
+		return
+	}
+	storageCfg, err := h.getDefaultStorage(ctx)
+	if err != nil {
+		Fail(c, "500", "获取存储配置失败")
+		return
+	}
+	if resp.Storage, err = storageUsage(ctx, h.db, storageCfg.ID); err != nil {
+		Fail(c, "500", "查询存储配额失败")
+		return
+	}
+	resp.StorageID = storageCfg.ID
+	resp.StorageName = storageCfg.Name
+	OK(c, resp)
+}
+
+// ListFileQuota handles GET /system/file/quota，可按 targetType 筛选。
+func (h *FileHandler) ListFileQuota(c *gin.Context) {
+	targetType, _ := strconv.Atoi(strings.TrimSpace(c.Query("targetType")))
+
+	const query = `
+SELECT q.id,
+       q.target_type,
+       q.target_id,
+       COALESCE(CASE WHEN q.target_type = 1 THEN r.name ELSE u.nickname END, ''),
+       q.quota,
+       CASE WHEN q.target_type = 2 THEN
+           (SELECT COALESCE(SUM(size), 0) FROM sys_file WHERE create_user = q.target_id AND type <> 0)
+         + (SELECT COALESCE(SUM(size), 0) FROM sys_file_recycle WHERE create_user = q.target_id AND type <> 0)
+       ELSE 0 END,
+       q.create_time,
+       COALESCE(cu.nickname, ''),
+       q.update_time,
+       COALESCE(uu.nickname, '')
+FROM sys_file_quota AS q
+LEFT JOIN sys_role AS r ON q.target_type = 1 AND r.id = q.target_id
+LEFT JOIN sys_user AS u ON q.target_type = 2 AND u.id = q.target_id
+LEFT JOIN sys_user AS cu ON cu.id = q.create_user
+LEFT JOIN sys_user AS uu ON uu.id = q.update_user
+WHERE $1 = 0 OR q.target_type = $1
+ORDER BY q.target_type, q.id;
+`
+	rows, err := h.db.QueryContext(c.Request.Context(), query, targetType)
+	if err != nil {
+		Fail(c, "500", "查询存储配额失败")
+		return
+	}
+	defer rows.Close()
+
+	list := []FileQuotaItem{}
+	for rows.Next() {
+		var (
+			item       FileQuotaItem
+			createTime time.Time
+			updateTime sql.NullTime
+		)
+		if err := rows.Scan(
+			&item.ID,
+			&item.TargetType,
+			&item.TargetID,
+			&item.TargetName,
+			&item.Quota,
+			&item.Used,
+			&createTime,
+			&item.CreateUserString,
+			&updateTime,
+			&item.UpdateUserString,
+		); err != nil {
+			Fail(c, "500", "查询存储配额失败")
+			return
+		}
+		item.CreateTime = formatTime(createTime)
+		if updateTime.Valid {
+			item.UpdateTime = formatTime(updateTime.Time)
+		}
+		list = append(list, item)
+	}
+	if err := rows.Err(); err != nil {
+		Fail(c, "500", "查询存储配额失败")
+		return
+	}
+	OK(c, list)
+}
+
+// SaveFileQuota handles POST /system/file/quota。
+func (h *FileHandler) SaveFileQuota(c *gin.Context) {
+	userID := h.currentUserID(c)
+	if userID == 0 {
+		return
+	}
+	var req fileQuotaReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Fail(c, "400", "请求参数不正确")
+		return
+	}
+	if req.TargetType != fileQuotaTargetRole && req.TargetType != fileQuotaTargetUser {
+		Fail(c, "400", "配额类型不正确")
+		return
+	}
+	if req.Quota < 0 {
+		Fail(c, "400", "配额不能小于 0")
+		return
+	}
+	ctx := c.Request.Context()
+
+	targetSQL := `SELECT EXISTS (SELECT 1 FROM sys_role WHERE id = $1);`
+	if req.TargetType == fileQuotaTargetUser {
+		targetSQL = `SELECT EXISTS (SELECT 1 FROM sys_user WHERE id = $1);`
+	}
+	var exists bool
+	if err := h.db.QueryRowContext(ctx, targetSQL, req.TargetID).Scan(&exists); err != nil {
+		Fail(c, "500", "保存存储配额失败")
+		return
+	}
+	if !exists {
+		Fail(c, "400", "角色或用户不存在")
+		return
+	}
+
+	const upsertSQL = `
+INSERT INTO sys_file_quota (id, target_type, target_id, quota, create_user, create_time)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (target_type, target_id) DO UPDATE
+SET quota = EXCLUDED.quota, update_user = EXCLUDED.create_user, update_time = EXCLUDED.create_time;
+`
+	if _, err := h.db.ExecContext(ctx, upsertSQL, id.Next(), req.TargetType, req.TargetID, req.Quota, userID, time.Now()); err != nil {
+		Fail(c, "500", "保存存储配额失败")
+		return
+	}
+	OK(c, true)
+}
+
+// DeleteFileQuota handles DELETE /system/file/quota，删除后按角色配置或不限制。
+func (h *FileHandler) DeleteFileQuota(c *gin.Context) {
+	var req idsRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) == 0 {
+		Fail(c, "400", "ID 列表不能为空")
+		return
+	}
+	if _, err := h.db.ExecContext(c.Request.Context(), `DELETE FROM sys_file_quota WHERE id = ANY($1);`, pq.Int64Array(req.IDs)); err != nil {
+		Fail(c, "500", "删除存储配额失败")
+		return
+	}
+	OK(c, true)
+}
@@ -0,0 +1,427 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+
+	"voc-go-backend/internal/infrastructure/id"
+)
+
+// 回收站：DeleteFile 将文件及空文件夹移入 sys_file_recycle，对象引用随记录保留；
+// 还原时移回 sys_file（缺失的上级文件夹自动创建），彻底删除、清空回收站或超过保留期被清理时
+// 才释放对象引用并删除物理文件。
+const (
+	// optionFileRecycleRetentionDays 回收站保留天数（sys_option，category FILE），0 表示不自动清理。
+	optionFileRecycleRetentionDays  = "FILE_RECYCLE_RETENTION_DAYS"
+	defaultFileRecycleRetentionDays = 30
+	// fileRecyclePurgeBatch 后台清理每批删除的记录数。
+	fileRecyclePurgeBatch = 200
+)
+
+// sysFileColumns 为 sys_file 与 sys_file_recycle 共有的列。
+const sysFileColumns = `id, name, original_name, size, parent_path, path, extension, content_type,
+    type, sha256, metadata, thumbnail_name, thumbnail_size, thumbnail_metadata,
+    storage_id, blob_id, create_user, create_time, update_user, update_time`
+
+// FileRecycleItem 回收站中的文件或文件夹。
+type FileRecycleItem struct {
+	FileItem
+	DeleteUserString string `json:"deleteUserString"`
+	DeleteTime       string `json:"deleteTime"`
+}
+
+// moveToRecycle 在事务中将文件移入回收站。
+func moveToRecycle(ctx context.Context, tx *sql.Tx, ids []int64, userID int64) error {
+	const insertSQL = `
+INSERT INTO sys_file_recycle (` + sysFileColumns + `, delete_user, delete_time)
+SELECT ` + sysFileColumns + `, $2, $3
+FROM sys_file
+WHERE id = ANY($1);
+`
+	if _, err := tx.ExecContext(ctx, insertSQL, pq.Int64Array(ids), userID, time.Now()); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `DELETE FROM sys_file WHERE id = ANY($1);`, pq.Int64Array(ids))
+	return err
+}
+
+// ListRecycle handles GET /system/file/recycle (paged)，按删除时间倒序。
+func (h *FileHandler) ListRecycle(c *gin.Context) {
+	originalName := strings.TrimSpace(c.Query("originalName"))
+	typeStr := strings.TrimSpace(c.Query("type"))
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "30"))
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = 30
+	}
+
+	where := "WHERE 1=1"
+	args := []any{}
+	argPos := 1
+	if originalName != "" {
+		where += fmt.Sprintf(" AND r.original_name ILIKE $%d", argPos)
+		args = append(args, "%"+originalName+"%")
+		argPos++
+	}
+	if typeStr != "" && typeStr != "0" {
+		if t, err := strconv.Atoi(typeStr); err == nil && t > 0 {
+			where += fmt.Sprintf(" AND r.type = $%d", argPos)
+			args = append(args, t)
+			argPos++
+		}
+	}
+
+	ctx := c.Request.Context()
+	var total int64
+	if err := h.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sys_file_recycle AS r "+where, args...).Scan(&total); err != nil {
+		Fail(c, "500", "查询回收站失败")
+		return
+	}
+	if total == 0 {
+		OK(c, PageResult[FileRecycleItem]{List: []FileRecycleItem{}, Total: 0})
+		return
+	}
+
+	args = append(args, int64(size), int64((page-1)*size))
+	query := fmt.Sprintf(`
+SELECT r.id,
+       r.name,
+       r.original_name,
+       r.size,
+       r.parent_path,
+       r.path,
+       COALESCE(r.extension, ''),
+       COALESCE(r.content_type, ''),
+       r.type,
+       COALESCE(r.sha256, ''),
+       COALESCE(r.metadata, ''),
+       COALESCE(r.thumbnail_name, ''),
+       r.thumbnail_size,
+       COALESCE(r.thumbnail_metadata, ''),
+       r.storage_id,
+       COALESCE(s.name, '本地存储'),
+       r.create_time,
+       COALESCE(cu.nickname, ''),
+       r.delete_time,
+       COALESCE(du.nickname, '')
+FROM sys_file_recycle AS r
+LEFT JOIN sys_storage AS s ON s.id = r.storage_id
+LEFT JOIN sys_user AS cu ON cu.id = r.create_user
+LEFT JOIN sys_user AS du ON du.id = r.delete_user
+%s
+ORDER BY r.delete_time DESC, r.id DESC
+LIMIT $%d OFFSET $%d;
+`, where, argPos, argPos+1)
+
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		Fail(c, "500", "查询回收站失败")
+		return
+	}
+	defer rows.Close()
+
+	list := make([]FileRecycleItem, 0, size)
+	for rows.Next() {
+		var (
+			item         FileRecycleItem
+			sizeVal      sql.NullInt64
+			thumbSizeVal sql.NullInt64
+			createTime   time.Time
+			deleteTime   time.Time
+		)
+		if err := rows.Scan(
+			&item.ID,
+			&item.Name,
+			&item.OriginalName,
+			&sizeVal,
+			&item.ParentPath,
+			&item.Path,
+			&item.Extension,
+			&item.ContentType,
+			&item.Type,
+			&item.Sha256,
+			&item.Metadata,
+			&item.ThumbnailName,
+			&thumbSizeVal,
+			&item.ThumbnailMeta,
+			&item.StorageID,
+			&item.StorageName,
+			&createTime,
+			&item.CreateUserString,
+			&deleteTime,
+			&item.DeleteUserString,
+		); err != nil {
+			Fail(c, "500", "查询回收站失败")
+			return
+		}
+		if sizeVal.Valid {
+			v := sizeVal.Int64
+			item.Size = &v
+		}
+		if thumbSizeVal.Valid {
+			v := thumbSizeVal.Int64
+			item.ThumbnailSize = &v
+		}
+		item.CreateTime = createTime.Format("2006-01-02 15:04:05")
+		item.DeleteTime = deleteTime.Format("2006-01-02 15:04:05")
+		list = append(list, item)
+	}
+	if err := rows.Err(); err != nil {
+		Fail(c, "500", "查询回收站失败")
+		return
+	}
+	OK(c, PageResult[FileRecycleItem]{List: list, Total: total})
+}
+
+// RestoreRecycle handles POST /system/file/recycle/restore.
+// 还原到原目录，原目录已被删除时自动重新创建；同名文件夹已存在时合并到该文件夹。
+// 还原文件夹时同时还原与其一起删除的下级内容。
+func (h *FileHandler) RestoreRecycle(c *gin.Context) {
+	var req idsRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) == 0 {
+		Fail(c, "400", "ID 列表不能为空")
+		return
+	}
+	userID := h.currentUserID(c)
+	if userID == 0 {
+		return
+	}
+	ctx := c.Request.Context()
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		Fail(c, "500", "还原文件失败")
+		return
+	}
+	defer tx.Rollback()
+
+	// 还原文件夹时一并还原同一次删除中移入回收站的下级内容，上级文件夹在前
+	const expandSQL = `
+SELECT r.id
+FROM sys_file_recycle AS r
+WHERE r.id = ANY($1)
+   OR EXISTS (
+       SELECT 1 FROM sys_file_recycle AS d
+       WHERE d.id = ANY($1) AND d.type = 0 AND d.delete_time = r.delete_time
+         AND (r.parent_path = d.path OR left(r.parent_path, char_length(d.path) + 1) = d.path || '/')
+   )
+ORDER BY r.type, char_length(r.parent_path), r.id;
+`
+	ids, err := queryIDs(ctx, tx, expandSQL, pq.Int64Array(req.IDs))
+	if err != nil {
+		Fail(c, "500", "还原文件失败")
+		return
+	}
+
+	for _, idVal := range ids {
+		var (
+			parentPath, fullPath string
+			fileType             int16
+		)
+		const selectSQL = `SELECT parent_path, path, type FROM sys_file_recycle WHERE id = $1 FOR UPDATE;`
+		if err := tx.QueryRowContext(ctx, selectSQL, idVal).Scan(&parentPath, &fullPath, &fileType); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			Fail(c, "500", "还原文件失败")
+			return
+		}
+		if err := ensureDirPath(ctx, tx, parentPath, userID); err != nil {
+			Fail(c, "500", "还原文件失败")
+			return
+		}
+
+		merge := false
+		if fileType == 0 {
+			const existsSQL = `SELECT EXISTS (SELECT 1 FROM sys_file WHERE path = $1 AND type = 0);`
+			if err := tx.QueryRowContext(ctx, existsSQL, fullPath).Scan(&merge); err != nil {
+				Fail(c, "500", "还原文件失败")
+				return
+			}
+		}
+		if !merge {
+			const restoreSQL = `
+INSERT INTO sys_file (` + sysFileColumns + `)
+SELECT ` + sysFileColumns + `
+FROM sys_file_recycle
+WHERE id = $1;
+`
+			if _, err := tx.ExecContext(ctx, restoreSQL, idVal); err != nil {
+				Fail(c, "500", "还原文件失败")
+				return
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM sys_file_recycle WHERE id = $1;`, idVal); err != nil {
+			Fail(c, "500", "还原文件失败")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		Fail(c, "500", "还原文件失败")
+		return
+	}
+	OK(c, true)
+}
+
+// ensureDirPath 确保 dirPath 及其所有上级文件夹存在，缺失的文件夹以 userID 创建。
+func ensureDirPath(ctx context.Context, tx *sql.Tx, dirPath string, userID int64) error {
+	dirPath = normalizeParentPath(dirPath)
+	if dirPath == "/" {
+		return nil
+	}
+	const insertSQL = `
+INSERT INTO sys_file (
+    id, name, original_name, size, parent_path, path, extension, content_type,
+    type, sha256, metadata, thumbnail_name, thumbnail_size, thumbnail_metadata,
+    storage_id, create_user, create_time
+)
+SELECT $1, $2, $2, NULL, $3, $4, NULL, NULL,
+       0, '', '', '', NULL, '',
+       1, $5, $6
+WHERE NOT EXISTS (SELECT 1 FROM sys_file WHERE path = $4 AND type = 0);
+`
+	parent := "/"
+	for _, name := range strings.Split(strings.TrimPrefix(dirPath, "/"), "/") {
+		current := joinStoragePath(parent, name)
+		if _, err := tx.ExecContext(ctx, insertSQL, id.Next(), name, parent, current, userID, time.Now()); err != nil {
+			return err
+		}
+		parent = current
+	}
+	return nil
+}
+
+// DeleteRecycle handles DELETE /system/file/recycle，彻底删除指定文件。
+func (h *FileHandler) DeleteRecycle(c *gin.Context) {
+	var req idsRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) == 0 {
+		Fail(c, "400", "ID 列表不能为空")
+		return
+	}
+	const deleteSQL = `DELETE FROM sys_file_recycle WHERE id = ANY($1) RETURNING blob_id;`
+	if _, err := h.purgeRecycleRows(c.Request.Context(), deleteSQL, pq.Int64Array(req.IDs)); err != nil {
+		Fail(c, "500", "删除文件失败")
+		return
+	}
+	OK(c, true)
+}
+
+// CleanRecycle handles DELETE /system/file/recycle/clean，清空回收站。
+func (h *FileHandler) CleanRecycle(c *gin.Context) {
+	const deleteSQL = `DELETE FROM sys_file_recycle RETURNING blob_id;`
+	if _, err := h.purgeRecycleRows(c.Request.Context(), deleteSQL); err != nil {
+		Fail(c, "500", "清空回收站失败")
+		return
+	}
+	OK(c, true)
+}
+
+// purgeRecycleRows 在事务中执行 DELETE ... RETURNING blob_id 并释放对象引用，提交后删除不再引用的物理文件。
+// 返回删除的记录数。
+func (h *FileHandler) purgeRecycleRows(ctx context.Context, query string, args ...any) (int, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	blobIDs, n, err := deleteFileRows(ctx, tx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	released, err := releaseBlobs(ctx, tx, blobIDs)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	h.removeBlobObjects(ctx, released)
+	return n, nil
+}
+
+// StartRecyclePurge 启动后台任务，每隔 interval 彻底删除超过保留期的回收站文件（本地及对象存储）。
+// 多实例部署时各实例可同时运行，批量删除使用 SKIP LOCKED 互不阻塞。
+func (h *FileHandler) StartRecyclePurge(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if n, err := h.purgeExpiredRecycle(ctx); err != nil {
+				log.Printf("purge file recycle bin failed: %v", err)
+			} else if n > 0 {
+				log.Printf("purged %d expired files from recycle bin", n)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// purgeExpiredRecycle 分批删除超过保留期的回收站记录，返回删除数量。
+func (h *FileHandler) purgeExpiredRecycle(ctx context.Context) (int, error) {
+	days, err := h.recycleRetentionDays(ctx)
+	if err != nil || days <= 0 {
+		return 0, err
+	}
+	const deleteSQL = `
+DELETE FROM sys_file_recycle
+WHERE id IN (
+    SELECT id FROM sys_file_recycle
+    WHERE delete_time < $1
+    ORDER BY delete_time
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING blob_id;
+`
+	before := time.Now().AddDate(0, 0, -days)
+	total := 0
+	for {
+		n, err := h.purgeRecycleRows(ctx, deleteSQL, before, fileRecyclePurgeBatch)
+		total += n
+		if err != nil || n < fileRecyclePurgeBatch {
+			return total, err
+		}
+	}
+}
+
+// recycleRetentionDays 读取回收站保留天数，未配置时使用默认值。
+func (h *FileHandler) recycleRetentionDays(ctx context.Context) (int, error) {
+	const query = `
+SELECT COALESCE(value, default_value, '')
+FROM sys_option
+WHERE code = $1
+LIMIT 1;
+`
+	var raw string
+	err := h.db.QueryRowContext(ctx, query, optionFileRecycleRetentionDays).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return defaultFileRecycleRetentionDays, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	days, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return defaultFileRecycleRetentionDays, nil
+	}
+	return days, nil
+}
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"strconv"
+
+	"golang.org/x/image/draw"
+
+	"voc-go-backend/internal/infrastructure/storage"
+)
+
+// 缩略图参数，与 Java 上传预处理一致：等比缩放到 100x100 以内，输出 JPEG，文件名追加 .min.jpg。
+const (
+	thumbnailMaxSize = 100
+	thumbnailSuffix  = ".min.jpg"
+	thumbnailQuality = 85
+	// thumbnailMaxPixels 原图像素上限，超过时不生成缩略图，避免解码超大图片占满内存。
+	thumbnailMaxPixels = 40_000_000
+)
+
+var errThumbnailTooLarge = errors.New("image too large for thumbnail")
+
+// imageDecoders 支持生成缩略图的扩展名及对应解码器。
+var imageDecoders = map[string]func(io.Reader) (image.Image, error){
+	"jpg":  jpeg.Decode,
+	"jpeg": jpeg.Decode,
+	"png":  png.Decode,
+	"gif":  gif.Decode, // 动图取第一帧
+}
+
+var imageConfigDecoders = map[string]func(io.Reader) (image.Config, error){
+	"jpg":  jpeg.DecodeConfig,
+	"jpeg": jpeg.DecodeConfig,
+	"png":  png.DecodeConfig,
+	"gif":  gif.DecodeConfig,
+}
+
+// fileThumbnail 为已生成的缩略图信息。
+type fileThumbnail struct {
+	Name   string
+	Size   int64
+	Width  int
+	Height int
+	// 原图尺寸，写入 sys_file.metadata
+	SourceWidth  int
+	SourceHeight int
+}
+
+// Metadata 返回缩略图尺寸，写入 sys_file.thumbnail_metadata。
+func (t *fileThumbnail) Metadata() map[string]string {
+	return imageSizeMetadata(t.Width, t.Height)
+}
+
+// SourceMetadata 返回原图尺寸。
+func (t *fileThumbnail) SourceMetadata() map[string]string {
+	return imageSizeMetadata(t.SourceWidth, t.SourceHeight)
+}
+
+// marshalFileMetadata 将元数据序列化为 JSON，与 Java 中 JSONUtil.toJsonStr 存储格式一致。
+func marshalFileMetadata(m map[string]string) string {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// parseFileMetadata 解析 sys_file.metadata，内容为空或格式不正确时返回空 map。
+func parseFileMetadata(s string) map[string]string {
+	m := map[string]string{}
+	if s != "" {
+		_ = json.Unmarshal([]byte(s), &m)
+	}
+	return m
+}
+
+func imageSizeMetadata(width, height int) map[string]string {
+	return map[string]string{
+		"width":  strconv.Itoa(width),
+		"height": strconv.Itoa(height),
+	}
+}
+
+// supportsThumbnail 判断扩展名是否支持生成缩略图（JPEG/PNG/GIF）。
+func supportsThumbnail(ext string) bool {
+	_, ok := imageDecoders[ext]
+	return ok
+}
+
+// putThumbnail 为图片生成缩略图并写入原文件所在的存储目录，
+// 缩略图文件名为 {原文件名}.min.jpg。
+func putThumbnail(ctx context.Context, driver storage.Driver, src io.ReadSeeker, ext, fullPath string) (*fileThumbnail, error) {
+	data, thumb, err := makeThumbnail(src, ext)
+	if err != nil {
+		return nil, err
+	}
+	thumb.Name = path.Base(fullPath) + thumbnailSuffix
+	if err := driver.Put(ctx, thumbnailStoragePath(fullPath, thumb.Name), bytes.NewReader(data), thumb.Size, "image/jpeg"); err != nil {
+		return nil, err
+	}
+	return thumb, nil
+}
+
+// thumbnailStoragePath 返回缩略图的存储路径，缩略图与原文件位于同一目录。
+// 秒传记录的 parent_path 可能与对象所在目录不同，因此按 path 而非 parent_path 计算。
+func thumbnailStoragePath(fullPath, thumbName string) string {
+	return joinStoragePath(path.Dir(fullPath), thumbName)
+}
+
+// makeThumbnail 解码图片并等比缩放到 thumbnailMaxSize 以内（小图不放大），
+// 返回 JPEG 编码后的缩略图内容及尺寸信息（Name 由调用方填写）。
+func makeThumbnail(r io.ReadSeeker, ext string) ([]byte, *fileThumbnail, error) {
+	decode, ok := imageDecoders[ext]
+	if !ok {
+		return nil, nil, image.ErrFormat
+	}
+	cfg, err := imageConfigDecoders[ext](r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > thumbnailMaxPixels {
+		return nil, nil, errThumbnailTooLarge
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	img, err := decode(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b := img.Bounds()
+	w, h := thumbnailDimensions(b.Dx(), b.Dy())
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	// JPEG 不支持透明通道，透明区域填充白色
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), &fileThumbnail{
+		Size:         int64(buf.Len()),
+		Width:        w,
+		Height:       h,
+		SourceWidth:  b.Dx(),
+		SourceHeight: b.Dy(),
+	}, nil
+}
+
+// thumbnailDimensions 计算等比缩放后的尺寸。
+func thumbnailDimensions(w, h int) (int, int) {
+	if w <= thumbnailMaxSize && h <= thumbnailMaxSize {
+		return w, h
+	}
+	if w >= h {
+		return thumbnailMaxSize, max(1, h*thumbnailMaxSize/w)
+	}
+	return max(1, w*thumbnailMaxSize/h), thumbnailMaxSize
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestThumbnailDimensions(t *testing.T) {
+	tests := []struct {
+		name         string
+		w, h         int
+		wantW, wantH int
+	}{
+		{"small image kept", 80, 60, 80, 60},
+		{"exact bound kept", 100, 100, 100, 100},
+		{"landscape", 400, 200, 100, 50},
+		{"portrait", 200, 400, 50, 100},
+		{"square", 1000, 1000, 100, 100},
+		{"extreme landscape keeps one pixel", 10000, 10, 100, 1},
+		{"extreme portrait keeps one pixel", 10, 10000, 1, 100},
+		{"one side over bound", 101, 50, 100, 49},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h := thumbnailDimensions(tt.w, tt.h)
+			if w != tt.wantW || h != tt.wantH {
+				t.Fatalf("thumbnailDimensions(%d, %d) = %d, %d; want %d, %d", tt.w, tt.h, w, h, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestMakeThumbnail(t *testing.T) {
+	var src bytes.Buffer
+	if err := png.Encode(&src, image.NewRGBA(image.Rect(0, 0, 300, 150))); err != nil {
+		t.Fatal(err)
+	}
+
+	data, thumb, err := makeThumbnail(bytes.NewReader(src.Bytes()), "png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thumb.Width != 100 || thumb.Height != 50 || thumb.SourceWidth != 300 || thumb.SourceHeight != 150 {
+		t.Fatalf("thumbnail = %+v, want 100x50 from 300x150", thumb)
+	}
+	if thumb.Size != int64(len(data)) {
+		t.Fatalf("Size = %d, want %d", thumb.Size, len(data))
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("thumbnail is not a JPEG: %v", err)
+	}
+	if cfg.Width != 100 || cfg.Height != 50 {
+		t.Fatalf("JPEG size = %dx%d, want 100x50", cfg.Width, cfg.Height)
+	}
+
+	if _, _, err := makeThumbnail(bytes.NewReader(src.Bytes()), "bmp"); !errors.Is(err, image.ErrFormat) {
+		t.Fatalf("unsupported ext error = %v, want image.ErrFormat", err)
+	}
+	if _, _, err := makeThumbnail(bytes.NewReader([]byte("not an image")), "png"); err == nil {
+		t.Fatal("expected error for corrupt image")
+	}
+}
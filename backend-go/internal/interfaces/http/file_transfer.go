@@ -0,0 +1,680 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"voc-go-backend/internal/infrastructure/id"
+	"voc-go-backend/internal/infrastructure/storage"
+)
+
+// 文件移动与复制。
+// 文件夹为 type = 0 的记录，path 为其逻辑路径；文件的 parent_path 为所在文件夹，
+// path 为对象在存储中的位置（可能被多条记录共享），因此移动文件只修改 parent_path，
+// 移动文件夹时其下所有文件的 parent_path 与子文件夹的 parent_path/path 在同一事务中改写。
+// 指定目标存储且与文件所在存储不同时，对象复制到目标存储（已有相同内容的对象则直接引用）。
+// 对象复制在事务外完成，之后在短事务中加锁复核并切换引用，避免复制大文件期间长时间持有锁。
+
+// fileTransferReq 对应 POST /system/file/move 与 POST /system/file/copy。
+type fileTransferReq struct {
+	IDs        []int64 `json:"ids"`
+	ParentPath string  `json:"parentPath"`
+	// StorageID 目标存储，0 表示保持文件原有存储
+	StorageID int64 `json:"storageId"`
+}
+
+// fileTransferError 为可直接返回给前端的校验错误。
+type fileTransferError struct{ msg string }
+
+func (e *fileTransferError) Error() string { return e.msg }
+
+// fileRecord 为 sys_file 中的一条记录。
+type fileRecord struct {
+	id           int64
+	name         string
+	originalName string
+	size         sql.NullInt64
+	parentPath   string
+	path         string
+	ext          sql.NullString
+	contentType  sql.NullString
+	fileType     int16
+	sha256       string
+	metadata     string
+	thumbName    string
+	thumbSize    sql.NullInt64
+	thumbMeta    string
+	storageID    int64
+	blobID       sql.NullInt64
+}
+
+const fileRecordColumns = `id, name, original_name, size, parent_path, path, extension, content_type,
+       type, COALESCE(sha256, ''), COALESCE(metadata, ''), COALESCE(thumbnail_name, ''),
+       thumbnail_size, COALESCE(thumbnail_metadata, ''), storage_id, blob_id`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFileRecord(row rowScanner) (*fileRecord, error) {
+	var r fileRecord
+	if err := row.Scan(
+		&r.id, &r.name, &r.originalName, &r.size, &r.parentPath, &r.path, &r.ext, &r.contentType,
+		&r.fileType, &r.sha256, &r.metadata, &r.thumbName,
+		&r.thumbSize, &r.thumbMeta, &r.storageID, &r.blobID,
+	); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// isSubPath 判断 p 是否为 dir 本身或其下级路径。
+func isSubPath(p, dir string) bool {
+	return p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// descendantCond 为匹配文件夹 $n 下所有文件及子文件夹（按 parent_path）的条件。
+func descendantCond(n int) string {
+	return fmt.Sprintf("(parent_path = $%d OR left(parent_path, char_length($%d) + 1) = $%d || '/')", n, n, n)
+}
+
+// fileTransfer 记录一次移动或复制中预先复制、登记及不再引用的对象，事务提交后统一清理。
+type fileTransfer struct {
+	h      *FileHandler
+	tx     *sql.Tx
+	userID int64
+	now    time.Time
+	// target 为目标存储，nil 表示保持原存储
+	target *storage.Config
+	// copies 为事务外复制到目标存储、尚未登记的对象，按源对象索引
+	copies map[string]*fileBlob
+	// registered 为本次事务中已登记的复制结果及其对象 ID，提交后未登记的复制结果被删除
+	registered map[string]int64
+	// released 为移动后原记录不再引用的对象
+	released []int64
+	// quotaLocked 为 true 时已持有当前用户的配额锁
+	quotaLocked bool
+}
+
+func (h *FileHandler) newFileTransfer(ctx context.Context, req *fileTransferReq, userID int64) (*fileTransfer, error) {
+	t := &fileTransfer{h: h, userID: userID, now: time.Now(), copies: make(map[string]*fileBlob)}
+	if req.StorageID != 0 {
+		cfg, err := h.getStorageByID(ctx, req.StorageID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &fileTransferError{msg: "目标存储不存在"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Status != 1 {
+			return nil, &fileTransferError{msg: fmt.Sprintf("存储 [%s] 已被禁用", cfg.Name)}
+		}
+		t.target = cfg
+	}
+
+	parentPath := normalizeParentPath(req.ParentPath)
+	if parentPath != "/" {
+		var exists bool
+		const existsSQL = `SELECT EXISTS (SELECT 1 FROM sys_file WHERE path = $1 AND type = 0);`
+		if err := h.db.QueryRowContext(ctx, existsSQL, parentPath).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, &fileTransferError{msg: "目标文件夹不存在"}
+		}
+	}
+	req.ParentPath = parentPath
+	return t, nil
+}
+
+// needsCopy 判断文件是否需要复制到目标存储。
+func (t *fileTransfer) needsCopy(r *fileRecord) bool {
+	return r.fileType != 0 && t.target != nil && r.storageID != t.target.ID
+}
+
+// copyKey 为源对象在 copies 中的索引。
+func copyKey(r *fileRecord) string {
+	return fmt.Sprintf("%d:%s", r.storageID, r.path)
+}
+
+// prepareCopies 在事务外将 ids 中需要跨存储的文件（含文件夹下的文件）复制到目标存储。
+// 目标存储已有相同内容或已复制过的对象跳过；事务中发现未复制的对象时返回 errBlobReleased，由调用方补充复制后重试。
+func (t *fileTransfer) prepareCopies(ctx context.Context, ids []int64) error {
+	if t.target == nil {
+		return nil
+	}
+	var files []*fileRecord
+	for _, idVal := range ids {
+		r, err := scanFileRecord(t.h.db.QueryRowContext(ctx, `SELECT `+fileRecordColumns+` FROM sys_file WHERE id = $1;`, idVal))
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if r.fileType != 0 {
+			files = append(files, r)
+			continue
+		}
+		query := `SELECT ` + fileRecordColumns + `
+FROM sys_file
+WHERE type <> 0 AND storage_id <> $2 AND ` + descendantCond(1) + `;`
+		rows, err := t.h.db.QueryContext(ctx, query, r.path, t.target.ID)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			f, err := scanFileRecord(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			files = append(files, f)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range files {
+		if !t.needsCopy(r) || t.copies[copyKey(r)] != nil {
+			continue
+		}
+		if r.sha256 != "" {
+			existing, err := findBlob(ctx, t.h.db, t.target.ID, r.sha256, r.size.Int64)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				continue
+			}
+		}
+		copied, err := t.putObject(ctx, r)
+		if err != nil {
+			return err
+		}
+		t.copies[copyKey(r)] = copied
+	}
+	return nil
+}
+
+// copyObject 在事务中登记文件在目标存储中的对象引用，r 随之指向该对象。
+// 目标存储已有相同内容时直接引用，否则登记事务外预先复制的对象；没有复制结果时返回 errBlobReleased。
+func (t *fileTransfer) copyObject(ctx context.Context, r *fileRecord, parentPath string) error {
+	u := &uploadedFile{
+		originalName: r.originalName,
+		parentPath:   parentPath,
+		ext:          r.ext.String,
+		contentType:  r.contentType.String,
+		sha256:       r.sha256,
+		size:         r.size.Int64,
+		metadata:     r.metadata,
+	}
+
+	blob, err := findBlob(ctx, t.tx, t.target.ID, r.sha256, r.size.Int64)
+	if err != nil {
+		return err
+	}
+	retained := false
+	if blob != nil {
+		if retained, err = retainBlob(ctx, t.tx, blob.ID); err != nil {
+			return err
+		}
+	}
+	if retained {
+		u.useBlob(blob)
+	} else {
+		key := copyKey(r)
+		copied := t.copies[key]
+		if copied == nil {
+			return errBlobReleased
+		}
+		u.name = path.Base(copied.Path)
+		u.path = copied.Path
+		u.thumbName = copied.ThumbName
+		u.thumbSize = copied.ThumbSize
+		u.thumbMeta = copied.ThumbMeta
+		if blobID, ok := t.registered[key]; ok {
+			u.blobID = blobID
+		} else if err := checkStorageQuota(ctx, t.tx, t.target, u.size); err != nil {
+			return err
+		}
+		redundant, err := acquireBlob(ctx, t.tx, t.target.ID, u)
+		if err != nil {
+			return err
+		}
+		// 并发写入已登记相同内容时改为引用已有对象，复制结果在提交后删除
+		if redundant == nil {
+			t.registered[key] = u.blobID
+		}
+	}
+
+	r.name = u.name
+	r.path = u.path
+	r.metadata = u.metadata
+	r.thumbName = u.thumbName
+	r.thumbSize = sql.NullInt64{}
+	if u.thumbSize != nil {
+		r.thumbSize = sql.NullInt64{Int64: *u.thumbSize, Valid: true}
+	}
+	r.thumbMeta = u.thumbMeta
+	r.storageID = t.target.ID
+	r.blobID = sql.NullInt64{Int64: u.blobID, Valid: true}
+	return nil
+}
+
+// putObject 将 r 的对象及缩略图复制到目标存储，文件名规则与上传一致，返回尚未登记（ID 为 0）的目标对象。
+func (t *fileTransfer) putObject(ctx context.Context, r *fileRecord) (*fileBlob, error) {
+	srcCfg, err := t.h.storageForFile(ctx, r.storageID)
+	if err != nil {
+		return nil, err
+	}
+	src, err := t.h.storages.Driver(srcCfg)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := t.h.storages.Driver(t.target)
+	if err != nil {
+		return nil, err
+	}
+
+	// 提前校验以免复制注定无法登记的对象，登记时在事务中再次校验
+	if err := checkStorageQuota(ctx, t.h.db, t.target, r.size.Int64); err != nil {
+		return nil, err
+	}
+
+	name := strconv.FormatInt(id.Next(), 10)
+	if r.ext.String != "" {
+		name += "." + r.ext.String
+	}
+	copied := &fileBlob{StorageID: t.target.ID, Path: joinStoragePath(r.parentPath, name), Metadata: r.metadata}
+	if _, err := copyStoredObject(ctx, src, r.path, dst, copied.Path, r.contentType.String); err != nil {
+		return nil, err
+	}
+
+	// 缩略图复制失败时不影响文件本身
+	if r.thumbName != "" {
+		thumbName := name + thumbnailSuffix
+		size, err := copyStoredObject(ctx, src, thumbnailStoragePath(r.path, r.thumbName), dst, thumbnailStoragePath(copied.Path, thumbName), "image/jpeg")
+		if err == nil {
+			copied.ThumbName = thumbName
+			copied.ThumbSize = &size
+			copied.ThumbMeta = r.thumbMeta
+		}
+	}
+	return copied, nil
+}
+
+// copyStoredObject 在两个存储之间复制对象，返回对象大小。
+func copyStoredObject(ctx context.Context, src storage.Driver, srcPath string, dst storage.Driver, dstPath, contentType string) (int64, error) {
+	info, err := src.Stat(ctx, srcPath)
+	if err != nil {
+		return 0, err
+	}
+	rc, err := src.Get(ctx, srcPath)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	if contentType == "" {
+		contentType = info.ContentType
+	}
+	if err := dst.Put(ctx, dstPath, rc, info.Size, contentType); err != nil {
+		return 0, err
+	}
+	return info.Size, nil
+}
+
+// abort 删除事务外复制到目标存储的对象，在处理失败时调用。
+func (t *fileTransfer) abort(ctx context.Context) {
+	t.removeCopies(ctx, nil)
+}
+
+// removeCopies 删除 registered 以外的复制结果。
+func (t *fileTransfer) removeCopies(ctx context.Context, registered map[string]int64) {
+	var unused []fileBlob
+	for key, copied := range t.copies {
+		if _, ok := registered[key]; !ok {
+			unused = append(unused, *copied)
+		}
+	}
+	t.h.removeBlobObjects(ctx, unused)
+}
+
+// commit 释放不再引用的对象并提交事务，随后删除不再需要的物理文件及未使用的复制结果。
+func (t *fileTransfer) commit(ctx context.Context) error {
+	released, err := releaseBlobs(ctx, t.tx, t.released)
+	if err != nil {
+		return err
+	}
+	if err := t.tx.Commit(); err != nil {
+		return err
+	}
+	t.h.removeBlobObjects(ctx, released)
+	t.removeCopies(ctx, t.registered)
+	return nil
+}
+
+// lockFileRecord 查询并锁定 sys_file 记录，不存在时返回 nil。
+func lockFileRecord(ctx context.Context, tx *sql.Tx, fileID int64) (*fileRecord, error) {
+	r, err := scanFileRecord(tx.QueryRowContext(ctx, `SELECT `+fileRecordColumns+` FROM sys_file WHERE id = $1 FOR UPDATE;`, fileID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return r, err
+}
+
+// checkDirTarget 校验文件夹可以移动或复制到 parentPath，返回其新路径。
+func checkDirTarget(ctx context.Context, tx *sql.Tx, dir *fileRecord, parentPath, action string) (string, error) {
+	if isSubPath(parentPath, dir.path) {
+		return "", &fileTransferError{msg: fmt.Sprintf("不能将文件夹 [%s] %s到自身或其子文件夹下", dir.originalName, action)}
+	}
+	newPath := joinStoragePath(parentPath, dir.name)
+	if newPath == dir.path {
+		return newPath, nil
+	}
+	var exists bool
+	const existsSQL = `SELECT EXISTS (SELECT 1 FROM sys_file WHERE path = $1 AND type = 0);`
+	if err := tx.QueryRowContext(ctx, existsSQL, newPath).Scan(&exists); err != nil {
+		return "", err
+	}
+	if exists {
+		return "", &fileTransferError{msg: fmt.Sprintf("目标文件夹下已存在同名文件夹 [%s]", dir.name)}
+	}
+	return newPath, nil
+}
+
+// MoveFile handles POST /system/file/move.
+func (h *FileHandler) MoveFile(c *gin.Context) {
+	h.transferFiles(c, "移动", (*fileTransfer).move)
+}
+
+// CopyFile handles POST /system/file/copy.
+func (h *FileHandler) CopyFile(c *gin.Context) {
+	h.transferFiles(c, "复制", (*fileTransfer).copy)
+}
+
+func (h *FileHandler) transferFiles(c *gin.Context, action string, apply func(*fileTransfer, context.Context, *fileRecord, string) error) {
+	userID := h.currentUserID(c)
+	if userID == 0 {
+		return
+	}
+	var req fileTransferReq
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) == 0 {
+		Fail(c, "400", "ID 列表不能为空")
+		return
+	}
+	ctx := c.Request.Context()
+
+	t, err := h.newFileTransfer(ctx, &req, userID)
+	if err == nil {
+		err = t.run(ctx, req.IDs, req.ParentPath, apply)
+		if errors.Is(err, errBlobReleased) {
+			// 复制后文件或目标对象有变化，补充复制后重试
+			err = t.run(ctx, req.IDs, req.ParentPath, apply)
+		}
+		if err != nil {
+			t.abort(ctx)
+		}
+	}
+	if err != nil {
+		var transferErr *fileTransferError
+		if errors.As(err, &transferErr) {
+			Fail(c, "400", transferErr.msg)
+			return
+		}
+		var quotaErr *fileQuotaError
+		if errors.As(err, &quotaErr) {
+			Fail(c, "400", quotaErr.msg)
+			return
+		}
+		Fail(c, "500", action+"文件失败")
+		return
+	}
+	OK(c, true)
+}
+
+// run 在事务外复制跨存储的对象，再在事务中依次处理 ids 并提交，已不存在的记录忽略。
+func (t *fileTransfer) run(ctx context.Context, ids []int64, parentPath string, apply func(*fileTransfer, context.Context, *fileRecord, string) error) error {
+	if err := t.prepareCopies(ctx, ids); err != nil {
+		return err
+	}
+	tx, err := t.h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	t.tx = tx
+	t.registered = make(map[string]int64)
+	t.released = nil
+	t.quotaLocked = false
+
+	for _, idVal := range ids {
+		r, err := lockFileRecord(ctx, t.tx, idVal)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			continue
+		}
+		if err := apply(t, ctx, r, parentPath); err != nil {
+			return err
+		}
+	}
+	return t.commit(ctx)
+}
+
+// move 将文件或文件夹移动到 parentPath。
+func (t *fileTransfer) move(ctx context.Context, r *fileRecord, parentPath string) error {
+	if r.fileType != 0 {
+		return t.moveFile(ctx, r, parentPath)
+	}
+
+	newPath, err := checkDirTarget(ctx, t.tx, r, parentPath, "移动")
+	if err != nil {
+		return err
+	}
+	if newPath != r.path {
+		// 先改写下级记录（子文件夹的 path 以原路径开头），再改写文件夹本身
+		rewriteSQL := `
+UPDATE sys_file
+SET parent_path = $2 || substr(parent_path, char_length($1) + 1),
+    path        = CASE WHEN type = 0 THEN $2 || substr(path, char_length($1) + 1) ELSE path END,
+    update_user = $3,
+    update_time = $4
+WHERE ` + descendantCond(1) + `;
+`
+		if _, err := t.tx.ExecContext(ctx, rewriteSQL, r.path, newPath, t.userID, t.now); err != nil {
+			return err
+		}
+		const updateSQL = `
+UPDATE sys_file
+SET parent_path = $1, path = $2, update_user = $3, update_time = $4
+WHERE id = $5;
+`
+		if _, err := t.tx.ExecContext(ctx, updateSQL, parentPath, newPath, t.userID, t.now, r.id); err != nil {
+			return err
+		}
+	}
+	if t.target == nil {
+		return nil
+	}
+
+	// 跨存储移动：文件夹下位于其他存储的文件逐个复制到目标存储
+	query := `SELECT ` + fileRecordColumns + `
+FROM sys_file
+WHERE type <> 0 AND storage_id <> $2 AND ` + descendantCond(1) + `
+FOR UPDATE;`
+	rows, err := t.tx.QueryContext(ctx, query, newPath, t.target.ID)
+	if err != nil {
+		return err
+	}
+	var files []*fileRecord
+	for rows.Next() {
+		f, err := scanFileRecord(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		files = append(files, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := t.moveFile(ctx, f, f.parentPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveFile 修改文件所在文件夹，需要时将对象复制到目标存储并释放原对象。
+func (t *fileTransfer) moveFile(ctx context.Context, r *fileRecord, parentPath string) error {
+	oldBlob := r.blobID
+	if t.needsCopy(r) {
+		if err := t.copyObject(ctx, r, parentPath); err != nil {
+			return err
+		}
+		if oldBlob.Valid {
+			t.released = append(t.released, oldBlob.Int64)
+		}
+	}
+	const updateSQL = `
+UPDATE sys_file
+SET parent_path        = $1,
+    name               = $2,
+    path               = $3,
+    metadata           = $4,
+    thumbnail_name     = $5,
+    thumbnail_size     = $6,
+    thumbnail_metadata = $7,
+    storage_id         = $8,
+    blob_id            = $9,
+    update_user        = $10,
+    update_time        = $11
+WHERE id = $12;
+`
+	_, err := t.tx.ExecContext(ctx, updateSQL,
+		parentPath, r.name, r.path, r.metadata, r.thumbName, r.thumbSize, r.thumbMeta,
+		r.storageID, r.blobID, t.userID, t.now, r.id,
+	)
+	return err
+}
+
+// copy 将文件或文件夹（含全部下级）复制到 parentPath。
+// 副本的创建人为当前用户，计入其存储配额。
+func (t *fileTransfer) copy(ctx context.Context, r *fileRecord, parentPath string) error {
+	if !t.quotaLocked {
+		if err := lockUserQuota(ctx, t.tx, t.userID); err != nil {
+			return err
+		}
+		t.quotaLocked = true
+	}
+	if r.fileType != 0 {
+		return t.copyFile(ctx, r, parentPath)
+	}
+
+	newPath, err := checkDirTarget(ctx, t.tx, r, parentPath, "复制")
+	if err != nil {
+		return err
+	}
+	if newPath == r.path {
+		return &fileTransferError{msg: fmt.Sprintf("文件夹 [%s] 已位于目标文件夹下", r.originalName)}
+	}
+
+	// 先读取全部下级，父文件夹在前
+	query := `SELECT ` + fileRecordColumns + `
+FROM sys_file
+WHERE ` + descendantCond(1) + `
+ORDER BY type, char_length(parent_path), id;`
+	rows, err := t.tx.QueryContext(ctx, query, r.path)
+	if err != nil {
+		return err
+	}
+	var children []*fileRecord
+	for rows.Next() {
+		f, err := scanFileRecord(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		children = append(children, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	oldPath := r.path
+	r.path = newPath
+	if err := t.insertCopy(ctx, r, parentPath); err != nil {
+		return err
+	}
+	for _, f := range children {
+		childParent := newPath + strings.TrimPrefix(f.parentPath, oldPath)
+		if f.fileType != 0 {
+			if err := t.copyFile(ctx, f, childParent); err != nil {
+				return err
+			}
+			continue
+		}
+		f.path = newPath + strings.TrimPrefix(f.path, oldPath)
+		if err := t.insertCopy(ctx, f, childParent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile 复制文件记录，同一存储内引用同一对象，跨存储时复制对象。
+// 本次已复制的文件在同一事务中可见，配额校验时与新文件大小一并累计。
+func (t *fileTransfer) copyFile(ctx context.Context, r *fileRecord, parentPath string) error {
+	if err := checkUserQuota(ctx, t.tx, t.userID, r.size.Int64); err != nil {
+		return err
+	}
+	if t.needsCopy(r) {
+		if err := t.copyObject(ctx, r, parentPath); err != nil {
+			return err
+		}
+	} else if r.blobID.Valid {
+		ok, err := retainBlob(ctx, t.tx, r.blobID.Int64)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errBlobReleased
+		}
+	}
+	return t.insertCopy(ctx, r, parentPath)
+}
+
+// insertCopy 以新 ID 写入 r 的副本，创建人为当前用户。
+func (t *fileTransfer) insertCopy(ctx context.Context, r *fileRecord, parentPath string) error {
+	const insertSQL = `
+INSERT INTO sys_file (
+    id, name, original_name, size, parent_path, path, extension, content_type,
+    type, sha256, metadata, thumbnail_name, thumbnail_size, thumbnail_metadata,
+    storage_id, blob_id, create_user, create_time
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8,
+    $9, $10, $11, $12, $13, $14,
+    $15, $16, $17, $18
+);`
+	_, err := t.tx.ExecContext(ctx, insertSQL,
+		id.Next(), r.name, r.originalName, r.size, parentPath, r.path, r.ext, r.contentType,
+		r.fileType, r.sha256, r.metadata, r.thumbName, r.thumbSize, r.thumbMeta,
+		r.storageID, r.blobID, t.userID, t.now,
+	)
+	return err
+}
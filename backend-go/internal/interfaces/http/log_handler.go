@@ -3,12 +3,13 @@ package http
 import (
 	"database/sql"
 	"fmt"
-	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"voc-go-backend/internal/domain/syslog"
 )
 
 // LogResp 与前端 LogResp 类型对齐。
@@ -54,19 +55,22 @@ type LogDetailResp struct {
 // LogHandler 提供 /system/log 相关接口。
 type LogHandler struct {
 	db *sql.DB
+	// writer 为异步日志写入状态，同步写入时为 nil
+	writer syslog.StatsReporter
 }
 
 // NewLogHandler 创建日志 handler。
-func NewLogHandler(db *sql.DB) *LogHandler {
-	return &LogHandler{db: db}
+func NewLogHandler(db *sql.DB, writer syslog.StatsReporter) *LogHandler {
+	return &LogHandler{db: db, writer: writer}
 }
 
 // RegisterLogRoutes 注册系统日志路由。
-func (h *LogHandler) RegisterLogRoutes(r *gin.Engine) {
-	r.GET("/system/log", h.PageLog)
-	r.GET("/system/log/:id", h.GetLog)
-	r.GET("/system/log/export/login", h.ExportLoginLog)
-	r.GET("/system/log/export/operation", h.ExportOperationLog)
+func (h *LogHandler) RegisterLogRoutes(r *gin.Engine, am *AuthMiddleware) {
+	r.GET("/system/log", Audit("系统日志", "分页查询日志", AuditNoResponseBody), am.RequirePermission("monitor:log:list"), h.PageLog)
+	r.GET("/system/log/:id", Audit("系统日志", "查询日志详情", AuditNoResponseBody), am.RequirePermission("monitor:log:get"), h.GetLog)
+	r.GET("/system/log/writer", Audit("系统日志", "查询日志写入状态"), am.RequirePermission("monitor:log:list"), h.GetLogWriterStats)
+	r.GET("/system/log/export/login", Audit("系统日志", "导出登录日志", AuditNoResponseBody), am.RequirePermission("monitor:log:export"), h.ExportLoginLog)
+	r.GET("/system/log/export/operation", Audit("系统日志", "导出操作日志", AuditNoResponseBody), am.RequirePermission("monitor:log:export"), h.ExportOperationLog)
 }
 
 // PageLog 处理 GET /system/log，返回分页日志列表。
@@ -85,6 +89,7 @@ func (h *LogHandler) PageLog(c *gin.Context) {
 	ip := strings.TrimSpace(c.Query("ip"))
 	createUser := strings.TrimSpace(c.Query("createUserString"))
 	statusStr := strings.TrimSpace(c.Query("status"))
+	traceID := strings.TrimSpace(c.Query("traceId"))
 
 	var statusFilter int64
 	if statusStr != "" {
@@ -133,6 +138,11 @@ LEFT JOIN sys_user AS t2 ON t2.id = t1.create_user
 		args = append(args, "%"+createUser+"%")
 		argPos++
 	}
+	if traceID != "" {
+		where += fmt.Sprintf(" AND t1.trace_id = $%d", argPos)
+		args = append(args, traceID)
+		argPos++
+	}
 	if statusFilter != 0 {
 		where += fmt.Sprintf(" AND t1.status = $%d", argPos)
 		args = append(args, statusFilter)
@@ -144,6 +154,13 @@ LEFT JOIN sys_user AS t2 ON t2.id = t1.create_user
 		argPos += 2
 	}
 
+	scope, err := loadDataScope(c.Request.Context(), h.db, contextUserID(c))
+	if err != nil {
+		Fail(c, "500", "查询日志失败")
+		return
+	}
+	where, args, argPos = scope.appendWhere(where, args, argPos, logDataScopeColumns)
+
 	countSQL := "SELECT COUNT(*) " + baseFrom + where
 	var total int64
 	if err := h.db.QueryRowContext(c.Request.Context(), countSQL, args...).Scan(&total); err != nil {
@@ -222,6 +239,15 @@ LIMIT $%d OFFSET $%d;
 	OK(c, PageResult[LogResp]{List: list, Total: total})
 }
 
+// GetLogWriterStats 处理 GET /system/log/writer，返回日志写入队列状态（排队、写入、丢弃、失败条数）。
+func (h *LogHandler) GetLogWriterStats(c *gin.Context) {
+	var stats syslog.WriterStats
+	if h.writer != nil {
+		stats = h.writer.Stats()
+	}
+	OK(c, stats)
+}
+
 // GetLog 处理 GET /system/log/:id，返回日志详情。
 func (h *LogHandler) GetLog(c *gin.Context) {
 	idVal, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -230,7 +256,15 @@ func (h *LogHandler) GetLog(c *gin.Context) {
 		return
 	}
 
-	const query = `
+	// 不在数据权限范围内的日志按不存在处理
+	scope, err := loadDataScope(c.Request.Context(), h.db, contextUserID(c))
+	if err != nil {
+		Fail(c, "500", "查询日志失败")
+		return
+	}
+	where, args, _ := scope.appendWhere("WHERE t1.id = $1", []any{idVal}, 2, logDataScopeColumns)
+
+	query := `
 SELECT t1.id,
        COALESCE(t1.trace_id, ''),
        t1.description,
@@ -253,15 +287,14 @@ SELECT t1.id,
        COALESCE(t2.nickname, '')
 FROM sys_log AS t1
 LEFT JOIN sys_user AS t2 ON t2.id = t1.create_user
-WHERE t1.id = $1;
-`
+` + where + ";"
 
 	var (
 		resp     LogDetailResp
 		createAt time.Time
 		createBy string
 	)
-	err = h.db.QueryRowContext(c.Request.Context(), query, idVal).Scan(
+	err = h.db.QueryRowContext(c.Request.Context(), query, args...).Scan(
 		&resp.ID,
 		&resp.TraceID,
 		&resp.Description,
@@ -297,18 +330,46 @@ WHERE t1.id = $1;
 	OK(c, resp)
 }
 
-// ExportLoginLog 处理 GET /system/log/export/login，导出登录日志 CSV。
+// loginLogExportColumns 与 Java LoginLogExportResp 的 @ExcelProperty 保持一致。
+var loginLogExportColumns = []exportColumn{
+	{"id", "ID"},
+	{"createTime", "登录时间"},
+	{"createUserString", "用户昵称"},
+	{"description", "登录行为"},
+	{"status", "状态"},
+	{"ip", "登录 IP"},
+	{"address", "登录地点"},
+	{"browser", "浏览器"},
+	{"os", "终端系统"},
+}
+
+// operationLogExportColumns 与 Java OperationLogExportResp 的 @ExcelProperty 保持一致。
+var operationLogExportColumns = []exportColumn{
+	{"id", "ID"},
+	{"createTime", "操作时间"},
+	{"createUserString", "操作人"},
+	{"description", "操作内容"},
+	{"module", "所属模块"},
+	{"status", "状态"},
+	{"ip", "操作 IP"},
+	{"address", "操作地点"},
+	{"timeTaken", "耗时（ms）"},
+	{"browser", "浏览器"},
+	{"os", "终端系统"},
+}
+
+// ExportLoginLog 处理 GET /system/log/export/login，导出登录日志。
 func (h *LogHandler) ExportLoginLog(c *gin.Context) {
-	h.exportLogCSV(c, true)
+	h.exportLog(c, true)
 }
 
-// ExportOperationLog 处理 GET /system/log/export/operation，导出操作日志 CSV。
+// ExportOperationLog 处理 GET /system/log/export/operation，导出操作日志。
 func (h *LogHandler) ExportOperationLog(c *gin.Context) {
-	h.exportLogCSV(c, false)
+	h.exportLog(c, false)
 }
 
-// exportLogCSV 按条件导出登录/操作日志为 CSV。
-func (h *LogHandler) exportLogCSV(c *gin.Context, isLogin bool) {
+// exportLog 按条件导出登录/操作日志，支持 format（xlsx/csv）与 columns 参数。
+func (h *LogHandler) exportLog(c *gin.Context, isLogin bool) {
 	description := strings.TrimSpace(c.Query("description"))
 	module := strings.TrimSpace(c.Query("module"))
 	ip := strings.TrimSpace(c.Query("ip"))
@@ -373,6 +434,13 @@ LEFT JOIN sys_user AS t2 ON t2.id = t1.create_user
 		argPos += 2
 	}
 
+	scope, err := loadDataScope(c.Request.Context(), h.db, contextUserID(c))
+	if err != nil {
+		Fail(c, "500", "导出日志失败")
+		return
+	}
+	where, args, _ = scope.appendWhere(where, args, argPos, logDataScopeColumns)
+
 	// 登录日志/操作日志使用相同数据源，仅导出列标题不同。
 	selectSQL := `
 SELECT t1.id,
@@ -396,123 +464,73 @@ SELECT t1.id,
 	}
 	defer rows.Close()
 
-	type rowItem struct {
-		ID         int64
-		CreateTime time.Time
-		UserNick   string
-		Desc       string
-		Module     string
-		Status     int16
-		IP         string
-		Address    string
-		Browser    string
-		OS         string
-		TimeTaken  int64
-	}
-
-	var items []rowItem
+	columns := operationLogExportColumns
+	name := "操作日志数据"
+	if isLogin {
+		columns = loginLogExportColumns
+		name = "登录日志数据"
+	}
+	exporter := newTableExporter(c, name, columns)
+	if exporter == nil {
+		return
+	}
 	for rows.Next() {
-		var r rowItem
+		var (
+			id                      int64
+			createTime              time.Time
+			userNick, desc, module  string
+			status                  int16
+			ip, address, browser, o string
+			timeTaken               int64
+		)
 		if err := rows.Scan(
-			&r.ID,
-			&r.CreateTime,
-			&r.UserNick,
-			&r.Desc,
-			&r.Module,
-			&r.Status,
-			&r.IP,
-			&r.Address,
-			&r.Browser,
-			&r.OS,
-			&r.TimeTaken,
+			&id,
+			&createTime,
+			&userNick,
+			&desc,
+			&module,
+			&status,
+			&ip,
+			&address,
+			&browser,
+			&o,
+			&timeTaken,
 		); err != nil {
-			Fail(c, "500", "解析日志数据失败")
+			exporter.Finish(c, err)
 			return
 		}
-		items = append(items, r)
-	}
-	if err := rows.Err(); err != nil {
-		Fail(c, "500", "导出日志失败")
-		return
-	}
-
-	if len(items) == 0 {
-		// 无数据时返回一个空文件，避免前端认为是错误响应。
-		c.Header("Content-Type", "text/csv; charset=utf-8")
+		var record []string
 		if isLogin {
-			c.Header("Content-Disposition", "attachment; filename=\"login-log.csv\"")
+			record = []string{
+				strconv.FormatInt(id, 10),
+				formatTime(createTime),
+				userNick,
+				desc,
+				exportLogStatusText(status),
+				ip,
+				address,
+				browser,
+				o,
+			}
 		} else {
-			c.Header("Content-Disposition", "attachment; filename=\"operation-log.csv\"")
-		}
-		c.String(http.StatusOK, "")
-		return
-	}
-
-	c.Header("Content-Type", "text/csv; charset=utf-8")
-	if isLogin {
-		c.Header("Content-Disposition", "attachment; filename=\"login-log.csv\"")
-	} else {
-		c.Header("Content-Disposition", "attachment; filename=\"operation-log.csv\"")
-	}
-
-	// 简单按逗号分隔输出 CSV，字段中若包含逗号/换行可以按需增强转义，这里先满足基础导出需求。
-	w := c.Writer
-
-	if isLogin {
-		// 登录日志导出列
-		fmt.Fprintln(w, "ID,登录时间,用户昵称,登录行为,状态,登录 IP,登录地点,浏览器,终端系统")
-		for _, r := range items {
-			statusText := "成功"
-			if r.Status != 1 {
-				statusText = "失败"
+			record = []string{
+				strconv.FormatInt(id, 10),
+				formatTime(createTime),
+				userNick,
+				desc,
+				module,
+				exportLogStatusText(status),
+				ip,
+				address,
+				strconv.FormatInt(timeTaken, 10),
+				browser,
+				o,
 			}
-			line := fmt.Sprintf("%d,%s,%s,%s,%s,%s,%s,%s,%s",
-				r.ID,
-				formatTime(r.CreateTime),
-				escapeCSV(r.UserNick),
-				escapeCSV(r.Desc),
-				statusText,
-				escapeCSV(r.IP),
-				escapeCSV(r.Address),
-				escapeCSV(r.Browser),
-				escapeCSV(r.OS),
-			)
-			fmt.Fprintln(w, line)
 		}
-	} else {
-		// 操作日志导出列
-		fmt.Fprintln(w, "ID,操作时间,操作人,操作内容,所属模块,状态,操作 IP,操作地点,耗时（ms）,浏览器,终端系统")
-		for _, r := range items {
-			statusText := "成功"
-			if r.Status != 1 {
-				statusText = "失败"
-			}
-			line := fmt.Sprintf("%d,%s,%s,%s,%s,%s,%s,%s,%d,%s,%s",
-				r.ID,
-				formatTime(r.CreateTime),
-				escapeCSV(r.UserNick),
-				escapeCSV(r.Desc),
-				escapeCSV(r.Module),
-				statusText,
-				escapeCSV(r.IP),
-				escapeCSV(r.Address),
-				r.TimeTaken,
-				escapeCSV(r.Browser),
-				escapeCSV(r.OS),
-			)
-			fmt.Fprintln(w, line)
+		if err := exporter.Write(record); err != nil {
+			exporter.Finish(c, err)
+			return
 		}
 	}
-}
-
-// escapeCSV 对包含逗号或引号的字段进行简单转义。
-func escapeCSV(val string) string {
-	if val == "" {
-		return ""
-	}
-	if !strings.ContainsAny(val, ",\"\n\r") {
-		return val
-	}
-	escaped := strings.ReplaceAll(val, `"`, `""`)
-	return `"` + escaped + `"`
+	exporter.Finish(c, rows.Err())
 }
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
-	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -12,31 +11,44 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"voc-go-backend/internal/domain/syslog"
+	"voc-go-backend/internal/infrastructure/clientinfo"
 	"voc-go-backend/internal/infrastructure/security"
+	"voc-go-backend/internal/infrastructure/trace"
 )
 
 // sysLogMiddleware 负责在 HTTP 层统一采集请求/响应信息并写入 sys_log。
 // 设计目标：
 //   - 尽量贴近 Java 版 LogDaoLocalImpl 的字段含义；
 //   - 只记录业务接口（跳过 OPTIONS 等预检请求），减少无效数据；
-//   - 出错时不影响业务请求主流程。
+//   - 出错时不影响业务请求主流程；
+//   - 写入前对令牌、密码、密钥等敏感信息脱敏。
 type sysLogMiddleware struct {
 	repo     syslog.Repository
 	tokenSvc *security.TokenService
+	masker   *syslog.Masker
+	client   *clientinfo.Resolver
 }
 
 // NewSysLogMiddleware 创建 Gin 中间件，用于记录系统操作日志。
-func NewSysLogMiddleware(repo syslog.Repository, tokenSvc *security.TokenService) gin.HandlerFunc {
+// masker 为 nil 时使用默认脱敏规则；client 用于解析 IP 归属地、浏览器与操作系统。
+func NewSysLogMiddleware(repo syslog.Repository, tokenSvc *security.TokenService, masker *syslog.Masker, client *clientinfo.Resolver) gin.HandlerFunc {
+	if masker == nil {
+		masker = syslog.NewMasker(syslog.DefaultMaskConfig())
+	}
 	m := &sysLogMiddleware{
 		repo:     repo,
 		tokenSvc: tokenSvc,
+		masker:   masker,
+		client:   client,
 	}
 	return m.handle
 }
 
 // bodyCaptureWriter 包装 ResponseWriter，用于捕获响应状态码和响应体。
+// 路由声明不记录响应体（或不记录日志）时不缓存响应内容，避免文件下载等占用内存。
 type bodyCaptureWriter struct {
 	gin.ResponseWriter
+	c      *gin.Context
 	status int
 	body   bytes.Buffer
 }
@@ -50,10 +62,42 @@ func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
 	if w.status == 0 {
 		w.status = http.StatusOK
 	}
-	_, _ = w.body.Write(b)
+	if meta := auditMetaFrom(w.c); meta == nil || !(meta.Skip || meta.NoResponseBody) {
+		_, _ = w.body.Write(b)
+	}
 	return w.ResponseWriter.Write(b)
 }
 
+// bodyCaptureReader 包装请求体，在 handler 读取时同步捕获内容。
+// 路由声明不记录请求体（或不记录日志）时不缓存，避免文件上传等占用内存。
+type bodyCaptureReader struct {
+	io.ReadCloser
+	c    *gin.Context
+	body bytes.Buffer
+}
+
+func (r *bodyCaptureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 && r.capture() {
+		_, _ = r.body.Write(p[:n])
+	}
+	return n, err
+}
+
+func (r *bodyCaptureReader) capture() bool {
+	meta := auditMetaFrom(r.c)
+	return meta == nil || !(meta.Skip || meta.NoRequestBody)
+}
+
+// String 返回完整请求体，handler 未读完的部分（如鉴权失败）在此补读。
+func (r *bodyCaptureReader) String() string {
+	if !r.capture() {
+		return ""
+	}
+	_, _ = r.body.ReadFrom(r.ReadCloser)
+	return r.body.String()
+}
+
 // handle 是实际的中间件逻辑。
 func (m *sysLogMiddleware) handle(c *gin.Context) {
 	// 仅记录业务请求，跳过浏览器预检。
@@ -64,17 +108,16 @@ func (m *sysLogMiddleware) handle(c *gin.Context) {
 
 	start := time.Now()
 
-	// 读取并保留请求体，避免影响后续 handler。
-	var reqBody []byte
-	if c.Request.Body != nil {
-		data, _ := io.ReadAll(c.Request.Body)
-		reqBody = data
-		c.Request.Body = io.NopCloser(bytes.NewBuffer(data))
+	// 包装请求体，随 handler 读取一并捕获，不影响后续 handler。
+	var reqCapture *bodyCaptureReader
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		reqCapture = &bodyCaptureReader{ReadCloser: c.Request.Body, c: c}
+		c.Request.Body = reqCapture
 	}
 
 	// 包装响应 writer，捕获状态码与响应内容。
 	origWriter := c.Writer
-	cw := &bodyCaptureWriter{ResponseWriter: origWriter}
+	cw := &bodyCaptureWriter{ResponseWriter: origWriter, c: c}
 	c.Writer = cw
 
 	// 执行后续处理。
@@ -82,6 +125,12 @@ func (m *sysLogMiddleware) handle(c *gin.Context) {
 
 	duration := time.Since(start)
 
+	// 路由声明不记录日志（如健康检查、文件下载）。
+	meta := auditMetaFrom(c)
+	if meta != nil && meta.Skip {
+		return
+	}
+
 	// 如果没有仓储实现，直接返回，不影响主流程。
 	if m.repo == nil {
 		return
@@ -93,21 +142,30 @@ func (m *sysLogMiddleware) handle(c *gin.Context) {
 		path = c.Request.URL.Path
 	}
 
+	ip := c.ClientIP()
+	info := m.client.Resolve(ip, c.Request.UserAgent())
+
+	var reqBody string
+	if reqCapture != nil {
+		reqBody = reqCapture.String()
+	}
+
 	rec := &syslog.Record{
+		TraceID:        traceIDFrom(c),
 		RequestURL:     c.Request.URL.String(),
 		RequestMethod:  c.Request.Method,
 		RequestHeaders: marshalHeaders(c.Request.Header),
-		RequestBody:    string(reqBody),
+		RequestBody:    reqBody,
 		StatusCode:     statusFromWriter(cw),
 		ResponseHeaders: marshalHeaders(
 			http.Header(cw.Header()),
 		),
 		ResponseBody: cw.body.String(),
 		TimeTaken:    duration.Milliseconds(),
-		IP:           truncateString(c.ClientIP(), 100),
-		Address:      "",
-		Browser:      truncateString(c.Request.UserAgent(), 100),
-		OS:           "",
+		IP:           truncateString(ip, 100),
+		Address:      truncateString(info.Address, 255),
+		Browser:      truncateString(info.Browser, 100),
+		OS:           truncateString(info.OS, 100),
 		CreateTime:   start,
 	}
 
@@ -128,13 +186,20 @@ func (m *sysLogMiddleware) handle(c *gin.Context) {
 		}
 	}
 
-	// 按 URL 粗略推断模块与描述，先提供基础能力，后续可按需细化。
-	rec.Module, rec.Description = inferModuleAndDescription(path, c.Request.Method)
+	// 模块与描述取自路由声明（Audit），未声明的路由（如 404）按请求方法与路径记录。
+	if meta != nil {
+		rec.Module, rec.Description = meta.Module, meta.Description
+	} else {
+		rec.Module, rec.Description = "其它", c.Request.Method+" "+path
+	}
+
+	// 落库前脱敏（需在解析 Authorization 之后）。
+	m.masker.Mask(rec)
 
 	// 最终落库，错误不影响业务，但打印错误便于排查。
 	if err := m.repo.Save(c.Request.Context(), rec); err != nil {
 		// 仅打印日志，不向前端暴露内部错误。
-		log.Printf("[syslog] save failed: method=%s path=%s status=%d err=%v",
+		trace.Printf(c.Request.Context(), "[syslog] save failed: method=%s path=%s status=%d err=%v",
 			c.Request.Method, path, rec.StatusCode, err)
 	}
 }
@@ -163,39 +228,6 @@ func statusFromWriter(w *bodyCaptureWriter) int {
 	return w.status
 }
 
-// inferModuleAndDescription 根据路由前缀推断所属模块和简单描述。
-// 这里仅做基础映射，用于满足前端系统日志展示需求。
-func inferModuleAndDescription(path, method string) (module string, desc string) {
-	switch {
-	case strings.HasPrefix(path, "/auth/login"):
-		return "登录", "用户登录"
-	case strings.HasPrefix(path, "/auth/logout"):
-		return "登录", "用户退出登录"
-	case strings.HasPrefix(path, "/system/user"):
-		return "用户管理", method + " /system/user"
-	case strings.HasPrefix(path, "/system/role"):
-		return "角色管理", method + " /system/role"
-	case strings.HasPrefix(path, "/system/dept"):
-		return "部门管理", method + " /system/dept"
-	case strings.HasPrefix(path, "/system/menu"):
-		return "菜单管理", method + " /system/menu"
-	case strings.HasPrefix(path, "/system/dict"):
-		return "字典管理", method + " /system/dict"
-	case strings.HasPrefix(path, "/system/config"):
-		return "系统配置", method + " /system/config"
-	case strings.HasPrefix(path, "/system/storage"):
-		return "存储配置", method + " /system/storage"
-	case strings.HasPrefix(path, "/system/client"):
-		return "客户端配置", method + " /system/client"
-	case strings.HasPrefix(path, "/monitor/online"):
-		return "在线用户", method + " /monitor/online"
-	case strings.HasPrefix(path, "/monitor/log"):
-		return "系统日志", method + " /monitor/log"
-	default:
-		return "其它", method + " " + path
-	}
-}
-
 // truncateString 用于避免写入超过数据库字段长度的字符串。
 func truncateString(s string, max int) string {
 	if max <= 0 {
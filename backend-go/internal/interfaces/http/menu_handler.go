@@ -65,13 +65,13 @@ func NewMenuHandler(db *sql.DB, tokenSvc *security.TokenService) *MenuHandler {
 }
 
 // RegisterMenuRoutes registers menu management routes.
-func (h *MenuHandler) RegisterMenuRoutes(r *gin.Engine) {
-	r.GET("/system/menu/tree", h.ListMenuTree)
-	r.GET("/system/menu/:id", h.GetMenu)
-	r.POST("/system/menu", h.CreateMenu)
-	r.PUT("/system/menu/:id", h.UpdateMenu)
-	r.DELETE("/system/menu", h.DeleteMenu)
-	r.DELETE("/system/menu/cache", h.ClearMenuCache)
+func (h *MenuHandler) RegisterMenuRoutes(r *gin.Engine, am *AuthMiddleware) {
+	r.GET("/system/menu/tree", Audit("菜单管理", "查询菜单树"), am.RequirePermission("system:menu:list"), h.ListMenuTree)
+	r.GET("/system/menu/:id", Audit("菜单管理", "查询菜单详情"), am.RequirePermission("system:menu:get"), h.GetMenu)
+	r.POST("/system/menu", Audit("菜单管理", "新增菜单"), am.RequirePermission("system:menu:create"), h.CreateMenu)
+	r.PUT("/system/menu/:id", Audit("菜单管理", "修改菜单"), am.RequirePermission("system:menu:update"), h.UpdateMenu)
+	r.DELETE("/system/menu", Audit("菜单管理", "删除菜单"), am.RequirePermission("system:menu:delete"), h.DeleteMenu)
+	r.DELETE("/system/menu/cache", Audit("菜单管理", "清除菜单缓存"), am.RequirePermission("system:menu:clearCache"), h.ClearMenuCache)
 }
 
 // ListMenuTree handles GET /system/menu/tree.
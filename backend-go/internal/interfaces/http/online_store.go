@@ -0,0 +1,257 @@
+package http
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"voc-go-backend/internal/application/auth"
+	"voc-go-backend/internal/infrastructure/clientinfo"
+	"voc-go-backend/internal/infrastructure/security"
+)
+
+// OnlineSession 表示一条在线会话记录。
+// ExpireTime 为会话绝对过期时间（零值表示不限制），与 Token 过期时间一致；
+// ActiveTimeout 为空闲超时时长（<=0 表示不限制），对应 sys_client.active_timeout。
+type OnlineSession struct {
+	UserID         int64
+	Username       string
+	Nickname       string
+	Token          string
+	ClientType     string
+	ClientID       string
+	IP             string
+	Address        string
+	Browser        string
+	OS             string
+	LoginTime      time.Time
+	LastActiveTime time.Time
+	ExpireTime     time.Time
+	ActiveTimeout  time.Duration
+}
+
+// ttl 返回会话在 now 时刻的剩余有效期：
+//   - < 0：已过期（超过绝对有效期或空闲超时）；
+//   - = 0：永不过期；
+//   - > 0：剩余时长。
+func (s *OnlineSession) ttl(now time.Time) time.Duration {
+	var ttl time.Duration
+	if !s.ExpireTime.IsZero() {
+		ttl = s.ExpireTime.Sub(now)
+		if ttl <= 0 {
+			return -1
+		}
+	}
+	if s.ActiveTimeout > 0 {
+		idle := s.LastActiveTime.Add(s.ActiveTimeout).Sub(now)
+		if idle <= 0 {
+			return -1
+		}
+		if ttl == 0 || idle < ttl {
+			ttl = idle
+		}
+	}
+	return ttl
+}
+
+// OnlineStore 抽象在线会话存储。
+// 生产环境使用 RedisOnlineStore 以便多实例共享、重启不丢失；
+// MemoryOnlineStore 仅在当前进程内有效，适用于本地开发。
+type OnlineStore interface {
+	// Save 保存（覆盖）一条在线会话。
+	Save(ctx context.Context, sess *OnlineSession) error
+	// Touch 刷新会话最后活跃时间；会话不存在或已过期时返回 false。
+	Touch(ctx context.Context, token string) (bool, error)
+	// RemoveByToken 根据 token 移除在线会话。
+	RemoveByToken(ctx context.Context, token string) error
+	// List 返回全部未过期的在线会话。
+	List(ctx context.Context) ([]*OnlineSession, error)
+}
+
+// revokeUserSessions 吊销指定用户全部在线会话的 Token 并移除会话，
+// 用于修改或重置密码、导入覆盖密码等场景，使旧密码登录的会话立即失效。
+func revokeUserSessions(ctx context.Context, store OnlineStore, tokenSvc *security.TokenService, userIDs ...int64) error {
+	if store == nil || len(userIDs) == 0 {
+		return nil
+	}
+	targets := make(map[int64]struct{}, len(userIDs))
+	for _, uid := range userIDs {
+		targets[uid] = struct{}{}
+	}
+	sessions, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, sess := range sessions {
+		if _, ok := targets[sess.UserID]; !ok {
+			continue
+		}
+		if err := tokenSvc.Revoke(ctx, sess.Token); err != nil {
+			return err
+		}
+		if err := store.RemoveByToken(ctx, sess.Token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newOnlineSession 根据登录结果构建在线会话，
+// 过期时间与 Token 一致，空闲超时取自客户端 active_timeout，登录地点、浏览器与操作系统由 client 解析。
+func newOnlineSession(c *gin.Context, resp *auth.LoginResponse, client *clientinfo.Resolver) *OnlineSession {
+	now := time.Now()
+	ip := c.ClientIP()
+	info := client.Resolve(ip, c.Request.UserAgent())
+	return &OnlineSession{
+		UserID:         resp.UserID,
+		Username:       resp.Username,
+		Nickname:       resp.Nickname,
+		Token:          resp.Token,
+		ClientType:     resp.ClientType,
+		ClientID:       resp.ClientID,
+		IP:             ip,
+		Address:        info.Address,
+		Browser:        info.Browser,
+		OS:             info.OS,
+		LoginTime:      now,
+		LastActiveTime: now,
+		ExpireTime:     resp.ExpireTime,
+		ActiveTimeout:  resp.IdleTimeout,
+	}
+}
+
+// pageOnlineSessions 按昵称/登录时间过滤，并按登录时间倒序分页。
+func pageOnlineSessions(sessions []*OnlineSession, nickname string, loginStart, loginEnd *time.Time, page, size int) ([]OnlineUserResp, int64) {
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = 10
+	}
+
+	var filtered []*OnlineSession
+	for _, sess := range sessions {
+		if nickname != "" &&
+			!strings.Contains(sess.Username, nickname) &&
+			!strings.Contains(sess.Nickname, nickname) {
+			continue
+		}
+		if loginStart != nil && sess.LoginTime.Before(*loginStart) {
+			continue
+		}
+		if loginEnd != nil && sess.LoginTime.After(*loginEnd) {
+			continue
+		}
+		filtered = append(filtered, sess)
+	}
+
+	// 按登录时间倒序排序
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].LoginTime.After(filtered[j].LoginTime)
+	})
+
+	total := int64(len(filtered))
+	start := (page - 1) * size
+	if start > len(filtered) {
+		start = len(filtered)
+	}
+	end := start + size
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+
+	result := make([]OnlineUserResp, 0, end-start)
+	for _, sess := range filtered[start:end] {
+		result = append(result, OnlineUserResp{
+			ID:             sess.UserID,
+			Token:          sess.Token,
+			Username:       sess.Username,
+			Nickname:       sess.Nickname,
+			ClientType:     sess.ClientType,
+			ClientID:       sess.ClientID,
+			IP:             sess.IP,
+			Address:        sess.Address,
+			Browser:        sess.Browser,
+			OS:             sess.OS,
+			LoginTime:      formatTime(sess.LoginTime),
+			LastActiveTime: formatTime(sess.LastActiveTime),
+		})
+	}
+	return result, total
+}
+
+// MemoryOnlineStore 维护当前进程内的在线会话信息（开发环境使用）。
+type MemoryOnlineStore struct {
+	mu       sync.Mutex
+	sessions map[string]*OnlineSession
+}
+
+// NewMemoryOnlineStore 创建内存在线会话存储。
+func NewMemoryOnlineStore() *MemoryOnlineStore {
+	return &MemoryOnlineStore{
+		sessions: make(map[string]*OnlineSession),
+	}
+}
+
+// Save 保存在线会话。
+func (s *MemoryOnlineStore) Save(_ context.Context, sess *OnlineSession) error {
+	if sess == nil || sess.UserID == 0 || sess.Token == "" {
+		return nil
+	}
+	cp := *sess
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[sess.Token] = &cp
+	return nil
+}
+
+// Touch 刷新最后活跃时间，过期会话被顺带清理。
+func (s *MemoryOnlineStore) Touch(_ context.Context, token string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[token]
+	if !ok {
+		return false, nil
+	}
+	now := time.Now()
+	if sess.ttl(now) < 0 {
+		delete(s.sessions, token)
+		return false, nil
+	}
+	sess.LastActiveTime = now
+	return true, nil
+}
+
+// RemoveByToken 根据 token 移除在线会话。
+func (s *MemoryOnlineStore) RemoveByToken(_ context.Context, token string) error {
+	if token == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, token)
+	return nil
+}
+
+// List 返回未过期的在线会话副本。
+func (s *MemoryOnlineStore) List(_ context.Context) ([]*OnlineSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	list := make([]*OnlineSession, 0, len(s.sessions))
+	for token, sess := range s.sessions {
+		if sess.ttl(now) < 0 {
+			delete(s.sessions, token)
+			continue
+		}
+		cp := *sess
+		list = append(list, &cp)
+	}
+	return list, nil
+}
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// onlineSessionKeyPrefix 在线会话 key：ONLINE_SESSION:{token}，value 为会话 JSON。
+	onlineSessionKeyPrefix = "ONLINE_SESSION:"
+	// onlineSessionIndexKey 在线会话索引（ZSET，member 为 token，score 为登录时间毫秒）。
+	onlineSessionIndexKey = "ONLINE_SESSION_INDEX"
+)
+
+// RedisOnlineStore 基于 Redis 的在线会话存储，多实例共享同一份在线列表。
+// 会话 key 的过期时间取绝对有效期与空闲超时中较早者，每次请求刷新；
+// 索引中残留的过期 token 在 List 时清理。
+type RedisOnlineStore struct {
+	client *redis.Client
+}
+
+// NewRedisOnlineStore 创建 Redis 在线会话存储。
+func NewRedisOnlineStore(client *redis.Client) *RedisOnlineStore {
+	return &RedisOnlineStore{client: client}
+}
+
+// Save 写入会话并加入索引。
+func (s *RedisOnlineStore) Save(ctx context.Context, sess *OnlineSession) error {
+	if sess == nil || sess.UserID == 0 || sess.Token == "" {
+		return nil
+	}
+	ttl := sess.ttl(time.Now())
+	if ttl < 0 {
+		return nil
+	}
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, onlineSessionKeyPrefix+sess.Token, data, ttl)
+	pipe.ZAdd(ctx, onlineSessionIndexKey, redis.Z{
+		Score:  float64(sess.LoginTime.UnixMilli()),
+		Member: sess.Token,
+	})
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Touch 刷新最后活跃时间并顺延 key 过期时间，会话已被删除时返回 false。
+func (s *RedisOnlineStore) Touch(ctx context.Context, token string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+	sess, err := s.get(ctx, token)
+	if err != nil || sess == nil {
+		return false, err
+	}
+	now := time.Now()
+	if sess.ttl(now) < 0 {
+		return false, s.RemoveByToken(ctx, token)
+	}
+	sess.LastActiveTime = now
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return false, err
+	}
+	// SET ... XX：仅在 key 仍存在时覆盖，读取后被强退/登出删除的会话不会被重新写回。
+	err = s.client.SetArgs(ctx, onlineSessionKeyPrefix+token, data, redis.SetArgs{
+		Mode: "XX",
+		TTL:  sess.ttl(now),
+	}).Err()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveByToken 删除会话及其索引。
+func (s *RedisOnlineStore) RemoveByToken(ctx context.Context, token string) error {
+	if token == "" {
+		return nil
+	}
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, onlineSessionKeyPrefix+token)
+	pipe.ZRem(ctx, onlineSessionIndexKey, token)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// List 读取索引中的全部会话，已过期的 token 从索引中移除。
+func (s *RedisOnlineStore) List(ctx context.Context) ([]*OnlineSession, error) {
+	tokens, err := s.client.ZRange(ctx, onlineSessionIndexKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(tokens))
+	for i, token := range tokens {
+		keys[i] = onlineSessionKeyPrefix + token
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var (
+		list  = make([]*OnlineSession, 0, len(values))
+		stale []any
+	)
+	for i, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			stale = append(stale, tokens[i])
+			continue
+		}
+		var sess OnlineSession
+		if err := json.Unmarshal([]byte(raw), &sess); err != nil || sess.ttl(now) < 0 {
+			stale = append(stale, tokens[i])
+			continue
+		}
+		list = append(list, &sess)
+	}
+	if len(stale) > 0 {
+		_ = s.client.ZRem(ctx, onlineSessionIndexKey, stale...).Err()
+	}
+	return list, nil
+}
+
+func (s *RedisOnlineStore) get(ctx context.Context, token string) (*OnlineSession, error) {
+	raw, err := s.client.Get(ctx, onlineSessionKeyPrefix+token).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sess OnlineSession
+	if err := json.Unmarshal(raw, &sess); err != nil {
+		return nil, nil
+	}
+	return &sess, nil
+}
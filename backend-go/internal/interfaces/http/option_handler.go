@@ -41,11 +41,25 @@ func NewOptionHandler(db *sql.DB, tokenSvc *security.TokenService) *OptionHandle
 	}
 }
 
+// 系统配置按类别拆分为多个页签，拥有任一页签的查看/修改权限即可访问对应接口。
+var (
+	optionGetPerms = []string{
+		"system:siteConfig:get",
+		"system:securityConfig:get",
+		"system:loginConfig:get",
+	}
+	optionUpdatePerms = []string{
+		"system:siteConfig:update",
+		"system:securityConfig:update",
+		"system:loginConfig:update",
+	}
+)
+
 // RegisterOptionRoutes registers /system/option endpoints.
-func (h *OptionHandler) RegisterOptionRoutes(r *gin.Engine) {
-	r.GET("/system/option", h.ListOption)
-	r.PUT("/system/option", h.UpdateOption)
-	r.PATCH("/system/option/value", h.ResetOptionValue)
+func (h *OptionHandler) RegisterOptionRoutes(r *gin.Engine, am *AuthMiddleware) {
+	r.GET("/system/option", Audit("系统配置", "查询系统配置"), am.RequirePermission(optionGetPerms...), h.ListOption)
+	r.PUT("/system/option", Audit("系统配置", "修改系统配置"), am.RequirePermission(optionUpdatePerms...), h.UpdateOption)
+	r.PATCH("/system/option/value", Audit("系统配置", "恢复默认配置"), am.RequirePermission(optionUpdatePerms...), h.ResetOptionValue)
 }
 
 // currentUserID parses token and returns userID; shared with other handlers.
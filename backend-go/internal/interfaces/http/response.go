@@ -15,6 +15,8 @@ type APIResponse[T any] struct {
 	Msg       string `json:"msg"`
 	Success   bool   `json:"success"`
 	Timestamp string `json:"timestamp"`
+	// TraceID 为请求的链路 ID，可据此在系统日志中定位请求
+	TraceID string `json:"traceId,omitempty"`
 }
 
 // PageResult represents a generic paginated result.
@@ -37,6 +39,7 @@ func OK[T any](c *gin.Context, data T) {
 		Msg:       "操作成功",
 		Success:   true,
 		Timestamp: nowString(),
+		TraceID:   traceIDFrom(c),
 	}
 	c.JSON(http.StatusOK, resp)
 }
@@ -49,6 +52,7 @@ func Fail(c *gin.Context, code, msg string) {
 		Msg:       msg,
 		Success:   false,
 		Timestamp: nowString(),
+		TraceID:   traceIDFrom(c),
 	}
 	c.JSON(http.StatusOK, resp)
 }
@@ -81,18 +81,18 @@ func NewRoleHandler(db *sql.DB, tokenSvc *security.TokenService) *RoleHandler {
 }
 
 // RegisterRoleRoutes registers role management routes.
-func (h *RoleHandler) RegisterRoleRoutes(r *gin.Engine) {
-	r.GET("/system/role/list", h.ListRole)
-	r.GET("/system/role/:id", h.GetRole)
-	r.POST("/system/role", h.CreateRole)
-	r.PUT("/system/role/:id", h.UpdateRole)
-	r.DELETE("/system/role", h.DeleteRole)
-
-	r.PUT("/system/role/:id/permission", h.UpdateRolePermission)
-	r.GET("/system/role/:id/user", h.PageRoleUser)
-	r.POST("/system/role/:id/user", h.AssignToUsers)
-	r.DELETE("/system/role/user", h.UnassignFromUsers)
-	r.GET("/system/role/:id/user/id", h.ListRoleUserIDs)
+func (h *RoleHandler) RegisterRoleRoutes(r *gin.Engine, am *AuthMiddleware) {
+	r.GET("/system/role/list", Audit("角色管理", "查询角色列表"), am.RequirePermission("system:role:list"), h.ListRole)
+	r.GET("/system/role/:id", Audit("角色管理", "查询角色详情"), am.RequirePermission("system:role:get"), h.GetRole)
+	r.POST("/system/role", Audit("角色管理", "新增角色"), am.RequirePermission("system:role:create"), h.CreateRole)
+	r.PUT("/system/role/:id", Audit("角色管理", "修改角色"), am.RequirePermission("system:role:update"), h.UpdateRole)
+	r.DELETE("/system/role", Audit("角色管理", "删除角色"), am.RequirePermission("system:role:delete"), h.DeleteRole)
+
+	r.PUT("/system/role/:id/permission", Audit("角色管理", "修改角色权限"), am.RequirePermission("system:role:updatePermission"), h.UpdateRolePermission)
+	r.GET("/system/role/:id/user", Audit("角色管理", "分页查询角色关联用户"), am.RequirePermission("system:role:list"), h.PageRoleUser)
+	r.POST("/system/role/:id/user", Audit("角色管理", "分配角色给用户"), am.RequirePermission("system:role:assign"), h.AssignToUsers)
+	r.DELETE("/system/role/user", Audit("角色管理", "取消分配角色"), am.RequirePermission("system:role:unassign"), h.UnassignFromUsers)
+	r.GET("/system/role/:id/user/id", Audit("角色管理", "查询角色关联用户 ID"), am.RequirePermission("system:role:list"), h.ListRoleUserIDs)
 }
 
 func (h *RoleHandler) currentUserID(c *gin.Context) int64 {
@@ -8,9 +8,11 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 
 	"voc-go-backend/internal/infrastructure/id"
 	"voc-go-backend/internal/infrastructure/security"
+	"voc-go-backend/internal/infrastructure/storage"
 )
 
 // StorageResp 对应前端 StorageResp 类型，用于存储配置列表与详情展示。
@@ -27,6 +29,9 @@ type StorageResp struct {
 	Domain           string `json:"domain"`
 	Description      string `json:"description"`
 	IsDefault        bool   `json:"isDefault"`
+	PublicAccess     bool   `json:"publicAccess"`
+	Quota            int64  `json:"quota"`
+	UsedSize         int64  `json:"usedSize"`
 	Sort             int32  `json:"sort"`
 	Status           int16  `json:"status"`
 	CreateUserString string `json:"createUserString"`
@@ -48,8 +53,12 @@ type storageReq struct {
 	Domain      string  `json:"domain"`
 	Description string  `json:"description"`
 	IsDefault   *bool   `json:"isDefault"`
-	Sort        int32   `json:"sort"`
-	Status      int16   `json:"status"`
+	// PublicAccess 为 false 时不生成公开访问 URL，文件只能通过下载接口访问。
+	PublicAccess *bool `json:"publicAccess"`
+	// Quota 存储容量上限（字节），0 表示不限制，修改时不传则保持原值。
+	Quota  *int64 `json:"quota"`
+	Sort   int32  `json:"sort"`
+	Status int16  `json:"status"`
 }
 
 // StorageHandler 提供 /system/storage 相关接口。
@@ -57,25 +66,35 @@ type StorageHandler struct {
 	db           *sql.DB
 	tokenSvc     *security.TokenService
 	rsaDecryptor *security.RSADecryptor
+	storages     *storage.Manager
+	// files 用于存储迁移时复用文件对象的读写与配额逻辑
+	files *FileHandler
+	redis *redis.Client
 }
 
-func NewStorageHandler(db *sql.DB, tokenSvc *security.TokenService, rsa *security.RSADecryptor) *StorageHandler {
+func NewStorageHandler(db *sql.DB, tokenSvc *security.TokenService, rsa *security.RSADecryptor, storages *storage.Manager, files *FileHandler, redisClient *redis.Client) *StorageHandler {
 	return &StorageHandler{
 		db:           db,
 		tokenSvc:     tokenSvc,
 		rsaDecryptor: rsa,
+		storages:     storages,
+		files:        files,
+		redis:        redisClient,
 	}
 }
 
 // RegisterStorageRoutes 注册存储配置相关路由。
-func (h *StorageHandler) RegisterStorageRoutes(r *gin.Engine) {
-	r.GET("/system/storage/list", h.ListStorage)
-	r.GET("/system/storage/:id", h.GetStorage)
-	r.POST("/system/storage", h.CreateStorage)
-	r.PUT("/system/storage/:id", h.UpdateStorage)
-	r.DELETE("/system/storage", h.DeleteStorage)
-	r.PUT("/system/storage/:id/status", h.UpdateStorageStatus)
-	r.PUT("/system/storage/:id/default", h.SetDefaultStorage)
+func (h *StorageHandler) RegisterStorageRoutes(r *gin.Engine, am *AuthMiddleware) {
+	r.GET("/system/storage/list", Audit("存储配置", "查询存储列表"), am.RequirePermission("system:storage:list"), h.ListStorage)
+	r.GET("/system/storage/:id", Audit("存储配置", "查询存储详情"), am.RequirePermission("system:storage:get"), h.GetStorage)
+	r.POST("/system/storage/test", Audit("存储配置", "测试存储连接"), am.RequirePermission("system:storage:test"), h.TestStorage)
+	r.POST("/system/storage/migrate", Audit("存储配置", "迁移存储"), am.RequirePermission("system:storage:migrate"), h.MigrateStorage)
+	r.GET("/system/storage/migrate/:taskId", Audit("存储配置", "查询存储迁移进度"), am.RequirePermission("system:storage:migrate"), h.GetStorageMigrate)
+	r.POST("/system/storage", Audit("存储配置", "新增存储"), am.RequirePermission("system:storage:create"), h.CreateStorage)
+	r.PUT("/system/storage/:id", Audit("存储配置", "修改存储"), am.RequirePermission("system:storage:update"), h.UpdateStorage)
+	r.DELETE("/system/storage", Audit("存储配置", "删除存储"), am.RequirePermission("system:storage:delete"), h.DeleteStorage)
+	r.PUT("/system/storage/:id/status", Audit("存储配置", "修改存储状态"), am.RequirePermission("system:storage:updateStatus"), h.UpdateStorageStatus)
+	r.PUT("/system/storage/:id/default", Audit("存储配置", "设为默认存储"), am.RequirePermission("system:storage:setDefault"), h.SetDefaultStorage)
 }
 
 func (h *StorageHandler) currentUserID(c *gin.Context) int64 {
@@ -148,6 +167,9 @@ SELECT s.id,
        COALESCE(s.domain, ''),
        COALESCE(s.description, ''),
        s.is_default,
+       COALESCE(s.public_access, TRUE),
+       COALESCE(s.quota, 0),
+       COALESCE((SELECT SUM(b.size + COALESCE(b.thumbnail_size, 0)) FROM sys_file_blob AS b WHERE b.storage_id = s.id), 0),
        COALESCE(s.sort, 999),
        s.status,
        s.create_time,
@@ -188,6 +210,9 @@ ORDER BY s.sort ASC, s.id ASC;
 			&item.Domain,
 			&item.Description,
 			&item.IsDefault,
+			&item.PublicAccess,
+			&item.Quota,
+			&item.UsedSize,
 			&item.Sort,
 			&item.Status,
 			&createAt,
@@ -235,6 +260,9 @@ SELECT s.id,
        COALESCE(s.domain, ''),
        COALESCE(s.description, ''),
        s.is_default,
+       COALESCE(s.public_access, TRUE),
+       COALESCE(s.quota, 0),
+       COALESCE((SELECT SUM(b.size + COALESCE(b.thumbnail_size, 0)) FROM sys_file_blob AS b WHERE b.storage_id = s.id), 0),
        COALESCE(s.sort, 999),
        s.status,
        s.create_time,
@@ -266,6 +294,9 @@ WHERE s.id = $1;
 			&resp.Domain,
 			&resp.Description,
 			&resp.IsDefault,
+			&resp.PublicAccess,
+			&resp.Quota,
+			&resp.UsedSize,
 			&resp.Sort,
 			&resp.Status,
 			&createAt,
@@ -317,6 +348,10 @@ func (h *StorageHandler) CreateStorage(c *gin.Context) {
 	if req.Type == 0 {
 		req.Type = 1
 	}
+	if req.Quota != nil && *req.Quota < 0 {
+		Fail(c, "400", "存储配额不能小于 0")
+		return
+	}
 	if req.Sort <= 0 {
 		req.Sort = 999
 	}
@@ -354,18 +389,26 @@ INSERT INTO sys_storage (
     id, name, code, type, access_key, secret_key, endpoint,
     region,
     bucket_name, domain, description, is_default, sort, status,
-    create_user, create_time
+    create_user, create_time, public_access, quota
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7,
     $8,
     $9, $10, $11, $12, $13, $14,
-    $15, $16
+    $15, $16, $17, $18
 );
 `
 	isDefault := false
 	if req.IsDefault != nil {
 		isDefault = *req.IsDefault
 	}
+	publicAccess := true
+	if req.PublicAccess != nil {
+		publicAccess = *req.PublicAccess
+	}
+	var quota int64
+	if req.Quota != nil {
+		quota = *req.Quota
+	}
 
 	if _, err := h.db.ExecContext(
 		c.Request.Context(),
@@ -386,6 +429,8 @@ INSERT INTO sys_storage (
 		req.Status,
 		userID,
 		now,
+		publicAccess,
+		quota,
 	); err != nil {
 		Fail(c, "500", "新增存储配置失败")
 		return
@@ -420,6 +465,10 @@ func (h *StorageHandler) UpdateStorage(c *gin.Context) {
 		Fail(c, "400", "名称不能为空")
 		return
 	}
+	if req.Quota != nil && *req.Quota < 0 {
+		Fail(c, "400", "存储配额不能小于 0")
+		return
+	}
 	if req.Sort <= 0 {
 		req.Sort = 999
 	}
@@ -466,7 +515,9 @@ UPDATE sys_storage
        sort = $10,
        status = $11,
        update_user = $12,
-       update_time = $13
+       update_time = $13,
+       public_access = COALESCE($15, public_access),
+       quota = COALESCE($16, quota)
  WHERE id = $14;
 `
 		if _, err := h.db.ExecContext(
@@ -486,6 +537,8 @@ UPDATE sys_storage
 			userID,
 			now,
 			idVal,
+			req.PublicAccess,
+			req.Quota,
 		); err != nil {
 			Fail(c, "500", "修改存储配置失败")
 			return
@@ -504,7 +557,9 @@ UPDATE sys_storage
        sort = $9,
        status = $10,
        update_user = $11,
-       update_time = $12
+       update_time = $12,
+       public_access = COALESCE($14, public_access),
+       quota = COALESCE($15, quota)
  WHERE id = $13;
 `
 		if _, err := h.db.ExecContext(
@@ -523,6 +578,8 @@ UPDATE sys_storage
 			userID,
 			now,
 			idVal,
+			req.PublicAccess,
+			req.Quota,
 		); err != nil {
 			Fail(c, "500", "修改存储配置失败")
 			return
@@ -576,6 +633,9 @@ func (h *StorageHandler) DeleteStorage(c *gin.Context) {
 		Fail(c, "500", "删除存储配置失败")
 		return
 	}
+	for _, idVal := range req.IDs {
+		h.storages.Evict(idVal)
+	}
 	OK(c, true)
 }
 
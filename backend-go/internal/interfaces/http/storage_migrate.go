@@ -0,0 +1,625 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"voc-go-backend/internal/infrastructure/id"
+	"voc-go-backend/internal/infrastructure/storage"
+)
+
+// storageTestTimeout 连通性测试超时时间。
+const storageTestTimeout = 15 * time.Second
+
+// storageTestReq 对应 POST /system/storage/test。
+// 修改已有存储时传入 ID，未修改密钥（secretKey 为空）时使用已保存的密钥。
+type storageTestReq struct {
+	ID int64 `json:"id"`
+	storageReq
+}
+
+// StorageTestResp 为连通性测试结果。
+type StorageTestResp struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	// Elapsed 耗时（毫秒）
+	Elapsed int64 `json:"elapsed"`
+}
+
+// TestStorage handles POST /system/storage/test，使用表单中的配置写入、查询并删除一个临时文件。
+func (h *StorageHandler) TestStorage(c *gin.Context) {
+	var req storageTestReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Fail(c, "400", "请求参数不正确")
+		return
+	}
+	if req.Type == 0 {
+		req.Type = storage.TypeLocal
+	}
+	ctx := c.Request.Context()
+
+	oldSecret := ""
+	if req.ID != 0 {
+		const selectOld = `SELECT COALESCE(secret_key, '') FROM sys_storage WHERE id = $1;`
+		if err := h.db.QueryRowContext(ctx, selectOld, req.ID).Scan(&oldSecret); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				Fail(c, "404", "存储配置不存在")
+				return
+			}
+			Fail(c, "500", "查询存储配置失败")
+			return
+		}
+	}
+	secretVal := ""
+	if req.Type == storage.TypeS3 {
+		var err error
+		if secretVal, err = h.decryptSecretKey(req.SecretKey, oldSecret); err != nil {
+			Fail(c, "400", err.Error())
+			return
+		}
+	}
+
+	cfg := &storage.Config{
+		Type:       req.Type,
+		AccessKey:  strings.TrimSpace(req.AccessKey),
+		SecretKey:  secretVal,
+		Endpoint:   strings.TrimSpace(req.Endpoint),
+		BucketName: strings.TrimSpace(req.BucketName),
+		Domain:     strings.TrimSpace(req.Domain),
+		Region:     strings.TrimSpace(req.Region),
+	}
+	if cfg.Type == storage.TypeLocal && cfg.BucketName == "" {
+		cfg.BucketName = storage.DefaultLocalBucket
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, storageTestTimeout)
+	defer cancel()
+	start := time.Now()
+	// 使用独立的驱动实例，避免未保存的配置进入驱动缓存
+	driver, err := storage.Open(cfg)
+	if err == nil {
+		err = storage.Probe(probeCtx, driver)
+	}
+	resp := StorageTestResp{
+		Success: err == nil,
+		Message: "连接成功",
+		Elapsed: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		resp.Message = storageTestMessage(err)
+	}
+	OK(c, resp)
+}
+
+// storageTestMessage 将连通性测试错误转换为提示信息。
+func storageTestMessage(err error) string {
+	var probeErr *storage.ProbeError
+	if !errors.As(err, &probeErr) {
+		return "创建存储客户端失败：" + err.Error()
+	}
+	switch probeErr.Step {
+	case "put":
+		return "写入测试文件失败：" + probeErr.Err.Error()
+	case "stat":
+		return "读取测试文件失败：" + probeErr.Err.Error()
+	default:
+		return "删除测试文件失败：" + probeErr.Err.Error()
+	}
+}
+
+const (
+	// storageMigrateKeyPrefix 迁移任务进度：SYSTEM:STORAGE_MIGRATE:{taskId}
+	storageMigrateKeyPrefix = "SYSTEM:STORAGE_MIGRATE:"
+	// storageMigrateLockKey 同一时间只允许一个迁移任务，值为任务 ID
+	storageMigrateLockKey = storageMigrateKeyPrefix + "LOCK"
+	storageMigrateTTL     = 24 * time.Hour
+	// storageMigrateLockTTL 任务运行期间定时续期（含复制大文件期间），进程退出后锁自动过期
+	storageMigrateLockTTL   = 10 * time.Minute
+	storageMigrateLockRenew = storageMigrateLockTTL / 3
+	storageMigrateBatch     = 100
+)
+
+// 任务锁仅在值仍为本任务 ID 时续期或释放，锁过期后被其他任务取得时不受影响。
+var (
+	storageMigrateRenewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+	storageMigrateReleaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+)
+
+// 迁移任务状态：1=进行中，2=已完成，3=失败（部分文件迁移失败或任务中断）。
+const (
+	storageMigrateRunning int16 = 1
+	storageMigrateSuccess int16 = 2
+	storageMigrateFailed  int16 = 3
+)
+
+// storageMigrateReq 对应 POST /system/storage/migrate。
+type storageMigrateReq struct {
+	SourceID int64 `json:"sourceId"`
+	TargetID int64 `json:"targetId"`
+	// DeleteSource 为 true 时迁移成功后删除源存储中的文件，默认保留以便核对
+	DeleteSource bool `json:"deleteSource"`
+}
+
+// StorageMigrateProgress 为迁移任务进度，按存储中的对象（去重后的物理文件）统计。
+type StorageMigrateProgress struct {
+	TaskID     string `json:"taskId"`
+	SourceID   int64  `json:"sourceId"`
+	SourceName string `json:"sourceName"`
+	TargetID   int64  `json:"targetId"`
+	TargetName string `json:"targetName"`
+	Status     int16  `json:"status"`
+	Total      int64  `json:"total"`
+	Migrated   int64  `json:"migrated"`
+	// Skipped 为迁移过程中已被删除的对象
+	Skipped int64 `json:"skipped"`
+	Failed  int64 `json:"failed"`
+	// Message 为最近一次失败原因
+	Message   string `json:"message"`
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+}
+
+// storageMigration 为运行中的迁移任务。
+type storageMigration struct {
+	progress     *StorageMigrateProgress
+	src, dst     *storage.Config
+	srcDriver    storage.Driver
+	dstDriver    storage.Driver
+	deleteSource bool
+}
+
+// MigrateStorage handles POST /system/storage/migrate，在后台将源存储中的全部文件迁移到目标存储，
+// 返回任务进度，之后通过 GET /system/storage/migrate/:taskId 查询。
+func (h *StorageHandler) MigrateStorage(c *gin.Context) {
+	var req storageMigrateReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.SourceID <= 0 || req.TargetID <= 0 {
+		Fail(c, "400", "请选择源存储和目标存储")
+		return
+	}
+	if req.SourceID == req.TargetID {
+		Fail(c, "400", "源存储和目标存储不能相同")
+		return
+	}
+	ctx := c.Request.Context()
+
+	src, err := h.files.getStorageByID(ctx, req.SourceID)
+	if err != nil {
+		failStorageLookup(c, err)
+		return
+	}
+	dst, err := h.files.getStorageByID(ctx, req.TargetID)
+	if err != nil {
+		failStorageLookup(c, err)
+		return
+	}
+	h.startStorageMigrate(c, src, dst, req.DeleteSource)
+}
+
+func failStorageLookup(c *gin.Context, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		Fail(c, "404", "存储配置不存在")
+		return
+	}
+	Fail(c, "500", "查询存储配置失败")
+}
+
+func (h *StorageHandler) startStorageMigrate(c *gin.Context, src, dst *storage.Config, deleteSource bool) {
+	ctx := c.Request.Context()
+	if dst.Status != 1 {
+		Fail(c, "400", "目标存储已被禁用")
+		return
+	}
+	m := &storageMigration{src: src, dst: dst, deleteSource: deleteSource}
+	var err error
+	if m.srcDriver, err = h.storages.Driver(src); err != nil {
+		Fail(c, "500", "获取存储配置失败")
+		return
+	}
+	if m.dstDriver, err = h.storages.Driver(dst); err != nil {
+		Fail(c, "500", "获取存储配置失败")
+		return
+	}
+
+	var total, totalSize int64
+	const countSQL = `
+SELECT COUNT(*), COALESCE(SUM(size + COALESCE(thumbnail_size, 0)), 0)
+FROM sys_file_blob
+WHERE storage_id = $1;
+`
+	if err := h.db.QueryRowContext(ctx, countSQL, src.ID).Scan(&total, &totalSize); err != nil {
+		Fail(c, "500", "查询待迁移文件失败")
+		return
+	}
+	if total == 0 {
+		Fail(c, "400", "源存储中没有需要迁移的文件")
+		return
+	}
+	if err := checkStorageQuota(ctx, h.db, dst, totalSize); err != nil {
+		var quotaErr *fileQuotaError
+		if errors.As(err, &quotaErr) {
+			Fail(c, "400", quotaErr.msg)
+			return
+		}
+		Fail(c, "500", "校验存储配额失败")
+		return
+	}
+
+	taskID, err := newImportKey()
+	if err != nil {
+		Fail(c, "500", "创建迁移任务失败")
+		return
+	}
+	ok, err := h.redis.SetNX(ctx, storageMigrateLockKey, taskID, storageMigrateLockTTL).Result()
+	if err != nil {
+		Fail(c, "500", "创建迁移任务失败")
+		return
+	}
+	if !ok {
+		Fail(c, "400", "已有存储迁移任务正在进行，请稍后再试")
+		return
+	}
+
+	m.progress = &StorageMigrateProgress{
+		TaskID:     taskID,
+		SourceID:   src.ID,
+		SourceName: src.Name,
+		TargetID:   dst.ID,
+		TargetName: dst.Name,
+		Status:     storageMigrateRunning,
+		Total:      total,
+		StartTime:  formatTime(time.Now()),
+	}
+	if err := h.saveMigrateProgress(ctx, m.progress); err != nil {
+		h.releaseMigrateLock(ctx, taskID)
+		Fail(c, "500", "创建迁移任务失败")
+		return
+	}
+	go h.runStorageMigrate(context.Background(), m)
+	OK(c, m.progress)
+}
+
+// GetStorageMigrate handles GET /system/storage/migrate/:taskId。
+func (h *StorageHandler) GetStorageMigrate(c *gin.Context) {
+	raw, err := h.redis.Get(c.Request.Context(), storageMigrateKeyPrefix+c.Param("taskId")).Bytes()
+	if errors.Is(err, redis.Nil) {
+		Fail(c, "404", "迁移任务不存在或已过期")
+		return
+	}
+	if err != nil {
+		Fail(c, "500", "查询迁移任务失败")
+		return
+	}
+	var p StorageMigrateProgress
+	if err := json.Unmarshal(raw, &p); err != nil {
+		Fail(c, "500", "查询迁移任务失败")
+		return
+	}
+	OK(c, p)
+}
+
+// saveMigrateProgress 保存任务进度。
+func (h *StorageHandler) saveMigrateProgress(ctx context.Context, p *StorageMigrateProgress) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return h.redis.Set(ctx, storageMigrateKeyPrefix+p.TaskID, data, storageMigrateTTL).Err()
+}
+
+// renewMigrateLock 定时为任务锁续期，直至 ctx 结束。
+func (h *StorageHandler) renewMigrateLock(ctx context.Context, taskID string) {
+	ticker := time.NewTicker(storageMigrateLockRenew)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n, err := storageMigrateRenewScript.Run(ctx, h.redis, []string{storageMigrateLockKey},
+			taskID, storageMigrateLockTTL.Milliseconds()).Int()
+		if err != nil && ctx.Err() == nil {
+			log.Printf("renew storage migrate lock %s failed: %v", taskID, err)
+		} else if err == nil && n == 0 {
+			log.Printf("storage migrate lock %s lost", taskID)
+		}
+	}
+}
+
+// releaseMigrateLock 释放本任务持有的任务锁。
+func (h *StorageHandler) releaseMigrateLock(ctx context.Context, taskID string) {
+	if err := storageMigrateReleaseScript.Run(ctx, h.redis, []string{storageMigrateLockKey}, taskID).Err(); err != nil {
+		log.Printf("release storage migrate lock %s failed: %v", taskID, err)
+	}
+}
+
+// runStorageMigrate 按对象 ID 顺序逐个迁移源存储中的对象，每个对象单独提交。
+func (h *StorageHandler) runStorageMigrate(ctx context.Context, m *storageMigration) {
+	p := m.progress
+	renewCtx, stopRenew := context.WithCancel(ctx)
+	go h.renewMigrateLock(renewCtx, p.TaskID)
+	defer func() {
+		stopRenew()
+		h.releaseMigrateLock(ctx, p.TaskID)
+	}()
+
+	const batchSQL = `
+SELECT id FROM sys_file_blob
+WHERE storage_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3;
+`
+	var lastID int64
+	for {
+		rows, err := h.db.QueryContext(ctx, batchSQL, m.src.ID, lastID, storageMigrateBatch)
+		var ids []int64
+		if err == nil {
+			for rows.Next() {
+				var blobID int64
+				if err = rows.Scan(&blobID); err != nil {
+					break
+				}
+				ids = append(ids, blobID)
+			}
+			rows.Close()
+			if err == nil {
+				err = rows.Err()
+			}
+		}
+		if err != nil {
+			p.Failed++
+			p.Message = "查询待迁移文件失败：" + err.Error()
+			break
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		for _, blobID := range ids {
+			lastID = blobID
+			migrated, err := h.migrateBlob(ctx, m, blobID)
+			switch {
+			case err != nil:
+				p.Failed++
+				p.Message = err.Error()
+			case migrated:
+				p.Migrated++
+			default:
+				p.Skipped++
+			}
+			// 迁移期间新上传到源存储的文件同样会被迁移
+			if done := p.Migrated + p.Skipped + p.Failed; done > p.Total {
+				p.Total = done
+			}
+			_ = h.saveMigrateProgress(ctx, p)
+		}
+	}
+
+	p.Status = storageMigrateSuccess
+	if p.Failed > 0 {
+		p.Status = storageMigrateFailed
+	}
+	p.EndTime = formatTime(time.Now())
+	_ = h.saveMigrateProgress(ctx, p)
+}
+
+// migrateBlob 将一个对象复制到目标存储（目标存储已有相同内容时直接引用），
+// 并将引用它的文件及回收站记录改为指向目标存储。对象已被删除时返回 false。
+// 复制在事务外完成，之后在短事务中加锁复核并切换引用，避免复制大文件期间长时间持有锁。
+func (h *StorageHandler) migrateBlob(ctx context.Context, m *storageMigration, blobID int64) (bool, error) {
+	const selectSQL = `
+SELECT path, COALESCE(sha256, ''), size, COALESCE(metadata, ''),
+       COALESCE(thumbnail_name, ''), COALESCE(thumbnail_metadata, '')
+FROM sys_file_blob
+WHERE id = $1 AND storage_id = $2;
+`
+	var (
+		b    fileBlob
+		sha  string
+		size int64
+	)
+	err := h.db.QueryRowContext(ctx, selectSQL, blobID, m.src.ID).Scan(&b.Path, &sha, &size, &b.Metadata, &b.ThumbName, &b.ThumbMeta)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// 目标存储已有相同内容时无需复制
+	var existing *fileBlob
+	if sha != "" {
+		if existing, err = findBlob(ctx, h.db, m.dst.ID, sha, size); err != nil {
+			return false, err
+		}
+	}
+	var copied *fileBlob
+	if existing == nil {
+		if copied, err = h.copyMigrateBlob(ctx, m, &b); err != nil {
+			return false, err
+		}
+	}
+
+	ok, err := h.switchMigrateBlob(ctx, m, blobID, &b, sha, size, copied)
+	if errors.Is(err, errBlobReleased) {
+		// 准备引用的目标对象在复制检查后已被删除，复制后重试
+		if copied, err = h.copyMigrateBlob(ctx, m, &b); err != nil {
+			return false, err
+		}
+		ok, err = h.switchMigrateBlob(ctx, m, blobID, &b, sha, size, copied)
+	}
+	if err != nil || !ok {
+		h.removeMigrateCopy(ctx, copied)
+		return false, err
+	}
+	if copied != nil && copied.ID == 0 {
+		// 切换时目标存储中已有相同内容，复制的文件未被使用
+		h.removeMigrateCopy(ctx, copied)
+	}
+
+	if m.deleteSource {
+		h.files.removeBlobObjects(ctx, []fileBlob{{StorageID: m.src.ID, Path: b.Path, ThumbName: b.ThumbName}})
+	}
+	return true, nil
+}
+
+// copyMigrateBlob 将对象及其缩略图复制到目标存储，返回尚未登记（ID 为 0）的目标对象。
+func (h *StorageHandler) copyMigrateBlob(ctx context.Context, m *storageMigration, b *fileBlob) (*fileBlob, error) {
+	// 沿用原路径；目标存储中已有其他对象占用该路径时重新命名
+	target := &fileBlob{StorageID: m.dst.ID, Path: b.Path, Metadata: b.Metadata}
+	var taken bool
+	const takenSQL = `SELECT EXISTS (SELECT 1 FROM sys_file_blob WHERE storage_id = $1 AND path = $2);`
+	if err := h.db.QueryRowContext(ctx, takenSQL, m.dst.ID, target.Path).Scan(&taken); err != nil {
+		return nil, err
+	}
+	if taken {
+		target.Path = joinStoragePath(path.Dir(b.Path), strconv.FormatInt(id.Next(), 10)+path.Ext(b.Path))
+	}
+
+	if _, err := copyStoredObject(ctx, m.srcDriver, b.Path, m.dstDriver, target.Path, ""); err != nil {
+		return nil, err
+	}
+	if b.ThumbName != "" {
+		thumbName := path.Base(target.Path) + thumbnailSuffix
+		thumbSize, err := copyStoredObject(ctx, m.srcDriver, thumbnailStoragePath(b.Path, b.ThumbName), m.dstDriver, thumbnailStoragePath(target.Path, thumbName), "image/jpeg")
+		if err == nil {
+			target.ThumbName = thumbName
+			target.ThumbSize = &thumbSize
+			target.ThumbMeta = b.ThumbMeta
+		}
+	}
+	return target, nil
+}
+
+// removeMigrateCopy 删除未登记的复制结果。
+func (h *StorageHandler) removeMigrateCopy(ctx context.Context, copied *fileBlob) {
+	if copied == nil {
+		return
+	}
+	h.files.removeBlobObjects(ctx, []fileBlob{{StorageID: copied.StorageID, Path: copied.Path, ThumbName: copied.ThumbName}})
+}
+
+// switchMigrateBlob 在短事务中锁定并复核源对象，将引用改为目标存储中的对象并删除源对象登记。
+// 目标存储已有相同内容时引用已有对象，copied 保持未登记（ID 为 0）；否则登记 copied。
+// 源对象已被删除或修改时返回 false；准备引用的目标对象已被删除且没有复制结果时返回 errBlobReleased。
+func (h *StorageHandler) switchMigrateBlob(ctx context.Context, m *storageMigration, blobID int64, b *fileBlob, sha string, size int64, copied *fileBlob) (bool, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// 先锁定引用该对象的记录再锁定对象，与删除文件时的加锁顺序一致
+	for _, query := range []string{
+		`SELECT id FROM sys_file WHERE blob_id = $1 FOR UPDATE;`,
+		`SELECT id FROM sys_file_recycle WHERE blob_id = $1 FOR UPDATE;`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, blobID); err != nil {
+			return false, err
+		}
+	}
+	const lockSQL = `
+SELECT path, ref_count
+FROM sys_file_blob
+WHERE id = $1 AND storage_id = $2
+FOR UPDATE;
+`
+	var (
+		curPath  string
+		refCount int64
+	)
+	err = tx.QueryRowContext(ctx, lockSQL, blobID, m.src.ID).Scan(&curPath, &refCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if curPath != b.Path {
+		return false, nil
+	}
+
+	// 与上传相同内容的文件串行，避免目标存储中出现重复对象
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('sys_file_blob:' || $1));`, sha); err != nil {
+		return false, err
+	}
+	var target *fileBlob
+	if sha != "" {
+		existing, err := findBlob(ctx, tx, m.dst.ID, sha, size)
+		if err != nil {
+			return false, err
+		}
+		if existing != nil {
+			if _, err := tx.ExecContext(ctx, `UPDATE sys_file_blob SET ref_count = ref_count + $2 WHERE id = $1;`, existing.ID, refCount); err != nil {
+				return false, err
+			}
+			target = existing
+		}
+	}
+	if target == nil {
+		if copied == nil {
+			return false, errBlobReleased
+		}
+		const insertSQL = `
+INSERT INTO sys_file_blob (
+    id, storage_id, path, sha256, size, metadata,
+    thumbnail_name, thumbnail_size, thumbnail_metadata, ref_count, create_time
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+`
+		copied.ID = id.Next()
+		if _, err := tx.ExecContext(ctx, insertSQL,
+			copied.ID, m.dst.ID, copied.Path, sha, size, copied.Metadata,
+			copied.ThumbName, copied.ThumbSize, copied.ThumbMeta, refCount, time.Now(),
+		); err != nil {
+			return false, err
+		}
+		target = copied
+	}
+
+	for _, table := range []string{"sys_file", "sys_file_recycle"} {
+		updateSQL := `
+UPDATE ` + table + `
+SET storage_id         = $1,
+    blob_id            = $2,
+    name               = $3,
+    path               = $4,
+    thumbnail_name     = $5,
+    thumbnail_size     = $6,
+    thumbnail_metadata = $7
+WHERE blob_id = $8;
+`
+		if _, err := tx.ExecContext(ctx, updateSQL,
+			m.dst.ID, target.ID, path.Base(target.Path), target.Path,
+			target.ThumbName, target.ThumbSize, target.ThumbMeta, blobID,
+		); err != nil {
+			return false, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sys_file_blob WHERE id = $1;`, blobID); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -2,14 +2,16 @@ package http
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
-	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 
+	"voc-go-backend/internal/domain/user"
 	"voc-go-backend/internal/infrastructure/id"
 	"voc-go-backend/internal/infrastructure/security"
 )
@@ -65,56 +67,49 @@ type userRoleUpdateReq struct {
 	RoleIDs []int64 `json:"roleIds"`
 }
 
-// UserImportParseResp is a simplified version of UserImportParseResp in Java.
-type UserImportParseResp struct {
-	ImportKey          string `json:"importKey"`
-	TotalRows          int    `json:"totalRows"`
-	ValidRows          int    `json:"validRows"`
-	DuplicateUserRows  int    `json:"duplicateUserRows"`
-	DuplicateEmailRows int    `json:"duplicateEmailRows"`
-	DuplicatePhoneRows int    `json:"duplicatePhoneRows"`
-}
-
-// UserImportResultResp matches UserImportResp in Java (used as import result).
-type UserImportResultResp struct {
-	TotalRows  int `json:"totalRows"`
-	InsertRows int `json:"insertRows"`
-	UpdateRows int `json:"updateRows"`
-}
-
 // SystemUserHandler provides /system/user endpoints.
 type SystemUserHandler struct {
 	db           *sql.DB
+	users        user.Repository
 	tokenSvc     *security.TokenService
 	rsaDecryptor *security.RSADecryptor
 	hasher       security.PasswordHasher
+	pwdPolicy    *security.PasswordPolicyValidator
+	redis        *redis.Client
+	online       OnlineStore
 }
 
-func NewSystemUserHandler(db *sql.DB, tokenSvc *security.TokenService, rsa *security.RSADecryptor, hasher security.PasswordHasher) *SystemUserHandler {
+// NewSystemUserHandler 创建用户管理 handler，users 用于重置密码（同时记录密码历史），
+// online 用于重置密码后吊销该用户的在线会话。
+func NewSystemUserHandler(db *sql.DB, users user.Repository, tokenSvc *security.TokenService, rsa *security.RSADecryptor, hasher security.PasswordHasher, pwdPolicy *security.PasswordPolicyValidator, redisClient *redis.Client, online OnlineStore) *SystemUserHandler {
 	return &SystemUserHandler{
 		db:           db,
+		users:        users,
 		tokenSvc:     tokenSvc,
 		rsaDecryptor: rsa,
 		hasher:       hasher,
+		pwdPolicy:    pwdPolicy,
+		redis:        redisClient,
+		online:       online,
 	}
 }
 
 // RegisterSystemUserRoutes registers /system/user related routes.
-func (h *SystemUserHandler) RegisterSystemUserRoutes(r *gin.Engine) {
-	r.GET("/system/user", h.ListUserPage)
-	r.GET("/system/user/list", h.ListAllUser)
-	r.GET("/system/user/:id", h.GetUserDetail)
-	r.POST("/system/user", h.CreateUser)
-	r.PUT("/system/user/:id", h.UpdateUser)
-	r.DELETE("/system/user", h.DeleteUser)
-	r.PATCH("/system/user/:id/password", h.ResetPassword)
-	r.PATCH("/system/user/:id/role", h.UpdateUserRole)
-
-	// 导出与导入相关接口（简化实现）
-	r.GET("/system/user/export", h.ExportUser)
-	r.GET("/system/user/import/template", h.DownloadImportTemplate)
-	r.POST("/system/user/import/parse", h.ParseImportUser)
-	r.POST("/system/user/import", h.ImportUser)
+func (h *SystemUserHandler) RegisterSystemUserRoutes(r *gin.Engine, am *AuthMiddleware) {
+	r.GET("/system/user", Audit("用户管理", "分页查询用户"), am.RequirePermission("system:user:list"), h.ListUserPage)
+	r.GET("/system/user/list", Audit("用户管理", "查询用户列表"), am.RequirePermission("system:user:list"), h.ListAllUser)
+	r.GET("/system/user/:id", Audit("用户管理", "查询用户详情"), am.RequirePermission("system:user:get"), h.GetUserDetail)
+	r.POST("/system/user", Audit("用户管理", "新增用户"), am.RequirePermission("system:user:create"), h.CreateUser)
+	r.PUT("/system/user/:id", Audit("用户管理", "修改用户"), am.RequirePermission("system:user:update"), h.UpdateUser)
+	r.DELETE("/system/user", Audit("用户管理", "删除用户"), am.RequirePermission("system:user:delete"), h.DeleteUser)
+	r.PATCH("/system/user/:id/password", Audit("用户管理", "重置密码"), am.RequirePermission("system:user:resetPwd"), h.ResetPassword)
+	r.PATCH("/system/user/:id/role", Audit("用户管理", "分配角色"), am.RequirePermission("system:user:updateRole"), h.UpdateUserRole)
+
+	// 导出与导入相关接口
+	r.GET("/system/user/export", Audit("用户管理", "导出用户", AuditNoResponseBody), am.RequirePermission("system:user:export"), h.ExportUser)
+	r.GET("/system/user/import/template", Audit("用户管理", "下载用户导入模板", AuditNoResponseBody), am.RequirePermission("system:user:import"), h.DownloadImportTemplate)
+	r.POST("/system/user/import/parse", Audit("用户管理", "解析用户导入数据", AuditNoRequestBody), am.RequirePermission("system:user:import"), h.ParseImportUser)
+	r.POST("/system/user/import", Audit("用户管理", "导入用户"), am.RequirePermission("system:user:import"), h.ImportUser)
 }
 
 func (h *SystemUserHandler) currentUserID(c *gin.Context) int64 {
@@ -138,38 +133,10 @@ func (h *SystemUserHandler) ListUserPage(c *gin.Context) {
 		size = 10
 	}
 
-	desc := strings.TrimSpace(c.Query("description"))
-	statusStr := strings.TrimSpace(c.Query("status"))
-	deptStr := strings.TrimSpace(c.Query("deptId"))
-
-	var (
-		statusFilter int64
-		deptID       int64
-	)
-	if statusStr != "" {
-		statusFilter, _ = strconv.ParseInt(statusStr, 10, 64)
-	}
-	if deptStr != "" {
-		deptID, _ = strconv.ParseInt(deptStr, 10, 64)
-	}
-
-	where := "WHERE 1=1"
-	args := []any{}
-	argPos := 1
-	if desc != "" {
-		where += fmt.Sprintf(" AND (u.username ILIKE $%d OR u.nickname ILIKE $%d OR COALESCE(u.description,'') ILIKE $%d)", argPos, argPos, argPos)
-		args = append(args, "%"+desc+"%")
-		argPos++
-	}
-	if statusFilter != 0 {
-		where += fmt.Sprintf(" AND u.status = $%d", argPos)
-		args = append(args, statusFilter)
-		argPos++
-	}
-	if deptID != 0 {
-		where += fmt.Sprintf(" AND u.dept_id = $%d", argPos)
-		args = append(args, deptID)
-		argPos++
+	where, args, argPos, err := h.userListWhere(c)
+	if err != nil {
+		Fail(c, "500", "查询用户失败")
+		return
 	}
 
 	countSQL := "SELECT COUNT(*) FROM sys_user AS u " + where
@@ -271,6 +238,51 @@ LIMIT $%d OFFSET $%d;
 	OK(c, PageResult[UserResp]{List: users, Total: total})
 }
 
+// userListWhere 根据分页查询/导出共用的筛选参数（description、status、deptId）
+// 及当前用户数据权限构建 WHERE 子句，表别名为 u。
+func (h *SystemUserHandler) userListWhere(c *gin.Context) (string, []any, int, error) {
+	desc := strings.TrimSpace(c.Query("description"))
+	statusStr := strings.TrimSpace(c.Query("status"))
+	deptStr := strings.TrimSpace(c.Query("deptId"))
+
+	var (
+		statusFilter int64
+		deptID       int64
+	)
+	if statusStr != "" {
+		statusFilter, _ = strconv.ParseInt(statusStr, 10, 64)
+	}
+	if deptStr != "" {
+		deptID, _ = strconv.ParseInt(deptStr, 10, 64)
+	}
+
+	where := "WHERE 1=1"
+	args := []any{}
+	argPos := 1
+	if desc != "" {
+		where += fmt.Sprintf(" AND (u.username ILIKE $%d OR u.nickname ILIKE $%d OR COALESCE(u.description,'') ILIKE $%d)", argPos, argPos, argPos)
+		args = append(args, "%"+desc+"%")
+		argPos++
+	}
+	if statusFilter != 0 {
+		where += fmt.Sprintf(" AND u.status = $%d", argPos)
+		args = append(args, statusFilter)
+		argPos++
+	}
+	if deptID != 0 {
+		where += fmt.Sprintf(" AND u.dept_id = $%d", argPos)
+		args = append(args, deptID)
+		argPos++
+	}
+
+	scope, err := loadDataScope(c.Request.Context(), h.db, contextUserID(c))
+	if err != nil {
+		return "", nil, 0, err
+	}
+	where, args, argPos = scope.appendWhere(where, args, argPos, userDataScopeColumns)
+	return where, args, argPos, nil
+}
+
 // ListAllUser handles GET /system/user/list.
 func (h *SystemUserHandler) ListAllUser(c *gin.Context) {
 	idStrs := c.QueryArray("userIds")
@@ -281,38 +293,25 @@ func (h *SystemUserHandler) ListAllUser(c *gin.Context) {
 		}
 	}
 
-	var rows *sql.Rows
-	var err error
+	where := "WHERE 1=1"
+	args := []any{}
+	argPos := 1
 	if len(ids) > 0 {
-		rows, err = h.db.QueryContext(
-			c.Request.Context(),
-			`SELECT u.id,
-       u.username,
-       u.nickname,
-       COALESCE(u.avatar, ''),
-       u.gender,
-       COALESCE(u.email, ''),
-       COALESCE(u.phone, ''),
-       COALESCE(u.description, ''),
-       u.status,
-       u.is_system,
-       u.dept_id,
-       COALESCE(d.name, ''),
-       u.create_time,
-       COALESCE(cu.nickname, ''),
-       u.update_time,
-       COALESCE(uu.nickname, '')
-FROM sys_user AS u
-LEFT JOIN sys_dept AS d ON d.id = u.dept_id
-LEFT JOIN sys_user AS cu ON cu.id = u.create_user
-LEFT JOIN sys_user AS uu ON uu.id = u.update_user
-WHERE u.id = ANY($1::bigint[])`,
-			pqInt64Array(ids),
-		)
-	} else {
-		rows, err = h.db.QueryContext(
-			c.Request.Context(),
-			`SELECT u.id,
+		where += fmt.Sprintf(" AND u.id = ANY($%d::bigint[])", argPos)
+		args = append(args, pqInt64Array(ids))
+		argPos++
+	}
+
+	scope, err := loadDataScope(c.Request.Context(), h.db, contextUserID(c))
+	if err != nil {
+		Fail(c, "500", "查询用户失败")
+		return
+	}
+	where, args, _ = scope.appendWhere(where, args, argPos, userDataScopeColumns)
+
+	rows, err := h.db.QueryContext(
+		c.Request.Context(),
+		`SELECT u.id,
        u.username,
        u.nickname,
        COALESCE(u.avatar, ''),
@@ -332,9 +331,10 @@ FROM sys_user AS u
 LEFT JOIN sys_dept AS d ON d.id = u.dept_id
 LEFT JOIN sys_user AS cu ON cu.id = u.create_user
 LEFT JOIN sys_user AS uu ON uu.id = u.update_user
+`+where+`
 ORDER BY u.id DESC`,
-		)
-	}
+		args...,
+	)
 	if err != nil {
 		Fail(c, "500", "查询用户失败")
 		return
@@ -450,7 +450,15 @@ func (h *SystemUserHandler) GetUserDetail(c *gin.Context) {
 		return
 	}
 
-	const query = `
+	// 不在数据权限范围内的用户按不存在处理
+	scope, err := loadDataScope(c.Request.Context(), h.db, contextUserID(c))
+	if err != nil {
+		Fail(c, "500", "查询用户失败")
+		return
+	}
+	where, args, _ := scope.appendWhere("WHERE u.id = $1", []any{idVal}, 2, userDataScopeColumns)
+
+	query := `
 SELECT u.id,
        u.username,
        u.nickname,
@@ -472,8 +480,7 @@ FROM sys_user AS u
 LEFT JOIN sys_dept AS d ON d.id = u.dept_id
 LEFT JOIN sys_user AS cu ON cu.id = u.create_user
 LEFT JOIN sys_user AS uu ON uu.id = u.update_user
-WHERE u.id = $1;
-`
+` + where + ";"
 	var (
 		u        UserDetailResp
 		pwdReset sql.NullTime
@@ -482,7 +489,7 @@ WHERE u.id = $1;
 		updateAt sql.NullTime
 		updateBy string
 	)
-	err = h.db.QueryRowContext(c.Request.Context(), query, idVal).
+	err = h.db.QueryRowContext(c.Request.Context(), query, args...).
 		Scan(
 			&u.ID,
 			&u.Username,
@@ -566,21 +573,7 @@ func (h *SystemUserHandler) CreateUser(c *gin.Context) {
 		Fail(c, "400", "密码解密失败")
 		return
 	}
-	if len(rawPwd) < 8 || len(rawPwd) > 32 {
-		Fail(c, "400", "密码长度为 8-32 个字符，至少包含字母和数字")
-		return
-	}
-	var hasLetter, hasDigit bool
-	for _, ch := range rawPwd {
-		switch {
-		case ch >= '0' && ch <= '9':
-			hasDigit = true
-		case (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z'):
-			hasLetter = true
-		}
-	}
-	if !hasLetter || !hasDigit {
-		Fail(c, "400", "密码长度为 8-32 个字符，至少包含字母和数字")
+	if !checkPasswordPolicy(c, h.pwdPolicy, 0, req.Username, "", rawPwd) {
 		return
 	}
 
@@ -690,6 +683,9 @@ func (h *SystemUserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 	defer tx.Rollback()
+	if !checkDataScope(c, h.db, tx, userDataScopeColumns, idVal, "用户不存在", "修改用户失败") {
+		return
+	}
 
 	const updateUser = `
 UPDATE sys_user
@@ -757,7 +753,11 @@ func (h *SystemUserHandler) DeleteUser(c *gin.Context) {
 	if userID == 0 {
 		return
 	}
-	_ = userID
+	scope, err := loadDataScope(c.Request.Context(), h.db, userID)
+	if err != nil {
+		Fail(c, "500", "删除用户失败")
+		return
+	}
 
 	var req idsRequest
 	if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) == 0 {
@@ -785,10 +785,23 @@ func (h *SystemUserHandler) DeleteUser(c *gin.Context) {
 		if isSystem {
 			continue
 		}
+		ok, err := scope.contains(c.Request.Context(), tx, userDataScopeColumns, idVal)
+		if err != nil {
+			Fail(c, "500", "删除用户失败")
+			return
+		}
+		if !ok {
+			Fail(c, "403", "没有权限删除其他部门的用户")
+			return
+		}
 		if _, err := tx.ExecContext(c.Request.Context(), `DELETE FROM sys_user_role WHERE user_id = $1`, idVal); err != nil {
 			Fail(c, "500", "删除用户失败")
 			return
 		}
+		if _, err := tx.ExecContext(c.Request.Context(), `DELETE FROM sys_user_password_history WHERE user_id = $1`, idVal); err != nil {
+			Fail(c, "500", "删除用户失败")
+			return
+		}
 		if _, err := tx.ExecContext(c.Request.Context(), `DELETE FROM sys_user WHERE id = $1`, idVal); err != nil {
 			Fail(c, "500", "删除用户失败")
 			return
@@ -829,21 +842,21 @@ func (h *SystemUserHandler) ResetPassword(c *gin.Context) {
 		Fail(c, "400", "密码解密失败")
 		return
 	}
-	if len(rawPwd) < 8 || len(rawPwd) > 32 {
-		Fail(c, "400", "密码长度为 8-32 个字符，至少包含字母和数字")
+	if !checkDataScope(c, h.db, h.db, userDataScopeColumns, idVal, "用户不存在", "查询用户失败") {
 		return
 	}
-	var hasLetter, hasDigit bool
-	for _, ch := range rawPwd {
-		switch {
-		case ch >= '0' && ch <= '9':
-			hasDigit = true
-		case (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z'):
-			hasLetter = true
+	var username, oldPwd string
+	if err := h.db.QueryRowContext(c.Request.Context(),
+		`SELECT username, COALESCE(password, '') FROM sys_user WHERE id = $1`, idVal,
+	).Scan(&username, &oldPwd); err != nil {
+		if err == sql.ErrNoRows {
+			Fail(c, "404", "用户不存在")
+			return
 		}
+		Fail(c, "500", "查询用户失败")
+		return
 	}
-	if !hasLetter || !hasDigit {
-		Fail(c, "400", "密码长度为 8-32 个字符，至少包含字母和数字")
+	if !checkPasswordPolicy(c, h.pwdPolicy, idVal, username, oldPwd, rawPwd) {
 		return
 	}
 
@@ -853,18 +866,15 @@ func (h *SystemUserHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	if _, err := h.db.ExecContext(
-		c.Request.Context(),
-		`UPDATE sys_user SET password = $1, pwd_reset_time = $2, update_user = $3, update_time = $4 WHERE id = $5`,
-		encodedPwd,
-		time.Now(),
-		userID,
-		time.Now(),
-		idVal,
-	); err != nil {
+	if err := h.users.UpdatePassword(c.Request.Context(), idVal, encodedPwd, time.Now(), userID); err != nil {
 		Fail(c, "500", "重置密码失败")
 		return
 	}
+	// 旧密码登录的会话一并下线。
+	if err := revokeUserSessions(c.Request.Context(), h.online, h.tokenSvc, idVal); err != nil {
+		Fail(c, "500", "注销用户会话失败")
+		return
+	}
 	OK(c, true)
 }
 
@@ -894,6 +904,9 @@ func (h *SystemUserHandler) UpdateUserRole(c *gin.Context) {
 		return
 	}
 	defer tx.Rollback()
+	if !checkDataScope(c, h.db, tx, userDataScopeColumns, idVal, "用户不存在", "分配角色失败") {
+		return
+	}
 
 	if _, err := tx.ExecContext(c.Request.Context(), `DELETE FROM sys_user_role WHERE user_id = $1`, idVal); err != nil {
 		Fail(c, "500", "分配角色失败")
@@ -920,79 +933,141 @@ ON CONFLICT (user_id, role_id) DO NOTHING;
 	OK(c, true)
 }
 
+// userExportColumns 与 Java UserDetailResp 的 @ExcelProperty 保持一致。
+var userExportColumns = []exportColumn{
+	{"id", "ID"},
+	{"username", "用户名"},
+	{"nickname", "昵称"},
+	{"status", "状态"},
+	{"gender", "性别"},
+	{"deptId", "部门 ID"},
+	{"deptName", "所属部门"},
+	{"roleIds", "角色 ID 列表"},
+	{"roleNames", "角色"},
+	{"phone", "手机号码"},
+	{"email", "邮箱"},
+	{"isSystem", "系统内置"},
+	{"description", "描述"},
+	{"avatar", "头像地址"},
+	{"createUserString", "创建人"},
+	{"createTime", "创建时间"},
+	{"updateUserString", "修改人"},
+	{"updateTime", "修改时间"},
+}
+
 // ExportUser handles GET /system/user/export.
-// It returns a very simple CSV file with basic user information.
+// 筛选条件与分页查询一致，支持 format（xlsx/csv）与 columns 参数。
 func (h *SystemUserHandler) ExportUser(c *gin.Context) {
-	rows, err := h.db.QueryContext(
-		c.Request.Context(),
-		`SELECT username, nickname, gender, COALESCE(email,''), COALESCE(phone,'') FROM sys_user ORDER BY id`,
-	)
+	where, args, _, err := h.userListWhere(c)
 	if err != nil {
 		Fail(c, "500", "导出用户失败")
 		return
 	}
-	defer rows.Close()
 
-	var b strings.Builder
-	b.WriteString("username,nickname,gender,email,phone\n")
-	for rows.Next() {
-		var username, nickname, email, phone string
-		var gender int16
-		if err := rows.Scan(&username, &nickname, &gender, &email, &phone); err != nil {
-			Fail(c, "500", "导出用户失败")
-			return
-		}
-		line := fmt.Sprintf("%s,%s,%d,%s,%s\n", username, nickname, gender, email, phone)
-		b.WriteString(line)
+	query := `
+SELECT u.id,
+       u.username,
+       u.nickname,
+       u.status,
+       u.gender,
+       u.dept_id,
+       COALESCE(d.name, ''),
+       COALESCE((SELECT string_agg(ur.role_id::text, ',' ORDER BY ur.role_id)
+                 FROM sys_user_role AS ur WHERE ur.user_id = u.id), ''),
+       COALESCE((SELECT string_agg(r.name, ',' ORDER BY r.id)
+                 FROM sys_user_role AS ur JOIN sys_role AS r ON r.id = ur.role_id
+                 WHERE ur.user_id = u.id), ''),
+       COALESCE(u.phone, ''),
+       COALESCE(u.email, ''),
+       u.is_system,
+       COALESCE(u.description, ''),
+       COALESCE(u.avatar, ''),
+       COALESCE(cu.nickname, ''),
+       u.create_time,
+       COALESCE(uu.nickname, ''),
+       u.update_time
+FROM sys_user AS u
+LEFT JOIN sys_dept AS d ON d.id = u.dept_id
+LEFT JOIN sys_user AS cu ON cu.id = u.create_user
+LEFT JOIN sys_user AS uu ON uu.id = u.update_user
+` + where + `
+ORDER BY u.id DESC;
+`
+	rows, err := h.db.QueryContext(c.Request.Context(), query, args...)
+	if err != nil {
+		Fail(c, "500", "导出用户失败")
+		return
 	}
+	defer rows.Close()
 
-	c.Header("Content-Type", "text/csv; charset=utf-8")
-	c.Header("Content-Disposition", "attachment; filename=\"users.csv\"")
-	c.String(http.StatusOK, b.String())
-}
-
-// DownloadImportTemplate handles GET /system/user/import/template.
-func (h *SystemUserHandler) DownloadImportTemplate(c *gin.Context) {
-	content := "username,nickname,gender,email,phone\n"
-	c.Header("Content-Type", "text/csv; charset=utf-8")
-	c.Header("Content-Disposition", "attachment; filename=\"user_import_template.csv\"")
-	c.String(http.StatusOK, content)
-}
-
-// ParseImportUser handles POST /system/user/import/parse.
-// 当前实现不真正解析 Excel，只返回一个空的解析结果，保证前端流程可用。
-func (h *SystemUserHandler) ParseImportUser(c *gin.Context) {
-	// 接收上传文件，但不做实际解析
-	if _, err := c.FormFile("file"); err != nil {
-		Fail(c, "400", "文件不能为空")
+	exporter := newTableExporter(c, "用户数据", userExportColumns)
+	if exporter == nil {
 		return
 	}
-	resp := UserImportParseResp{
-		ImportKey:          strconv.FormatInt(time.Now().UnixNano(), 10),
-		TotalRows:          0,
-		ValidRows:          0,
-		DuplicateUserRows:  0,
-		DuplicateEmailRows: 0,
-		DuplicatePhoneRows: 0,
+	for rows.Next() {
+		var (
+			idVal, deptID                        int64
+			username, nickname, deptName         string
+			roleIDs, roleNames, phone, email     string
+			description, avatar, createBy, updBy string
+			statusVal, gender                    int16
+			isSystem                             bool
+			createAt                             time.Time
+			updateAt                             sql.NullTime
+		)
+		if err := rows.Scan(
+			&idVal, &username, &nickname, &statusVal, &gender, &deptID, &deptName,
+			&roleIDs, &roleNames, &phone, &email, &isSystem, &description, &avatar,
+			&createBy, &createAt, &updBy, &updateAt,
+		); err != nil {
+			exporter.Finish(c, err)
+			return
+		}
+		updateTime := ""
+		if updateAt.Valid {
+			updateTime = formatTime(updateAt.Time)
+		} else {
+			updBy = ""
+		}
+		if err := exporter.Write([]string{
+			strconv.FormatInt(idVal, 10),
+			username,
+			nickname,
+			exportStatusText(statusVal),
+			exportGenderText(gender),
+			strconv.FormatInt(deptID, 10),
+			deptName,
+			roleIDs,
+			roleNames,
+			phone,
+			email,
+			exportBoolText(isSystem),
+			description,
+			avatar,
+			createBy,
+			formatTime(createAt),
+			updBy,
+			updateTime,
+		}); err != nil {
+			exporter.Finish(c, err)
+			return
+		}
 	}
-	OK(c, resp)
+	exporter.Finish(c, rows.Err())
 }
 
-// ImportUser handles POST /system/user/import.
-// 当前实现为占位实现，不执行真正的导入逻辑。
-func (h *SystemUserHandler) ImportUser(c *gin.Context) {
-	var body map[string]any
-	if err := c.ShouldBindJSON(&body); err != nil {
-		Fail(c, "400", "请求参数不正确")
-		return
+// checkPasswordPolicy 按 sys_option 中的密码策略校验新密码，
+// 违反策略时返回 400 及具体提示，读取配置等内部错误返回 500。
+func checkPasswordPolicy(c *gin.Context, v *security.PasswordPolicyValidator, userID int64, username, currentEncoded, raw string) bool {
+	err := v.Validate(c.Request.Context(), userID, username, currentEncoded, raw)
+	if err == nil {
+		return true
 	}
-	_ = body // 预留给未来扩展
-
-	resp := UserImportResultResp{
-		TotalRows:  0,
-		InsertRows: 0,
-		UpdateRows: 0,
+	var policyErr *security.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		Fail(c, "400", policyErr.Error())
+		return false
 	}
-	OK(c, resp)
+	Fail(c, "500", "校验密码策略失败")
+	return false
 }
-
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"voc-go-backend/internal/infrastructure/trace"
+)
+
+// TraceIDHeader 为链路 ID 的请求头与响应头。
+const TraceIDHeader = "X-Trace-Id"
+
+// traceContextKey 为链路 ID 在 gin.Context 中的键。
+const traceContextKey = "traceId"
+
+// NewTraceMiddleware 创建链路 ID 中间件，应在其它中间件之前注册：
+// 优先使用请求头 X-Trace-Id（格式不合法时忽略），否则生成新的链路 ID；
+// 链路 ID 写入 gin.Context 与请求 context，并通过响应头、APIResponse.traceId 返回，系统日志同样记录该 ID。
+func NewTraceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		traceID := c.GetHeader(TraceIDHeader)
+		if !trace.ValidID(traceID) {
+			traceID = trace.NewID()
+		}
+		c.Set(traceContextKey, traceID)
+		c.Request = c.Request.WithContext(trace.WithID(c.Request.Context(), traceID))
+		c.Header(TraceIDHeader, traceID)
+		c.Next()
+	}
+}
+
+// traceIDFrom 返回当前请求的链路 ID。
+func traceIDFrom(c *gin.Context) string {
+	return c.GetString(traceContextKey)
+}
+
+// TraceLogFormatter 为带链路 ID 的 Gin 访问日志格式。
+func TraceLogFormatter(p gin.LogFormatterParams) string {
+	traceID, _ := p.Keys[traceContextKey].(string)
+	if p.Latency > time.Minute {
+		p.Latency = p.Latency.Truncate(time.Second)
+	}
+	return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v | trace=%s\n%s",
+		p.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		p.StatusCode,
+		p.Latency,
+		p.ClientIP,
+		p.Method,
+		p.Path,
+		traceID,
+		p.ErrorMessage,
+	)
+}
@@ -15,6 +15,7 @@ type UserHandler struct {
 	roles       rbac.RoleRepository
 	menus       rbac.MenuRepository
 	tokenSvc    *security.TokenService
+	pwdPolicy   *security.PasswordPolicyValidator
 }
 
 func NewUserHandler(
@@ -22,19 +23,21 @@ func NewUserHandler(
 	roles rbac.RoleRepository,
 	menus rbac.MenuRepository,
 	tokenSvc *security.TokenService,
+	pwdPolicy *security.PasswordPolicyValidator,
 ) *UserHandler {
 	return &UserHandler{
-		users:    users,
-		roles:    roles,
-		menus:    menus,
-		tokenSvc: tokenSvc,
+		users:     users,
+		roles:     roles,
+		menus:     menus,
+		tokenSvc:  tokenSvc,
+		pwdPolicy: pwdPolicy,
 	}
 }
 
 // RegisterUserRoutes registers /auth/user endpoints.
 func (h *UserHandler) RegisterUserRoutes(r *gin.Engine) {
-	r.GET("/auth/user/info", h.GetUserInfo)
-	r.GET("/auth/user/route", h.ListUserRoute)
+	r.GET("/auth/user/info", Audit("登录", "获取登录用户信息"), h.GetUserInfo)
+	r.GET("/auth/user/route", Audit("登录", "获取登录用户路由"), h.ListUserRoute)
 }
 
 // GetUserInfo handles GET /auth/user/info.
@@ -69,7 +72,14 @@ func (h *UserHandler) GetUserInfo(c *gin.Context) {
 		return
 	}
 
-	info := appauth.BuildUserInfo(domainUser, roleCodes, perms, "", false)
+	// 密码有效期：根据 pwd_reset_time 与 PASSWORD_EXPIRATION_* 配置计算。
+	expiry, err := h.pwdPolicy.Expiry(c.Request.Context(), domainUser.PwdResetTime)
+	if err != nil {
+		Fail(c, "500", "获取密码策略失败")
+		return
+	}
+
+	info := appauth.BuildUserInfo(domainUser, roleCodes, perms, "", expiry)
 	OK(c, info)
 }
 
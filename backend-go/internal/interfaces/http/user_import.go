@@ -0,0 +1,694 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
+
+	"voc-go-backend/internal/infrastructure/excel"
+	"voc-go-backend/internal/infrastructure/id"
+	userpg "voc-go-backend/internal/infrastructure/persistence/user"
+	"voc-go-backend/internal/infrastructure/security"
+)
+
+const (
+	// userImportKeyPrefix 与 Java CacheConstants.DATA_IMPORT_KEY 保持一致：SYSTEM:DATA_IMPORT:{importKey}
+	userImportKeyPrefix = "SYSTEM:DATA_IMPORT:"
+	// userImportTTL 解析结果的缓存有效期。
+	userImportTTL = 10 * time.Minute
+	// userImportMaxFileSize 导入文件大小上限。
+	userImportMaxFileSize = 10 << 20
+)
+
+// 导入策略，对齐 Java ImportPolicyEnum。
+const (
+	importPolicySkip   = 1 // 跳过该行
+	importPolicyUpdate = 2 // 修改数据
+	importPolicyExit   = 3 // 停止导入
+)
+
+var (
+	// usernamePattern 对齐 Java RegexConstants.USERNAME。
+	usernamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{3,64}$`)
+	// generalNamePattern 对齐 Java RegexConstants.GENERAL_NAME。
+	generalNamePattern = regexp.MustCompile(`^[\p{Han}a-zA-Z0-9_-]{2,30}$`)
+)
+
+// userImportColumns 为导入模板的列，表头按中文或英文字段名匹配，与列顺序无关。
+var userImportColumns = []struct {
+	field   string
+	headers []string
+}{
+	{"username", []string{"用户名", "username"}},
+	{"nickname", []string{"昵称", "nickname"}},
+	{"password", []string{"密码", "password"}},
+	{"deptName", []string{"所属部门", "部门", "deptName"}},
+	{"roleName", []string{"所属角色", "角色", "roleName"}},
+	{"gender", []string{"性别", "gender"}},
+	{"email", []string{"邮箱", "email"}},
+	{"phone", []string{"手机号码", "手机号", "phone"}},
+	{"description", []string{"描述", "description"}},
+}
+
+// UserImportParseResp matches UserImportParseResp in Java.
+type UserImportParseResp struct {
+	ImportKey          string `json:"importKey"`
+	TotalRows          int    `json:"totalRows"`
+	ValidRows          int    `json:"validRows"`
+	DuplicateUserRows  int    `json:"duplicateUserRows"`
+	DuplicateEmailRows int    `json:"duplicateEmailRows"`
+	DuplicatePhoneRows int    `json:"duplicatePhoneRows"`
+}
+
+// UserImportResultResp matches UserImportResp in Java (used as import result).
+type UserImportResultResp struct {
+	TotalRows  int `json:"totalRows"`
+	InsertRows int `json:"insertRows"`
+	UpdateRows int `json:"updateRows"`
+}
+
+// userImportReq 对应 POST /system/user/import。
+type userImportReq struct {
+	ImportKey      string `json:"importKey"`
+	DuplicateUser  int    `json:"duplicateUser"`
+	DuplicateEmail int    `json:"duplicateEmail"`
+	DuplicatePhone int    `json:"duplicatePhone"`
+	DefaultStatus  int16  `json:"defaultStatus"`
+}
+
+// userImportRow 为一行导入数据，校验通过后以 JSON 缓存到 Redis。
+// 缓存前 Password 已替换为密码哈希，明文不会写入 Redis。
+type userImportRow struct {
+	Username    string `json:"username"`
+	Nickname    string `json:"nickname"`
+	Password    string `json:"password"`
+	DeptName    string `json:"deptName"`
+	RoleName    string `json:"roleName"`
+	Gender      int16  `json:"gender"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	Description string `json:"description"`
+}
+
+// DownloadImportTemplate handles GET /system/user/import/template.
+// 带 UTF-8 BOM，便于 Excel 直接打开；另存为 xlsx 后同样可以导入。
+func (h *SystemUserHandler) DownloadImportTemplate(c *gin.Context) {
+	headers := make([]string, 0, len(userImportColumns))
+	for _, col := range userImportColumns {
+		headers = append(headers, col.headers[0])
+	}
+	var buf bytes.Buffer
+	buf.WriteString("\uFEFF")
+	w := csv.NewWriter(&buf)
+	_ = w.Write(headers)
+	_ = w.Write([]string{"zhangsan", "张三", "zhangsan123", "研发部", "测试人员", "男", "zhangsan@example.com", "13800000000", "示例数据，导入前请删除"})
+	w.Flush()
+
+	c.Header("Content-Disposition", "attachment; filename=\"user_import_template.csv\"")
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// ParseImportUser handles POST /system/user/import/parse.
+// 解析并校验上传的 CSV/XLSX 文件，有效数据缓存 10 分钟，返回 importKey 供确认导入使用。
+func (h *SystemUserHandler) ParseImportUser(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil || header.Size == 0 {
+		Fail(c, "400", "文件不能为空")
+		return
+	}
+	if header.Size > userImportMaxFileSize {
+		Fail(c, "400", "文件大小不能超过 10MB")
+		return
+	}
+	records, err := readImportRecords(header)
+	if err != nil {
+		Fail(c, "400", "数据文件解析异常")
+		return
+	}
+	rows, total, ok := mapImportRecords(records)
+	if !ok || total == 0 {
+		Fail(c, "400", "数据文件格式不正确")
+		return
+	}
+
+	ctx := c.Request.Context()
+	policy, err := h.pwdPolicy.Policy(ctx)
+	if err != nil {
+		Fail(c, "500", "校验密码策略失败")
+		return
+	}
+	valid := filterImportRows(rows, policy)
+	if len(valid) == 0 {
+		Fail(c, "400", "数据文件格式不正确")
+		return
+	}
+
+	// 表格内邮箱、手机号不能重复
+	seenEmails := make(map[string]struct{})
+	seenPhones := make(map[string]struct{})
+	for _, row := range valid {
+		if row.Email != "" {
+			if _, ok := seenEmails[row.Email]; ok {
+				Fail(c, "400", "存在重复邮箱，请检测数据")
+				return
+			}
+			seenEmails[row.Email] = struct{}{}
+		}
+		if row.Phone != "" {
+			if _, ok := seenPhones[row.Phone]; ok {
+				Fail(c, "400", "存在重复手机，请检测数据")
+				return
+			}
+			seenPhones[row.Phone] = struct{}{}
+		}
+	}
+
+	// 角色、部门必须存在
+	roleIDs, err := h.lookupIDsByName(ctx, "sys_role", importRowValues(valid, func(r *userImportRow) string { return r.RoleName }))
+	if err != nil {
+		Fail(c, "500", "解析导入数据失败")
+		return
+	}
+	if missing := missingNames(valid, roleIDs, func(r *userImportRow) string { return r.RoleName }); len(missing) > 0 {
+		Fail(c, "400", "存在无效角色，请检查数据："+strings.Join(missing, "、"))
+		return
+	}
+	deptIDs, err := h.lookupIDsByName(ctx, "sys_dept", importRowValues(valid, func(r *userImportRow) string { return r.DeptName }))
+	if err != nil {
+		Fail(c, "500", "解析导入数据失败")
+		return
+	}
+	if missing := missingNames(valid, deptIDs, func(r *userImportRow) string { return r.DeptName }); len(missing) > 0 {
+		Fail(c, "400", "存在无效部门，请检查数据："+strings.Join(missing, "、"))
+		return
+	}
+
+	existing, err := h.loadImportExisting(ctx, valid)
+	if err != nil {
+		Fail(c, "500", "解析导入数据失败")
+		return
+	}
+	resp := UserImportParseResp{TotalRows: total, ValidRows: len(valid)}
+	for _, row := range valid {
+		if _, ok := existing.usernames[row.Username]; ok {
+			resp.DuplicateUserRows++
+		}
+		if _, ok := existing.emails[row.Email]; ok && row.Email != "" {
+			resp.DuplicateEmailRows++
+		}
+		if _, ok := existing.phones[row.Phone]; ok && row.Phone != "" {
+			resp.DuplicatePhoneRows++
+		}
+	}
+
+	for _, row := range valid {
+		encoded, err := h.hasher.Hash(row.Password)
+		if err != nil {
+			Fail(c, "500", "密码加密失败")
+			return
+		}
+		row.Password = encoded
+	}
+	data, err := json.Marshal(valid)
+	if err != nil {
+		Fail(c, "500", "解析导入数据失败")
+		return
+	}
+	importKey, err := newImportKey()
+	if err != nil {
+		Fail(c, "500", "解析导入数据失败")
+		return
+	}
+	if err := h.redis.Set(ctx, userImportKeyPrefix+importKey, data, userImportTTL).Err(); err != nil {
+		Fail(c, "500", "缓存导入数据失败")
+		return
+	}
+	resp.ImportKey = importKey
+	OK(c, resp)
+}
+
+// ImportUser handles POST /system/user/import.
+// 按用户名/邮箱/手机号重复时的策略（跳过、修改、停止导入）在一个事务中写入数据。
+func (h *SystemUserHandler) ImportUser(c *gin.Context) {
+	operatorID := h.currentUserID(c)
+	if operatorID == 0 {
+		return
+	}
+	var req userImportReq
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.ImportKey) == "" {
+		Fail(c, "400", "请求参数不正确")
+		return
+	}
+	if req.DuplicateUser < importPolicySkip || req.DuplicateUser > importPolicyExit {
+		req.DuplicateUser = importPolicySkip
+	}
+	// 邮箱、手机号不支持修改策略
+	if req.DuplicateEmail != importPolicyExit {
+		req.DuplicateEmail = importPolicySkip
+	}
+	if req.DuplicatePhone != importPolicyExit {
+		req.DuplicatePhone = importPolicySkip
+	}
+	if req.DefaultStatus != 2 {
+		req.DefaultStatus = 1
+	}
+
+	ctx := c.Request.Context()
+	key := userImportKeyPrefix + strings.TrimSpace(req.ImportKey)
+	raw, err := h.redis.Get(ctx, key).Bytes()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		Fail(c, "500", "读取导入数据失败")
+		return
+	}
+	var rows []*userImportRow
+	if err != nil || json.Unmarshal(raw, &rows) != nil || len(rows) == 0 {
+		Fail(c, "400", "导入已过期，请重新上传")
+		return
+	}
+
+	existing, err := h.loadImportExisting(ctx, rows)
+	if err != nil {
+		Fail(c, "500", "导入用户失败")
+		return
+	}
+	roleIDs, err := h.lookupIDsByName(ctx, "sys_role", importRowValues(rows, func(r *userImportRow) string { return r.RoleName }))
+	if err != nil {
+		Fail(c, "500", "导入用户失败")
+		return
+	}
+	deptIDs, err := h.lookupIDsByName(ctx, "sys_dept", importRowValues(rows, func(r *userImportRow) string { return r.DeptName }))
+	if err != nil {
+		Fail(c, "500", "导入用户失败")
+		return
+	}
+
+	// 逐行决定新增、修改或跳过；任一行命中“停止导入”则整体退出。
+	type plannedRow struct {
+		row    *userImportRow
+		userID int64 // >0 表示修改已存在用户
+	}
+	var plans []plannedRow
+	for _, row := range rows {
+		targetID := int64(0)
+		userDup := false
+		if uid, ok := existing.usernames[row.Username]; ok {
+			userDup = true
+			targetID = uid
+		}
+		emailDup := existing.conflicts(existing.emails, row.Email, targetID)
+		phoneDup := existing.conflicts(existing.phones, row.Phone, targetID)
+
+		if (userDup && req.DuplicateUser == importPolicyExit) ||
+			(emailDup && req.DuplicateEmail == importPolicyExit) ||
+			(phoneDup && req.DuplicatePhone == importPolicyExit) {
+			Fail(c, "400", "数据不符合导入策略，已退出导入")
+			return
+		}
+		if (userDup && req.DuplicateUser == importPolicySkip) || emailDup || phoneDup {
+			continue
+		}
+		if _, ok := roleIDs[row.RoleName]; !ok {
+			continue
+		}
+		if _, ok := deptIDs[row.DeptName]; !ok {
+			continue
+		}
+		plans = append(plans, plannedRow{row: row, userID: targetID})
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		Fail(c, "500", "导入用户失败")
+		return
+	}
+	defer tx.Rollback()
+
+	const insertUser = `
+INSERT INTO sys_user (
+    id, username, nickname, password, gender, email, phone,
+    description, status, is_system, pwd_reset_time, dept_id,
+    create_user, create_time
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7,
+        $8, $9, FALSE, $10, $11,
+        $12, $13);
+`
+	const lockUserPassword = `SELECT COALESCE(password, '') FROM sys_user WHERE id = $1 AND is_system = FALSE FOR UPDATE;`
+	// 系统内置用户不允许通过导入修改。
+	const updateUser = `
+UPDATE sys_user
+SET nickname = $2, password = $3, gender = $4, email = $5, phone = $6,
+    description = $7, status = $8, pwd_reset_time = $9, dept_id = $10,
+    update_user = $11, update_time = $12
+WHERE id = $1 AND is_system = FALSE;
+`
+	const deleteUserRoles = `DELETE FROM sys_user_role WHERE user_id = $1;`
+	const insertUserRole = `
+INSERT INTO sys_user_role (id, user_id, role_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, role_id) DO NOTHING;
+`
+
+	now := time.Now()
+	result := UserImportResultResp{}
+	var updatedIDs []int64
+	for _, p := range plans {
+		row := p.row
+		userID := p.userID
+		if userID > 0 {
+			// 与重置密码一致：被覆盖的密码在同一事务中写入历史，提交后下线该用户。
+			var replaced string
+			err := tx.QueryRowContext(ctx, lockUserPassword, userID).Scan(&replaced)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				Fail(c, "500", "导入用户失败")
+				return
+			}
+			if _, err := tx.ExecContext(ctx, updateUser,
+				userID, row.Nickname, row.Password, row.Gender, nullIfEmpty(row.Email), nullIfEmpty(row.Phone),
+				row.Description, req.DefaultStatus, now, deptIDs[row.DeptName],
+				operatorID, now,
+			); err != nil {
+				Fail(c, "500", "导入用户失败")
+				return
+			}
+			if err := userpg.AddPasswordHistory(ctx, tx, userID, replaced); err != nil {
+				Fail(c, "500", "导入用户失败")
+				return
+			}
+			if _, err := tx.ExecContext(ctx, deleteUserRoles, userID); err != nil {
+				Fail(c, "500", "导入用户失败")
+				return
+			}
+			updatedIDs = append(updatedIDs, userID)
+			result.UpdateRows++
+		} else {
+			userID = id.Next()
+			if _, err := tx.ExecContext(ctx, insertUser,
+				userID, row.Username, row.Nickname, row.Password, row.Gender, nullIfEmpty(row.Email), nullIfEmpty(row.Phone),
+				row.Description, req.DefaultStatus, now, deptIDs[row.DeptName],
+				operatorID, now,
+			); err != nil {
+				Fail(c, "500", "导入用户失败")
+				return
+			}
+			result.InsertRows++
+		}
+		if _, err := tx.ExecContext(ctx, insertUserRole, id.Next(), userID, roleIDs[row.RoleName]); err != nil {
+			Fail(c, "500", "保存用户角色失败")
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		Fail(c, "500", "导入用户失败")
+		return
+	}
+	_ = h.redis.Del(ctx, key).Err()
+	if err := revokeUserSessions(ctx, h.online, h.tokenSvc, updatedIDs...); err != nil {
+		Fail(c, "500", "注销用户会话失败")
+		return
+	}
+
+	result.TotalRows = result.InsertRows + result.UpdateRows
+	OK(c, result)
+}
+
+// readImportRecords 按扩展名读取 CSV 或 XLSX 文件的全部行。
+func readImportRecords(header *multipart.FileHeader) ([][]string, error) {
+	f, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch extensionFromFilename(header.Filename) {
+	case "xlsx":
+		return excel.ReadFirstSheet(f, header.Size)
+	case "csv":
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		data = bytes.TrimPrefix(data, []byte("\uFEFF"))
+		r := csv.NewReader(bytes.NewReader(data))
+		r.FieldsPerRecord = -1
+		r.LazyQuotes = true
+		r.TrimLeadingSpace = true
+		return r.ReadAll()
+	default:
+		return nil, errors.New("unsupported import file type")
+	}
+}
+
+// mapImportRecords 以第一行非空数据为表头映射列，返回数据行与总行数（不含空行）。
+// 缺少必填列时返回 ok=false。
+func mapImportRecords(records [][]string) ([]*userImportRow, int, bool) {
+	headerIdx := -1
+	for i, rec := range records {
+		if !isBlankRecord(rec) {
+			headerIdx = i
+			break
+		}
+	}
+	if headerIdx < 0 {
+		return nil, 0, false
+	}
+
+	colIdx := make(map[string]int)
+	for i, cell := range records[headerIdx] {
+		name := strings.TrimSpace(cell)
+		for _, col := range userImportColumns {
+			for _, h := range col.headers {
+				if strings.EqualFold(name, h) {
+					if _, dup := colIdx[col.field]; !dup {
+						colIdx[col.field] = i
+					}
+				}
+			}
+		}
+	}
+	for _, field := range []string{"username", "nickname", "password", "deptName", "roleName"} {
+		if _, ok := colIdx[field]; !ok {
+			return nil, 0, false
+		}
+	}
+
+	cell := func(rec []string, field string) string {
+		i, ok := colIdx[field]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+	var rows []*userImportRow
+	for _, rec := range records[headerIdx+1:] {
+		if isBlankRecord(rec) {
+			continue
+		}
+		rows = append(rows, &userImportRow{
+			Username:    cell(rec, "username"),
+			Nickname:    cell(rec, "nickname"),
+			Password:    cell(rec, "password"),
+			DeptName:    cell(rec, "deptName"),
+			RoleName:    cell(rec, "roleName"),
+			Gender:      parseImportGender(cell(rec, "gender")),
+			Email:       cell(rec, "email"),
+			Phone:       cell(rec, "phone"),
+			Description: cell(rec, "description"),
+		})
+	}
+	return rows, len(rows), true
+}
+
+// filterImportRows 过滤校验不通过的行（含密码策略），并按用户名去重（保留首次出现）。
+func filterImportRows(rows []*userImportRow, policy security.PasswordPolicy) []*userImportRow {
+	seen := make(map[string]struct{}, len(rows))
+	valid := make([]*userImportRow, 0, len(rows))
+	for _, row := range rows {
+		if !usernamePattern.MatchString(row.Username) ||
+			!generalNamePattern.MatchString(row.Nickname) ||
+			row.Password == "" || row.DeptName == "" || row.RoleName == "" {
+			continue
+		}
+		if row.Email != "" && (len(row.Email) > 255 || !emailPattern.MatchString(row.Email)) {
+			continue
+		}
+		if row.Phone != "" && !phonePattern.MatchString(row.Phone) {
+			continue
+		}
+		if len([]rune(row.Description)) > 200 {
+			continue
+		}
+		if policy.Check(row.Username, row.Password) != nil {
+			continue
+		}
+		if _, ok := seen[row.Username]; ok {
+			continue
+		}
+		seen[row.Username] = struct{}{}
+		valid = append(valid, row)
+	}
+	return valid
+}
+
+// parseImportGender 将“男/女/未知”或 1/2/0 转换为性别值。
+func parseImportGender(v string) int16 {
+	switch v {
+	case "男", "1":
+		return 1
+	case "女", "2":
+		return 2
+	default:
+		return 0
+	}
+}
+
+func isBlankRecord(rec []string) bool {
+	for _, v := range rec {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func importRowValues(rows []*userImportRow, get func(*userImportRow) string) []string {
+	seen := make(map[string]struct{})
+	var values []string
+	for _, row := range rows {
+		v := get(row)
+		if v == "" {
+			continue
+		}
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		values = append(values, v)
+	}
+	return values
+}
+
+func missingNames(rows []*userImportRow, found map[string]int64, get func(*userImportRow) string) []string {
+	var missing []string
+	for _, name := range importRowValues(rows, get) {
+		if _, ok := found[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// lookupIDsByName 按名称查询角色或部门 ID；部门名称不唯一时取 ID 最小者。
+func (h *SystemUserHandler) lookupIDsByName(ctx context.Context, table string, names []string) (map[string]int64, error) {
+	result := make(map[string]int64, len(names))
+	if len(names) == 0 {
+		return result, nil
+	}
+	// table 仅为内部常量（sys_role / sys_dept），不来自用户输入。
+	rows, err := h.db.QueryContext(ctx, `SELECT name, id FROM `+table+` WHERE name = ANY($1) ORDER BY id DESC`, pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var idVal int64
+		if err := rows.Scan(&name, &idVal); err != nil {
+			return nil, err
+		}
+		result[name] = idVal
+	}
+	return result, rows.Err()
+}
+
+// importExisting 记录数据库中已存在的用户名、邮箱、手机号及其所属用户 ID。
+type importExisting struct {
+	usernames map[string]int64
+	emails    map[string]int64
+	phones    map[string]int64
+}
+
+// conflicts 判断 value 是否已被 ownerID 以外的用户占用。
+func (e *importExisting) conflicts(m map[string]int64, value string, ownerID int64) bool {
+	if value == "" {
+		return false
+	}
+	uid, ok := m[value]
+	return ok && uid != ownerID
+}
+
+func (h *SystemUserHandler) loadImportExisting(ctx context.Context, rows []*userImportRow) (*importExisting, error) {
+	e := &importExisting{
+		usernames: make(map[string]int64),
+		emails:    make(map[string]int64),
+		phones:    make(map[string]int64),
+	}
+	queries := []struct {
+		column string
+		values []string
+		target map[string]int64
+	}{
+		{"username", importRowValues(rows, func(r *userImportRow) string { return r.Username }), e.usernames},
+		{"email", importRowValues(rows, func(r *userImportRow) string { return r.Email }), e.emails},
+		{"phone", importRowValues(rows, func(r *userImportRow) string { return r.Phone }), e.phones},
+	}
+	for _, q := range queries {
+		if len(q.values) == 0 {
+			continue
+		}
+		if err := scanExistingUsers(ctx, h.db, q.column, q.values, q.target); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+func scanExistingUsers(ctx context.Context, db *sql.DB, column string, values []string, target map[string]int64) error {
+	rows, err := db.QueryContext(ctx, `SELECT `+column+`, id FROM sys_user WHERE `+column+` = ANY($1)`, pq.Array(values))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v string
+		var uid int64
+		if err := rows.Scan(&v, &uid); err != nil {
+			return err
+		}
+		target[v] = uid
+	}
+	return rows.Err()
+}
+
+func newImportKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
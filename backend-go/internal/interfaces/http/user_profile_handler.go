@@ -0,0 +1,348 @@
+package http
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"voc-go-backend/internal/domain/user"
+	"voc-go-backend/internal/infrastructure/security"
+	"voc-go-backend/internal/infrastructure/trace"
+)
+
+// avatarParentPath 头像文件在存储中的目录。
+const avatarParentPath = "/avatar"
+
+// avatarSupportSuffix 与 Java avatarSupportSuffix 配置保持一致。
+var avatarSupportSuffix = []string{"jpg", "jpeg", "png", "gif"}
+
+var (
+	phonePattern = regexp.MustCompile(`^1[3-9]\d{9}$`)
+	emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+// userBasicInfoUpdateReq 对应 PATCH /user/profile/basic/info。
+type userBasicInfoUpdateReq struct {
+	Nickname string `json:"nickname"`
+	Gender   *int16 `json:"gender"`
+}
+
+// userPasswordUpdateReq 对应 PATCH /user/profile/password（均为 RSA 加密）。
+type userPasswordUpdateReq struct {
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+// userPhoneUpdateReq 对应 PATCH /user/profile/phone。
+type userPhoneUpdateReq struct {
+	Phone       string `json:"phone"`
+	OldPassword string `json:"oldPassword"`
+}
+
+// userEmailUpdateReq 对应 PATCH /user/profile/email。
+type userEmailUpdateReq struct {
+	Email       string `json:"email"`
+	OldPassword string `json:"oldPassword"`
+}
+
+// AvatarResp 修改头像返回值。
+type AvatarResp struct {
+	Avatar string `json:"avatar"`
+}
+
+// UserProfileHandler 提供 /user/profile 个人中心接口，对齐 Java UserProfileController。
+type UserProfileHandler struct {
+	users        user.Repository
+	rsaDecryptor *security.RSADecryptor
+	hasher       security.PasswordHasher
+	verifier     security.PasswordVerifier
+	pwdPolicy    *security.PasswordPolicyValidator
+	files        *FileHandler
+	tokenSvc     *security.TokenService
+	online       OnlineStore
+}
+
+// NewUserProfileHandler 创建个人中心 handler。
+func NewUserProfileHandler(
+	users user.Repository,
+	rsa *security.RSADecryptor,
+	hasher security.PasswordHasher,
+	verifier security.PasswordVerifier,
+	pwdPolicy *security.PasswordPolicyValidator,
+	files *FileHandler,
+	tokenSvc *security.TokenService,
+	online OnlineStore,
+) *UserProfileHandler {
+	return &UserProfileHandler{
+		users:        users,
+		rsaDecryptor: rsa,
+		hasher:       hasher,
+		verifier:     verifier,
+		pwdPolicy:    pwdPolicy,
+		files:        files,
+		tokenSvc:     tokenSvc,
+		online:       online,
+	}
+}
+
+// RegisterUserProfileRoutes 注册个人中心路由，仅需登录。
+// 修改密码接口允许密码已过期的用户访问，以完成强制改密。
+func (h *UserProfileHandler) RegisterUserProfileRoutes(r *gin.Engine, am *AuthMiddleware) {
+	r.PATCH("/user/profile/avatar", Audit("个人中心", "修改头像", AuditNoRequestBody), am.RequireLogin(), h.UpdateAvatar)
+	r.PATCH("/user/profile/basic/info", Audit("个人中心", "修改基础信息"), am.RequireLogin(), h.UpdateBasicInfo)
+	r.PATCH("/user/profile/password", Audit("个人中心", "修改密码"), am.RequireLoginAllowPwdExpired(), h.UpdatePassword)
+	r.PATCH("/user/profile/phone", Audit("个人中心", "修改手机号"), am.RequireLogin(), h.UpdatePhone)
+	r.PATCH("/user/profile/email", Audit("个人中心", "修改邮箱"), am.RequireLogin(), h.UpdateEmail)
+}
+
+// UpdateAvatar 处理 PATCH /user/profile/avatar（multipart，字段 avatarFile）。
+func (h *UserProfileHandler) UpdateAvatar(c *gin.Context) {
+	userID := contextUserID(c)
+	header, err := c.FormFile("avatarFile")
+	if err != nil || header.Size == 0 {
+		Fail(c, "400", "头像不能为空")
+		return
+	}
+	ext := extensionFromFilename(header.Filename)
+	supported := false
+	for _, s := range avatarSupportSuffix {
+		if ext == s {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		Fail(c, "400", "头像仅支持 "+strings.Join(avatarSupportSuffix, ",")+" 格式的图片")
+		return
+	}
+
+	ctx := c.Request.Context()
+	uploaded, err := h.files.saveUpload(ctx, header, avatarParentPath, userID)
+	if err != nil {
+		var quotaErr *fileQuotaError
+		if errors.As(err, &quotaErr) {
+			Fail(c, "400", quotaErr.msg)
+			return
+		}
+		Fail(c, "500", "上传头像失败")
+		return
+	}
+	oldAvatar, err := h.users.UpdateAvatar(ctx, userID, uploaded.URL)
+	if err != nil {
+		Fail(c, "500", "修改头像失败")
+		return
+	}
+	// 原头像移入回收站（尽力而为）
+	if err := h.files.recycleFileByURL(ctx, avatarParentPath, userID, oldAvatar); err != nil {
+		trace.Printf(ctx, "[avatar] recycle failed: user=%d err=%v", userID, err)
+	}
+	OK(c, AvatarResp{Avatar: uploaded.URL})
+}
+
+// UpdateBasicInfo 处理 PATCH /user/profile/basic/info。
+func (h *UserProfileHandler) UpdateBasicInfo(c *gin.Context) {
+	var req userBasicInfoUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Fail(c, "400", "请求参数不正确")
+		return
+	}
+	req.Nickname = strings.TrimSpace(req.Nickname)
+	if req.Nickname == "" {
+		Fail(c, "400", "昵称不能为空")
+		return
+	}
+	if len([]rune(req.Nickname)) > 30 {
+		Fail(c, "400", "昵称长度不能超过 30 个字符")
+		return
+	}
+	if req.Gender == nil || *req.Gender < 0 || *req.Gender > 2 {
+		Fail(c, "400", "性别无效")
+		return
+	}
+	if err := h.users.UpdateBasicInfo(c.Request.Context(), contextUserID(c), req.Nickname, *req.Gender); err != nil {
+		Fail(c, "500", "修改基础信息失败")
+		return
+	}
+	OK(c, true)
+}
+
+// UpdatePassword 处理 PATCH /user/profile/password。
+// 修改成功后该用户的全部 Token 失效，需要重新登录（与 Java StpUtil.logout 一致）。
+func (h *UserProfileHandler) UpdatePassword(c *gin.Context) {
+	var req userPasswordUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Fail(c, "400", "请求参数不正确")
+		return
+	}
+	rawOld, err := h.rsaDecryptor.DecryptBase64(req.OldPassword)
+	if err != nil {
+		Fail(c, "400", "当前密码解密失败")
+		return
+	}
+	rawNew, err := h.rsaDecryptor.DecryptBase64(req.NewPassword)
+	if err != nil || rawNew == "" {
+		Fail(c, "400", "新密码解密失败")
+		return
+	}
+	if rawNew == rawOld {
+		Fail(c, "400", "新密码不能与当前密码相同")
+		return
+	}
+
+	ctx := c.Request.Context()
+	u, ok := h.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+	if u.Password != "" {
+		matched, err := h.verifier.Verify(rawOld, u.Password)
+		if err != nil || !matched {
+			Fail(c, "400", "当前密码不正确")
+			return
+		}
+	}
+	if !checkPasswordPolicy(c, h.pwdPolicy, u.ID, u.Username, u.Password, rawNew) {
+		return
+	}
+
+	encoded, err := h.hasher.Hash(rawNew)
+	if err != nil {
+		Fail(c, "500", "密码加密失败")
+		return
+	}
+	// 历史密码与新密码在同一事务中写入。
+	if err := h.users.UpdatePassword(ctx, u.ID, encoded, time.Now(), u.ID); err != nil {
+		Fail(c, "500", "修改密码失败")
+		return
+	}
+
+	// 修改密码后登出当前会话，并下线该用户的其他会话（与管理员重置密码一致）。
+	token := bearerToken(c)
+	if err := h.tokenSvc.Revoke(ctx, token); err != nil {
+		Fail(c, "500", "退出登录失败")
+		return
+	}
+	if h.online != nil {
+		_ = h.online.RemoveByToken(ctx, token)
+	}
+	if err := revokeUserSessions(ctx, h.online, h.tokenSvc, u.ID); err != nil {
+		Fail(c, "500", "注销用户会话失败")
+		return
+	}
+	OK(c, true)
+}
+
+// UpdatePhone 处理 PATCH /user/profile/phone。
+// Go 端暂未提供短信验证码发送，修改手机号仅校验当前密码。
+func (h *UserProfileHandler) UpdatePhone(c *gin.Context) {
+	var req userPhoneUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Fail(c, "400", "请求参数不正确")
+		return
+	}
+	req.Phone = strings.TrimSpace(req.Phone)
+	if !phonePattern.MatchString(req.Phone) {
+		Fail(c, "400", "手机号格式不正确")
+		return
+	}
+	u, ok := h.verifyOldPassword(c, req.OldPassword)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	exists, err := h.users.ExistsByPhone(ctx, req.Phone, u.ID)
+	if err != nil {
+		Fail(c, "500", "修改手机号失败")
+		return
+	}
+	if exists {
+		Fail(c, "400", "手机号已绑定到其他账号，请更换其他手机号")
+		return
+	}
+	if err := h.users.UpdatePhone(ctx, u.ID, req.Phone); err != nil {
+		Fail(c, "500", "修改手机号失败")
+		return
+	}
+	OK(c, true)
+}
+
+// UpdateEmail 处理 PATCH /user/profile/email。
+// Go 端暂未提供邮件验证码发送，修改邮箱仅校验当前密码。
+func (h *UserProfileHandler) UpdateEmail(c *gin.Context) {
+	var req userEmailUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Fail(c, "400", "请求参数不正确")
+		return
+	}
+	req.Email = strings.TrimSpace(req.Email)
+	if !emailPattern.MatchString(req.Email) {
+		Fail(c, "400", "邮箱格式不正确")
+		return
+	}
+	u, ok := h.verifyOldPassword(c, req.OldPassword)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	exists, err := h.users.ExistsByEmail(ctx, req.Email, u.ID)
+	if err != nil {
+		Fail(c, "500", "修改邮箱失败")
+		return
+	}
+	if exists {
+		Fail(c, "400", "邮箱已绑定到其他账号，请更换其他邮箱")
+		return
+	}
+	if err := h.users.UpdateEmail(ctx, u.ID, req.Email); err != nil {
+		Fail(c, "500", "修改邮箱失败")
+		return
+	}
+	OK(c, true)
+}
+
+// loadCurrentUser 读取当前登录用户。
+func (h *UserProfileHandler) loadCurrentUser(c *gin.Context) (*user.User, bool) {
+	u, err := h.users.GetByID(c.Request.Context(), contextUserID(c))
+	if err != nil {
+		Fail(c, "500", "查询用户失败")
+		return nil, false
+	}
+	if u == nil {
+		Fail(c, "401", "未授权，请重新登录")
+		return nil, false
+	}
+	return u, true
+}
+
+// verifyOldPassword 解密并校验当前密码，用于修改手机号、邮箱等敏感操作。
+func (h *UserProfileHandler) verifyOldPassword(c *gin.Context, encrypted string) (*user.User, bool) {
+	rawOld, err := h.rsaDecryptor.DecryptBase64(encrypted)
+	if err != nil || strings.TrimSpace(rawOld) == "" {
+		Fail(c, "400", "当前密码解密失败")
+		return nil, false
+	}
+	u, ok := h.loadCurrentUser(c)
+	if !ok {
+		return nil, false
+	}
+	matched, err := h.verifier.Verify(rawOld, u.Password)
+	if err != nil || !matched {
+		Fail(c, "400", "当前密码不正确")
+		return nil, false
+	}
+	return u, true
+}
+
+// bearerToken 返回 Authorization 头中去掉 Bearer 前缀的 Token。
+func bearerToken(c *gin.Context) string {
+	token := strings.TrimSpace(c.GetHeader("Authorization"))
+	if strings.HasPrefix(strings.ToLower(token), "bearer ") {
+		token = strings.TrimSpace(token[7:])
+	}
+	return token
+}